@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/bits"
+	"os"
+	"time"
+)
+
+// AchievementsVersion is the progress file format written by this build.
+const AchievementsVersion = 1
+
+// Achievement criteria.
+const (
+	// CriterionCount unlocks after Target matching events.
+	CriterionCount = "count"
+	// CriterionDistinct unlocks after matching events with Target
+	// different asteroid sizes or power-up types.
+	CriterionDistinct = "distinct"
+	// CriterionSurvive unlocks after Target seconds of one run without a
+	// matching event; with no event, after Target seconds alive.
+	CriterionSurvive = "survive"
+)
+
+// AchievementDef is one achievement from assets/achievements.json. Its
+// name and description are the catalog messages achievement.<id>.name and
+// achievement.<id>.desc, the description formatted with Target.
+type AchievementDef struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Event   string `json:"event,omitempty"`
+	Size    string `json:"size,omitempty"`     // only asteroids of this size
+	PowerUp string `json:"power_up,omitempty"` // only this power-up
+	// Scope is "run" for progress that starts over every run, or
+	// "lifetime" (the default). Survive criteria are always per run.
+	Scope  string `json:"scope,omitempty"`
+	Target int    `json:"target"`
+
+	kind    EventKind
+	hasKind bool
+	size    AsteroidSize // sizeCount for any
+	powerUp PowerUpType  // powerUpCount for any
+}
+
+// matches reports whether e is the kind of event the achievement counts.
+func (d *AchievementDef) matches(e Event) bool {
+	return d.hasKind && e.Kind == d.kind &&
+		(d.size == sizeCount || e.Kind == EventAsteroidDestroyed && sizeClass(e.Size) == d.size) &&
+		(d.powerUp == powerUpCount || e.Kind == EventPowerUp && e.PowerUp == d.powerUp)
+}
+
+// perRun reports whether progress starts over with every run.
+func (d *AchievementDef) perRun() bool {
+	return d.Scope == "run" || d.Type == CriterionSurvive
+}
+
+// Goal is the progress at which the achievement unlocks: events,
+// distinct values or ticks.
+func (d *AchievementDef) Goal() int {
+	if d.Type == CriterionSurvive {
+		return d.Target * TicksPerSecond
+	}
+	return d.Target
+}
+
+// validate checks the definition and resolves its names.
+func (d *AchievementDef) validate() error {
+	if d.ID == "" {
+		return errors.New("missing id")
+	}
+	if d.Type != CriterionCount && d.Type != CriterionDistinct && d.Type != CriterionSurvive {
+		return fmt.Errorf("%s: unknown type %q", d.ID, d.Type)
+	}
+	if d.Scope != "" && d.Scope != "run" && d.Scope != "lifetime" {
+		return fmt.Errorf("%s: unknown scope %q", d.ID, d.Scope)
+	}
+	if d.Target <= 0 {
+		return fmt.Errorf("%s: target must be positive", d.ID)
+	}
+	if d.Event == "" && d.Type != CriterionSurvive {
+		return fmt.Errorf("%s: missing event", d.ID)
+	}
+	if d.Event != "" {
+		d.kind = EventKind(indexOf(eventNames[:], d.Event))
+		if eventNames[d.kind] != d.Event {
+			return fmt.Errorf("%s: unknown event %q", d.ID, d.Event)
+		}
+		d.hasKind = true
+	}
+	d.size = sizeCount
+	if d.Size != "" {
+		d.size = AsteroidSize(indexOf(sizeNames[:], d.Size))
+		if sizeNames[d.size] != d.Size {
+			return fmt.Errorf("%s: unknown size %q", d.ID, d.Size)
+		}
+	}
+	d.powerUp = powerUpCount
+	if d.PowerUp != "" {
+		d.powerUp = PowerUpType(indexOf(powerUpNames[:], d.PowerUp))
+		if powerUpNames[d.powerUp] != d.PowerUp {
+			return fmt.Errorf("%s: unknown power-up %q", d.ID, d.PowerUp)
+		}
+	}
+	if d.Type == CriterionDistinct && d.kind != EventAsteroidDestroyed && d.kind != EventPowerUp {
+		return fmt.Errorf("%s: distinct needs asteroid_destroyed or power_up events", d.ID)
+	}
+	return nil
+}
+
+// ParseAchievements reads and validates achievement definitions.
+func ParseAchievements(data []byte) ([]AchievementDef, error) {
+	var file struct {
+		Version      int              `json:"version"`
+		Achievements []AchievementDef `json:"achievements"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("unsupported achievements version %d", file.Version)
+	}
+	var errs []error
+	seen := map[string]bool{}
+	for i := range file.Achievements {
+		d := &file.Achievements[i]
+		if err := d.validate(); err != nil {
+			errs = append(errs, err)
+		} else if seen[d.ID] {
+			errs = append(errs, fmt.Errorf("%s: duplicate id", d.ID))
+		}
+		seen[d.ID] = true
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return file.Achievements, nil
+}
+
+// AchievementProgress is how far an achievement has got. For per-run
+// achievements the saved Count is the best run so far.
+type AchievementProgress struct {
+	Count    int        `json:"count,omitempty"`
+	Seen     uint32     `json:"seen,omitempty"` // distinct values, as bits
+	Unlocked *time.Time `json:"unlocked,omitempty"`
+}
+
+// Achievements tracks progress towards every achievement.
+type Achievements struct {
+	Defs     []AchievementDef
+	progress map[string]*AchievementProgress // persisted
+	run      map[string]*AchievementProgress // the current run
+}
+
+// NewAchievements tracks defs with no progress.
+func NewAchievements(defs []AchievementDef) *Achievements {
+	return &Achievements{
+		Defs:     defs,
+		progress: map[string]*AchievementProgress{},
+		run:      map[string]*AchievementProgress{},
+	}
+}
+
+func state(m map[string]*AchievementProgress, id string) *AchievementProgress {
+	p, ok := m[id]
+	if !ok {
+		p = &AchievementProgress{}
+		m[id] = p
+	}
+	return p
+}
+
+// Progress returns the saved progress of the achievement with id.
+func (a *Achievements) Progress(id string) AchievementProgress {
+	if p, ok := a.progress[id]; ok {
+		return *p
+	}
+	return AchievementProgress{}
+}
+
+// UnlockedCount is how many achievements are unlocked.
+func (a *Achievements) UnlockedCount() int {
+	n := 0
+	for _, d := range a.Defs {
+		if a.Progress(d.ID).Unlocked != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// StartRun clears the progress of per-run achievements.
+func (a *Achievements) StartRun() {
+	if a != nil {
+		a.run = map[string]*AchievementProgress{}
+	}
+}
+
+// Record counts e and returns the achievements it unlocked. A nil
+// tracker records nothing, for simulations that should not award any.
+func (a *Achievements) Record(e Event) []*AchievementDef {
+	if a == nil {
+		return nil
+	}
+	var unlocked []*AchievementDef
+	for i := range a.Defs {
+		d := &a.Defs[i]
+		saved := state(a.progress, d.ID)
+		if saved.Unlocked != nil {
+			continue
+		}
+		p := saved
+		if d.perRun() {
+			p = state(a.run, d.ID)
+		}
+		switch d.Type {
+		case CriterionCount:
+			if d.matches(e) {
+				p.Count++
+			}
+		case CriterionDistinct:
+			if d.matches(e) {
+				bit := uint(e.PowerUp)
+				if e.Kind == EventAsteroidDestroyed {
+					bit = uint(sizeClass(e.Size))
+				}
+				p.Seen |= 1 << bit
+				p.Count = bits.OnesCount32(p.Seen)
+			}
+		case CriterionSurvive:
+			if e.Kind == EventTick {
+				p.Count++
+			} else if d.matches(e) {
+				p.Count = 0
+			}
+		}
+		if p != saved {
+			saved.Count = max(saved.Count, p.Count)
+		}
+		if p.Count >= d.Goal() {
+			now := time.Now()
+			saved.Unlocked = &now
+			unlocked = append(unlocked, d)
+		}
+	}
+	return unlocked
+}
+
+// RunProgress returns the progress of the current run, for saving it.
+func (a *Achievements) RunProgress() map[string]AchievementProgress {
+	if a == nil || len(a.run) == 0 {
+		return nil
+	}
+	m := make(map[string]AchievementProgress, len(a.run))
+	for id, p := range a.run {
+		m[id] = *p
+	}
+	return m
+}
+
+// SetRunProgress restores the progress of a saved run.
+func (a *Achievements) SetRunProgress(m map[string]AchievementProgress) {
+	a.StartRun()
+	if a == nil {
+		return
+	}
+	for id, p := range m {
+		a.run[id] = &p
+	}
+}
+
+type achievementsFile struct {
+	Version  int                             `json:"version"`
+	Progress map[string]*AchievementProgress `json:"progress"`
+}
+
+// LoadProgress reads saved progress from path. A missing file is not an
+// error; a file from a newer version is, so it is not overwritten.
+func (a *Achievements) LoadProgress(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var f achievementsFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	if f.Version > AchievementsVersion {
+		return fmt.Errorf("%s: achievements version %d is newer than this game (%d)", path, f.Version, AchievementsVersion)
+	}
+	if f.Progress != nil {
+		a.progress = f.Progress
+	}
+	return nil
+}
+
+// SaveProgress writes the saved progress to path.
+func (a *Achievements) SaveProgress(path string) error {
+	data, err := json.MarshalIndent(achievementsFile{Version: AchievementsVersion, Progress: a.progress}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// loadAchievements reads the achievement definitions from the assets.
+// Broken definitions are logged and the game runs without achievements.
+func loadAchievements(assets *AssetStore) *Achievements {
+	data, err := assets.Data("achievements")
+	var defs []AchievementDef
+	if err == nil {
+		defs, err = ParseAchievements(data)
+	}
+	if err != nil {
+		log.Printf("achievements: %v", err)
+	}
+	return NewAchievements(defs)
+}
+
+// saveAchievements writes achievement progress, if there is somewhere to
+// write it.
+func (g *Game) saveAchievements() {
+	if g.achievementsPath == "" {
+		return
+	}
+	if err := g.achievements.SaveProgress(g.achievementsPath); err != nil {
+		log.Printf("saving achievements: %v", err)
+	}
+}
+
+// unlocked announces an achievement and saves progress straight away.
+func (g *Game) unlocked(d *AchievementDef) {
+	g.toasts = append(g.toasts, toast{
+		text:   g.loc.T("achievement.toast", g.loc.T("achievement."+d.ID+".name")),
+		frames: ToastFrames,
+	})
+	g.mixer.Play(SoundAchievement)
+	g.saveAchievements()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// ToastFrames is how long an unlock notice stays on screen.
+const ToastFrames = 180
+
+// AchievementRows is how many achievements fit in the gallery before it
+// scrolls.
+const AchievementRows = 12
+
+// toast is a short notice shown over whatever scene is on screen.
+type toast struct {
+	text   string
+	frames int
+}
+
+func (g *Game) updateToasts() {
+	active := g.toasts[:0]
+	for _, t := range g.toasts {
+		t.frames--
+		if t.frames > 0 {
+			active = append(active, t)
+		}
+	}
+	g.toasts = active
+}
+
+// drawToasts stacks the toasts below the top of the screen, newest last.
+func (g *Game) drawToasts(screen *ebiten.Image) {
+	face := g.fonts.Normal
+	y := 70.0
+	for _, t := range g.toasts {
+		w, h := MeasureText(face, t.text)
+		fillRect(screen, (ScreenWidth-w)/2-16, y-8, w+32, h+16, g.theme.Background, 0.9)
+		DrawText(screen, t.text, face, (ScreenWidth-w)/2, y, AlignLeft, g.theme.Highlight)
+		y += h + 24
+	}
+}
+
+// AchievementsScene is the gallery of achievements and their progress.
+type AchievementsScene struct {
+	g    *Game
+	list List
+}
+
+func newAchievementsScene(g *Game) *AchievementsScene {
+	s := &AchievementsScene{g: g, list: List{Rows: AchievementRows}}
+	for i := range g.achievements.Defs {
+		d := &g.achievements.Defs[i]
+		s.list.Items = append(s.list.Items, &Button{
+			Text:   func() string { return g.loc.T("achievement." + d.ID + ".name") },
+			Detail: func() string { return g.achievementStatus(d) },
+		})
+	}
+	s.list.Items = append(s.list.Items, g.button("settings.back", func() { g.scenes.Pop(SlideOut) }))
+	return s
+}
+
+// achievementStatus is the unlock date, or the progress towards it.
+func (g *Game) achievementStatus(d *AchievementDef) string {
+	p := g.achievements.Progress(d.ID)
+	if p.Unlocked != nil {
+		return g.loc.T("achievements.unlocked_on", p.Unlocked.Format("2006-01-02"))
+	}
+	if d.Type == CriterionSurvive {
+		return formatDuration(RunStats{Ticks: p.Count}.Duration()) + " / " + formatDuration(RunStats{Ticks: d.Goal()}.Duration())
+	}
+	return fmt.Sprintf("%d / %d", min(p.Count, d.Goal()), d.Goal())
+}
+
+func (s *AchievementsScene) Update() error {
+	if s.g.nav.Back {
+		s.g.scenes.Pop(SlideOut)
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *AchievementsScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	title := g.loc.T("achievements.title", g.achievements.UnlockedCount(), len(g.achievements.Defs))
+	drawAnchoredText(screen, title, g.fonts.Large, AnchorTop, 0, 40, g.theme.Text)
+	s.list.Draw(screen, 110, g.menuStyle())
+	if s.list.Focus < len(g.achievements.Defs) {
+		d := g.achievements.Defs[s.list.Focus]
+		desc := g.loc.T("achievement."+d.ID+".desc", d.Target)
+		drawAnchoredText(screen, desc, g.fonts.Normal, AnchorBottom, 0, 50, g.theme.Highlight)
+	}
+}
+
+func (s *AchievementsScene) Overlay() bool { return false }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestShippedAchievements(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := assets.Data("achievements")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs, err := ParseAchievements(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range Languages {
+		c, err := loadCatalog(assets, lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range defs {
+			for _, key := range []string{"achievement." + d.ID + ".name", "achievement." + d.ID + ".desc"} {
+				if _, ok := c[key]; !ok {
+					t.Errorf("catálogo %s: falta a chave %q", lang, key)
+				}
+			}
+		}
+	}
+}
+
+func TestParseAchievementsRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		def  string
+	}{
+		{"tipo desconhecido", `{"id": "a", "type": "sum", "event": "shot", "target": 1}`},
+		{"evento desconhecido", `{"id": "a", "type": "count", "event": "jump", "target": 1}`},
+		{"sem evento", `{"id": "a", "type": "count", "target": 1}`},
+		{"tamanho desconhecido", `{"id": "a", "type": "count", "event": "asteroid_destroyed", "size": "huge", "target": 1}`},
+		{"power-up desconhecido", `{"id": "a", "type": "count", "event": "power_up", "power_up": "laser", "target": 1}`},
+		{"alvo zero", `{"id": "a", "type": "count", "event": "shot", "target": 0}`},
+		{"escopo desconhecido", `{"id": "a", "type": "count", "event": "shot", "scope": "week", "target": 1}`},
+		{"distinto de tiros", `{"id": "a", "type": "distinct", "event": "shot", "target": 2}`},
+		{"id repetido", `{"id": "a", "type": "count", "event": "shot", "target": 1}, {"id": "a", "type": "count", "event": "shot", "target": 2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"version": 1, "achievements": [` + tt.def + `]}`
+			if _, err := ParseAchievements([]byte(data)); err == nil {
+				t.Error("ParseAchievements() sem erro; esperado erro")
+			}
+		})
+	}
+}
+
+func TestAchievementCriteria(t *testing.T) {
+	small := Event{Kind: EventAsteroidDestroyed, Size: 25}
+	large := Event{Kind: EventAsteroidDestroyed, Size: 90}
+	tick := Event{Kind: EventTick}
+	hit := Event{Kind: EventDamage}
+	power := func(p PowerUpType) Event { return Event{Kind: EventPowerUp, PowerUp: p} }
+
+	tests := []struct {
+		name   string
+		def    string
+		events []Event
+		runs   []int // indexes before which a new run starts
+		want   bool
+	}{
+		{"conta só o tamanho pedido", `"type": "count", "event": "asteroid_destroyed", "size": "small", "target": 2`,
+			[]Event{small, large, small}, nil, true},
+		{"ainda falta", `"type": "count", "event": "asteroid_destroyed", "size": "small", "target": 2`,
+			[]Event{small, large, large}, nil, false},
+		{"total acumula entre partidas", `"type": "count", "event": "asteroid_destroyed", "target": 3`,
+			[]Event{small, large, small}, []int{1, 2}, true},
+		{"por partida recomeça", `"type": "count", "event": "asteroid_destroyed", "scope": "run", "target": 3`,
+			[]Event{small, large, small}, []int{2}, false},
+		{"todos os tipos", `"type": "distinct", "event": "power_up", "scope": "run", "target": 3`,
+			[]Event{power(PowerUpShield), power(PowerUpShield), power(PowerUpRapidFire), power(PowerUpExtraLife)}, nil, true},
+		{"tipos repetidos não contam", `"type": "distinct", "event": "power_up", "scope": "run", "target": 3`,
+			[]Event{power(PowerUpShield), power(PowerUpRapidFire), power(PowerUpRapidFire)}, nil, false},
+		{"sobreviver sem dano", `"type": "survive", "event": "damage", "target": 1`,
+			append(repeat(tick, TicksPerSecond-1), hit, tick), nil, false},
+		{"dano zera o tempo", `"type": "survive", "event": "damage", "target": 1`,
+			append(append(repeat(tick, 30), hit), repeat(tick, TicksPerSecond)...), nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defs, err := ParseAchievements([]byte(`{"version": 1, "achievements": [{"id": "a", ` + tt.def + `}]}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := NewAchievements(defs)
+			unlocked := false
+			for i, e := range tt.events {
+				for _, r := range tt.runs {
+					if r == i {
+						a.StartRun()
+					}
+				}
+				if len(a.Record(e)) > 0 {
+					unlocked = true
+				}
+			}
+			if unlocked != tt.want || (a.Progress("a").Unlocked != nil) != tt.want {
+				t.Errorf("desbloqueada = %v; esperado %v", unlocked, tt.want)
+			}
+		})
+	}
+}
+
+func repeat(e Event, n int) []Event {
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = e
+	}
+	return events
+}
+
+func TestAchievementUnlockedDuringPlay(t *testing.T) {
+	g, null := newTestGame()
+	g.achievementsPath = filepath.Join(t.TempDir(), "achievements.json")
+	pos := Vector{X: 300, Y: 300}
+	g.asteroids = append(g.asteroids, Asteroid{position: pos, size: 25})
+	b := g.bulletPool.Get()
+	b.position = pos
+	g.bullets = append(g.bullets, b)
+	g.updateBullets()
+
+	if len(g.toasts) != 1 || null.Count(SoundAchievement) != 1 {
+		t.Fatalf("%d avisos e %d sons; esperado 1 de cada", len(g.toasts), null.Count(SoundAchievement))
+	}
+	for i := 0; i < ToastFrames; i++ {
+		g.updateToasts()
+	}
+	if len(g.toasts) != 0 {
+		t.Error("o aviso não sumiu")
+	}
+
+	saved := NewAchievements(g.achievements.Defs)
+	if err := saved.LoadProgress(g.achievementsPath); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Progress("first_blood").Unlocked == nil {
+		t.Error("a conquista desbloqueada não foi salva")
+	}
+	if p := saved.Progress("pebble_crusher"); p.Count != 1 || p.Unlocked != nil {
+		t.Errorf("progresso = %+v; esperado 1 asteroide pequeno", p)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed assets
+var embeddedFiles embed.FS
+
+// ManifestVersion is the newest asset manifest format this build reads.
+const ManifestVersion = 1
+
+const manifestFile = "manifest.json"
+
+// AssetType tells the store how an asset is decoded.
+type AssetType string
+
+const (
+	AssetImage AssetType = "image"
+	AssetFont  AssetType = "font"
+	AssetData  AssetType = "data"
+)
+
+// ManifestEntry maps an asset name used by the code to a file in a pack.
+type ManifestEntry struct {
+	Name string    `json:"name"`
+	Type AssetType `json:"type"`
+	Path string    `json:"path"`
+}
+
+// Manifest lists the assets a pack provides.
+type Manifest struct {
+	Version int             `json:"version"`
+	Assets  []ManifestEntry `json:"assets"`
+}
+
+// entry returns the manifest entry called name.
+func (m Manifest) entry(name string) (ManifestEntry, bool) {
+	for _, e := range m.Assets {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return ManifestEntry{}, false
+}
+
+// Validate checks the manifest and that every file it names exists in fsys.
+func (m Manifest) Validate(fsys fs.FS) error {
+	var errs []error
+	if m.Version < 1 || m.Version > ManifestVersion {
+		errs = append(errs, fmt.Errorf("unsupported manifest version %d", m.Version))
+	}
+	seen := map[string]bool{}
+	for i, e := range m.Assets {
+		if e.Name == "" {
+			errs = append(errs, fmt.Errorf("asset %d: missing name", i))
+		} else if seen[e.Name] {
+			errs = append(errs, fmt.Errorf("asset %q: duplicate name", e.Name))
+		}
+		seen[e.Name] = true
+		if e.Type != AssetImage && e.Type != AssetFont && e.Type != AssetData {
+			errs = append(errs, fmt.Errorf("asset %q: unknown type %q", e.Name, e.Type))
+		}
+		if !fs.ValidPath(e.Path) || e.Path == "." {
+			errs = append(errs, fmt.Errorf("asset %q: invalid path %q", e.Name, e.Path))
+		} else if _, err := fs.Stat(fsys, e.Path); err != nil {
+			errs = append(errs, fmt.Errorf("asset %q: %v", e.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// readManifest loads and validates the manifest at the root of fsys.
+func readManifest(fsys fs.FS) (Manifest, error) {
+	var m Manifest
+	data, err := fs.ReadFile(fsys, manifestFile)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("%s: %v", manifestFile, err)
+	}
+	return m, m.Validate(fsys)
+}
+
+// assetSource is one layer assets are read from: an external pack
+// directory or the defaults embedded in the binary.
+type assetSource struct {
+	name     string
+	fsys     fs.FS
+	manifest Manifest
+}
+
+// AssetStore resolves asset names through the packs, falling back to the
+// embedded defaults and finally to generated placeholders. In dev mode
+// Poll picks up edits to pack files so they can be reloaded live.
+type AssetStore struct {
+	Dev      bool
+	packDirs []string
+	sources  []*assetSource // highest priority first, embedded last
+	images   map[string]*ebiten.Image
+	modTimes map[string]time.Time
+}
+
+// NewAssetStore builds a store from the embedded defaults plus the given
+// pack directories; later packs override earlier ones. Problems with a
+// pack are returned as an error but never stop the store from working:
+// the broken part is skipped and the defaults are used instead.
+func NewAssetStore(packDirs ...string) (*AssetStore, error) {
+	s := &AssetStore{packDirs: packDirs}
+	err := s.load()
+	s.modTimes = s.snapshot()
+	return s, err
+}
+
+func (s *AssetStore) load() error {
+	var errs []error
+	embedded, err := fs.Sub(embeddedFiles, "assets")
+	if err != nil {
+		return err
+	}
+	defaults, err := readManifest(embedded)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("embedded assets: %v", err))
+	}
+	s.sources = []*assetSource{{name: "embedded", fsys: embedded, manifest: defaults}}
+	for _, dir := range s.packDirs {
+		if _, err := os.Stat(dir); err != nil {
+			errs = append(errs, fmt.Errorf("asset pack: %v", err))
+			continue
+		}
+		src := &assetSource{name: dir, fsys: os.DirFS(dir)}
+		// A pack without a manifest, or with a broken one, can still
+		// override the defaults file by file.
+		m, err := readManifest(src.fsys)
+		if err == nil {
+			src.manifest = m
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("asset pack %s: %v", dir, err))
+		}
+		s.sources = append([]*assetSource{src}, s.sources...)
+	}
+	s.images = map[string]*ebiten.Image{}
+	return errors.Join(errs...)
+}
+
+// resolve finds the source and entry that provide name. A pack provides
+// an asset when its manifest names it, or when it holds a file at the
+// path the embedded manifest uses for it.
+func (s *AssetStore) resolve(name string) (*assetSource, ManifestEntry, bool) {
+	def, hasDefault := s.sources[len(s.sources)-1].manifest.entry(name)
+	for _, src := range s.sources {
+		if e, ok := src.manifest.entry(name); ok {
+			return src, e, true
+		}
+		if hasDefault {
+			if _, err := fs.Stat(src.fsys, def.Path); err == nil {
+				return src, def, true
+			}
+		}
+	}
+	return nil, ManifestEntry{}, false
+}
+
+// Data returns the raw bytes of the named asset.
+func (s *AssetStore) Data(name string) ([]byte, error) {
+	src, e, ok := s.resolve(name)
+	if !ok {
+		return nil, fmt.Errorf("asset %q not found", name)
+	}
+	data, err := fs.ReadFile(src.fsys, e.Path)
+	if err != nil {
+		return nil, fmt.Errorf("asset %q from %s: %v", name, src.name, err)
+	}
+	return data, nil
+}
+
+// Image returns the named image. Missing or undecodable images are
+// logged and replaced by a placeholder so the game keeps running.
+func (s *AssetStore) Image(name string) *ebiten.Image {
+	if img, ok := s.images[name]; ok {
+		return img
+	}
+	img, err := s.decodeImage(name)
+	if err != nil {
+		log.Printf("using placeholder: %v", err)
+		img = placeholderImage(64)
+	}
+	s.images[name] = img
+	return img
+}
+
+func (s *AssetStore) decodeImage(name string) (*ebiten.Image, error) {
+	data, err := s.Data(name)
+	if err != nil {
+		return nil, err
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("asset %q: %v", name, err)
+	}
+	return ebiten.NewImageFromImage(decoded), nil
+}
+
+// snapshot records the modification time of every file in the packs.
+func (s *AssetStore) snapshot() map[string]time.Time {
+	times := map[string]time.Time{}
+	for _, dir := range s.packDirs {
+		fs.WalkDir(os.DirFS(dir), ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				times[dir+"/"+p] = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return times
+}
+
+// Poll reloads the packs when a file in them was added, removed or
+// modified since the last call, and reports whether it did. It does
+// nothing outside dev mode.
+func (s *AssetStore) Poll() bool {
+	if !s.Dev {
+		return false
+	}
+	now := s.snapshot()
+	changed := len(now) != len(s.modTimes)
+	for p, t := range now {
+		if old, ok := s.modTimes[p]; !ok || !old.Equal(t) {
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	s.modTimes = now
+	if err := s.load(); err != nil {
+		log.Printf("reloading assets: %v", err)
+	}
+	return true
+}
+
+// placeholderImage draws a magenta and black checkerboard, the usual
+// "missing texture" marker.
+func placeholderImage(size int) *ebiten.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	cell := size / 8
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if (x/cell+y/cell)%2 == 0 {
+				img.Set(x, y, color.RGBA{255, 0, 255, 255})
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+	return ebiten.NewImageFromImage(img)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmbeddedManifestIsValid(t *testing.T) {
+	if _, err := NewAssetStore(); err != nil {
+		t.Fatalf("manifesto embutido inválido: %v", err)
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.png"), "")
+	tests := []struct {
+		name  string
+		m     Manifest
+		valid bool
+	}{
+		{"válido", Manifest{Version: 1, Assets: []ManifestEntry{{"player", AssetImage, "a.png"}}}, true},
+		{"versão futura", Manifest{Version: ManifestVersion + 1}, false},
+		{"nome repetido", Manifest{Version: 1, Assets: []ManifestEntry{{"x", AssetImage, "a.png"}, {"x", AssetImage, "a.png"}}}, false},
+		{"tipo desconhecido", Manifest{Version: 1, Assets: []ManifestEntry{{"x", "sound", "a.png"}}}, false},
+		{"arquivo ausente", Manifest{Version: 1, Assets: []ManifestEntry{{"x", AssetImage, "b.png"}}}, false},
+		{"caminho fora do pacote", Manifest{Version: 1, Assets: []ManifestEntry{{"x", AssetImage, "../a.png"}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.m.Validate(os.DirFS(dir))
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v; válido esperado: %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestAssetPackOverrides(t *testing.T) {
+	byPath := t.TempDir()
+	writeFile(t, filepath.Join(byPath, "nave.png"), "from path")
+	byName := t.TempDir()
+	writeFile(t, filepath.Join(byName, "ship.png"), "from manifest")
+	writeFile(t, filepath.Join(byName, manifestFile), `{"version": 1, "assets": [{"name": "player", "type": "image", "path": "ship.png"}]}`)
+
+	s, err := NewAssetStore(byPath, byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := s.Data("player"); string(data) != "from manifest" {
+		t.Errorf("player = %q; esperado o último pacote", data)
+	}
+
+	s, _ = NewAssetStore(byName, byPath)
+	if data, _ := s.Data("player"); string(data) != "from path" {
+		t.Errorf("player = %q; esperado o último pacote", data)
+	}
+	if _, err := s.Data("asteroid"); err != nil {
+		t.Errorf("asteroid deveria vir dos padrões embutidos: %v", err)
+	}
+}
+
+func TestBrokenPackFallsBack(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, manifestFile), `{"version": 1, "assets": [{"name": "player", "type": "image", "path": "missing.png"}]}`)
+
+	s, err := NewAssetStore(dir, filepath.Join(dir, "does-not-exist"))
+	if err == nil {
+		t.Error("esperado erro para pacote quebrado")
+	}
+	if _, err := s.Data("player"); err != nil {
+		t.Errorf("player deveria cair para o padrão embutido: %v", err)
+	}
+	if img := s.Image("does-not-exist"); img == nil || img.Bounds().Dx() != 64 {
+		t.Error("imagem ausente deveria virar um placeholder")
+	}
+}
+
+func TestAssetHotReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nave.png")
+	writeFile(t, path, "v1")
+	s, _ := NewAssetStore(dir)
+
+	if s.Poll() {
+		t.Error("Poll fora do modo dev não deveria recarregar")
+	}
+	s.Dev = true
+	if s.Poll() {
+		t.Error("Poll sem mudanças não deveria recarregar")
+	}
+	writeFile(t, path, "v2")
+	later := time.Now().Add(time.Second)
+	os.Chtimes(path, later, later)
+	if !s.Poll() {
+		t.Fatal("Poll deveria notar o arquivo alterado")
+	}
+	if data, _ := s.Data("player"); string(data) != "v2" {
+		t.Errorf("player = %q; esperado v2", data)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import "github.com/hajimehoshi/ebiten/v2"
 
 type Asteroid struct {
+	id       uint32 // tells entities apart in network snapshots
 	position Vector
 	velocity Vector
 	size     float64
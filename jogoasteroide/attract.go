@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	// AttractDelay is how long the title screen waits for input before it
+	// starts playing demos.
+	AttractDelay = 10 * TicksPerSecond
+	// DemoMaxSteps cuts a bot-driven demo short.
+	DemoMaxSteps = 60 * TicksPerSecond
+	// blinkFrames is how long the "press enter" prompt stays on, then off.
+	blinkFrames = TicksPerSecond / 2
+)
+
+// demoAssets are the recorded demos in the asset store, played in turn
+// with a bot-driven run after the last.
+var demoAssets = []string{"demo-1", "demo-2", "demo-3"}
+
+// Demo plays a run by itself behind the title screen: a bundled replay,
+// or a bot flying every ship. It is silent and saves nothing.
+type Demo struct {
+	game   *Game
+	inputs []Input // a replay's, or nil when bots play
+	bots   []*Bot
+}
+
+// newDemo returns the nth demo (counting round the list) of those g can
+// play.
+func (g *Game) newDemo(n int) *Demo {
+	d := &Demo{game: g.mirrorGame()}
+	d.game.mixer = newSilentMixer(&d.game.settings.Audio)
+	if i := n % (len(demoAssets) + 1); i < len(demoAssets) {
+		r, err := loadDemo(g.assets, demoAssets[i])
+		if err == nil {
+			d.game.ResetRun(r.Config)
+			d.inputs = r.Inputs
+			return d
+		}
+		log.Printf("demo: %v", err)
+	}
+	d.game.ResetRun(RunConfig{Mode: ModeClassic, Seed: rand.Uint64(), Difficulty: DifficultyNormal})
+	d.bots = []*Bot{NewBot(BotHard, 0, d.game.config.Seed)}
+	return d
+}
+
+// loadDemo reads the replay stored as the asset name.
+func loadDemo(assets *AssetStore, name string) (Replay, error) {
+	data, err := assets.Data(name)
+	if err != nil {
+		return Replay{}, err
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Replay{}, fmt.Errorf("%s: %v", name, err)
+	}
+	if r.Version != ReplayVersion {
+		return Replay{}, fmt.Errorf("%s: replay version %d (this game reads %d)", name, r.Version, ReplayVersion)
+	}
+	if n := r.Config.playerCount(); len(r.Inputs) == 0 || len(r.Inputs)%n != 0 {
+		return Replay{}, fmt.Errorf("%s: %d inputs do not split between %d players", name, len(r.Inputs), n)
+	}
+	return r, nil
+}
+
+// Update plays the demo on by a frame and reports whether it goes on.
+func (d *Demo) Update() bool {
+	g := d.game
+	if g.camera.Update() {
+		n := len(g.players)
+		switch {
+		case !g.running:
+			return false
+		case d.bots != nil:
+			if g.tick >= DemoMaxSteps {
+				return false
+			}
+			in := make([]Input, n)
+			for i, b := range d.bots {
+				in[i] = b.Input(g)
+			}
+			g.updatePlaying(in...)
+		case g.tick*n < len(d.inputs):
+			g.updatePlaying(d.inputs[g.tick*n : g.tick*n+n]...)
+		case !g.dying:
+			return false
+		default:
+			// Inputs used up: let the final hit play out.
+			g.updatePlaying()
+		}
+	}
+	return true
+}
+
+// Draw draws the demo run.
+func (d *Demo) Draw(screen *ebiten.Image) {
+	d.game.drawPlaying(screen)
+}
+
+// anyInputPressed reports whether a key, mouse button or gamepad button
+// went down this frame.
+func anyInputPressed() bool {
+	if len(inpututil.AppendJustPressedKeys(nil)) > 0 || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return true
+	}
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if len(inpututil.AppendJustPressedStandardGamepadButtons(id, nil)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// updateAttract counts idle frames on the title screen, starting the
+// demos after AttractDelay of them and stopping them on any input. It
+// reports whether the input went to stopping a demo, so the menu should
+// ignore it.
+func (s *MenuScene) updateAttract() bool {
+	g := s.g
+	if anyInputPressed() || g.nav != (Nav{}) {
+		s.idle = 0
+		if s.demo != nil {
+			s.demo = nil
+			return true
+		}
+		return false
+	}
+	s.idle++
+	switch {
+	case s.demo != nil:
+		if !s.demo.Update() {
+			// Back to the menu for a while before the next one.
+			s.demo, s.idle = nil, 0
+		}
+	case s.idle >= AttractDelay:
+		s.demo = g.newDemo(s.demos)
+		s.demos++
+	}
+	return false
+}
+
+// drawAttract draws the demo with the title and a blinking prompt over it.
+func (s *MenuScene) drawAttract(screen *ebiten.Image) {
+	g := s.g
+	s.demo.Draw(screen)
+	drawAnchoredText(screen, g.loc.T("menu.title"), g.fonts.Title, AnchorCenter, 0, -150, g.theme.Text)
+	if s.idle/blinkFrames%2 == 0 {
+		drawAnchoredText(screen, g.loc.T("attract.press_enter"), g.fonts.Normal, AnchorCenter, 0, 60, g.theme.Highlight)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestBundledDemos(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range demoAssets {
+		t.Run(name, func(t *testing.T) {
+			r, err := loadDemo(assets, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sim, err := Simulate(assets, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sim.Ticks < 30*TicksPerSecond || sim.Score == 0 {
+				t.Errorf("demo de %d passos e %d pontos; esperado ao menos 30 s de jogo", sim.Ticks, sim.Score)
+			}
+		})
+	}
+}
+
+func TestDemoCycle(t *testing.T) {
+	g, _ := newTestGame()
+	for n := range 2 * (len(demoAssets) + 1) {
+		d := g.newDemo(n)
+		if bot := n%(len(demoAssets)+1) == len(demoAssets); bot != (d.bots != nil) {
+			t.Errorf("demo %d: robô %v, esperado %v", n, d.bots != nil, bot)
+		}
+		if d.game == g || d.game.mixer == g.mixer {
+			t.Errorf("demo %d joga no jogo principal ou com som", n)
+		}
+	}
+}
+
+func TestAttractMode(t *testing.T) {
+	g, _ := newTestGame()
+	g.nav = Nav{}
+	s := newMenuScene(g)
+	for range AttractDelay {
+		s.Update()
+	}
+	if s.demo == nil {
+		t.Fatalf("nenhuma demo depois de %d quadros parados", AttractDelay)
+	}
+	for range 120 {
+		s.Update()
+	}
+	if s.demo == nil || s.demo.game.tick == 0 {
+		t.Fatal("a demo não avançou")
+	}
+	if g.running && g.tick != 0 {
+		t.Error("a demo mexeu na partida do jogo")
+	}
+	focus := s.list.Focus
+	g.nav = Nav{Down: true}
+	s.Update()
+	if s.demo != nil {
+		t.Fatal("a demo continuou depois de uma tecla")
+	}
+	if s.list.Focus != focus {
+		t.Error("a tecla que parou a demo também moveu o menu")
+	}
+	g.nav = Nav{}
+	s.Update()
+	if s.demo != nil || s.idle != 1 {
+		t.Errorf("de volta ao menu: demo %v, %d quadros parados; esperado contar de novo", s.demo != nil, s.idle)
+	}
+}
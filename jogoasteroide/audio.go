@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+// Sound identifies a sound effect.
+type Sound int
+
+const (
+	SoundFire Sound = iota
+	SoundThrust
+	SoundExplosionSmall
+	SoundExplosionMedium
+	SoundExplosionLarge
+	SoundPowerUp
+	SoundDamage
+	SoundGameOver
+	SoundAchievement
+)
+
+// ThrustSoundInterval is how often, in frames, the thrust rumble retriggers
+// while the engine is on.
+const ThrustSoundInterval = 9
+
+// Positional audio tuning.
+const (
+	AudioFalloffDistance = 1100.0 // distance at which a sound reaches AudioMinGain
+	AudioMinGain         = 0.25   // far sounds stay audible
+	PanSteps             = 8      // pan positions cached per side
+	MaxVoicesPerSound    = 3
+	MaxVoices            = 12
+)
+
+// explosionSound picks the explosion sound for an asteroid of the given size.
+func explosionSound(size float64) Sound {
+	switch sizeClass(size) {
+	case SizeLarge:
+		return SoundExplosionLarge
+	case SizeMedium:
+		return SoundExplosionMedium
+	default:
+		return SoundExplosionSmall
+	}
+}
+
+// soundRecipes describes every sound effect as synthesiser tones.
+var soundRecipes = map[Sound][]tone{
+	SoundFire: {
+		{wave: waveSquare, duration: 0.09, freq: 880, freqEnd: 330, release: 0.05, volume: 0.25},
+	},
+	SoundThrust: {
+		{wave: waveNoise, duration: 0.16, attack: 0.02, release: 0.06, volume: 0.45, smooth: 0.9},
+	},
+	SoundExplosionSmall: {
+		{wave: waveNoise, duration: 0.25, release: 0.2, volume: 0.45, smooth: 0.5},
+		{wave: waveSine, duration: 0.2, freq: 240, freqEnd: 80, release: 0.15, volume: 0.3},
+	},
+	SoundExplosionMedium: {
+		{wave: waveNoise, duration: 0.45, release: 0.35, volume: 0.55, smooth: 0.7},
+		{wave: waveSine, duration: 0.4, freq: 150, freqEnd: 50, release: 0.3, volume: 0.4},
+	},
+	SoundExplosionLarge: {
+		{wave: waveNoise, duration: 0.8, release: 0.65, volume: 0.65, smooth: 0.85},
+		{wave: waveSine, duration: 0.7, freq: 95, freqEnd: 30, release: 0.5, volume: 0.55},
+	},
+	SoundPowerUp: notes(tone{wave: waveTriangle, duration: 0.08, release: 0.03, volume: 0.35}, 0.07,
+		523.25, 659.25, 783.99, 1046.5),
+	SoundDamage: {
+		{wave: waveSaw, duration: 0.3, freq: 320, freqEnd: 90, release: 0.15, volume: 0.35, smooth: 0.3},
+		{wave: waveNoise, duration: 0.12, release: 0.1, volume: 0.3},
+	},
+	SoundGameOver: notes(tone{wave: waveSquare, duration: 0.22, release: 0.08, volume: 0.2, smooth: 0.4}, 0.24,
+		392, 329.63, 261.63, 196),
+	SoundAchievement: notes(tone{wave: waveSquare, duration: 0.12, release: 0.06, volume: 0.18, smooth: 0.5}, 0.1,
+		659.25, 783.99, 987.77, 1318.51),
+}
+
+// musicLoop is a short A-minor bass line with a sparse lead on top.
+func musicLoop() []float64 {
+	const step = 0.25
+	bass := notes(tone{wave: waveTriangle, duration: step * 0.9, attack: 0.01, release: 0.08, volume: 0.3}, step,
+		110, 110, 164.81, 110, 130.81, 130.81, 196, 130.81,
+		98, 98, 146.83, 98, 82.41, 82.41, 123.47, 82.41)
+	lead := notes(tone{wave: waveSine, duration: step * 1.8, attack: 0.05, release: 0.3, volume: 0.12}, step*2,
+		440, 0, 523.25, 493.88, 392, 0, 329.63, 0)
+	return synth(append(bass, lead...)...)
+}
+
+// AudioSettings holds the mixer volumes, each in [0, 1].
+type AudioSettings struct {
+	Master float64 `json:"master"`
+	SFX    float64 `json:"sfx"`
+	Music  float64 `json:"music"`
+	Muted  bool    `json:"muted"`
+}
+
+func (s AudioSettings) sfxVolume() float64 {
+	if s.Muted {
+		return 0
+	}
+	return s.Master * s.SFX
+}
+
+func (s AudioSettings) musicVolume() float64 {
+	if s.Muted {
+		return 0
+	}
+	return s.Master * s.Music
+}
+
+// Voice is one sound effect handed to a backend. PCM already has the pan
+// baked into its channel gains; Pan is kept for inspection.
+type Voice struct {
+	Sound  Sound
+	PCM    []byte
+	Volume float64
+	Pan    float64
+}
+
+// AudioBackend plays encoded stereo PCM. The game talks to it only
+// through Mixer, so a NullAudio backend can stand in when there is no
+// sound device, as in tests.
+type AudioBackend interface {
+	PlaySFX(v Voice)
+	PlayMusic(pcm []byte, volume float64)
+	SetMusicVolume(volume float64)
+}
+
+type panKey struct {
+	sound Sound
+	step  int
+}
+
+// activeVoice remembers a playing sound until the tick it ends.
+type activeVoice struct {
+	sound      Sound
+	start, end int
+}
+
+// Mixer synthesises the sounds once and routes them to a backend at the
+// volumes chosen in the settings. It also pans and attenuates sounds by
+// position and limits how many copies of a sound play at once.
+type Mixer struct {
+	settings *AudioSettings
+	backend  AudioBackend
+	samples  map[Sound][]float64
+	panned   map[panKey][]byte
+	voices   []activeVoice
+	tick     int
+}
+
+// NewMixer returns a mixer that reads its volumes from settings and plays
+// through backend.
+func NewMixer(settings *AudioSettings, backend AudioBackend) *Mixer {
+	m := &Mixer{
+		settings: settings,
+		backend:  backend,
+		samples:  map[Sound][]float64{},
+		panned:   map[panKey][]byte{},
+	}
+	for s, recipe := range soundRecipes {
+		m.samples[s] = synth(recipe...)
+	}
+	return m
+}
+
+// newSilentMixer returns a mixer that synthesises nothing and plays into
+// a NullAudio, for headless simulations.
+func newSilentMixer(settings *AudioSettings) *Mixer {
+	return &Mixer{
+		settings: settings,
+		backend:  &NullAudio{},
+		samples:  map[Sound][]float64{},
+		panned:   map[panKey][]byte{},
+	}
+}
+
+// SetBackend switches the output, e.g. from NullAudio to the sound card.
+func (m *Mixer) SetBackend(backend AudioBackend) {
+	m.backend = backend
+}
+
+// Update advances the mixer clock; call it once per frame.
+func (m *Mixer) Update() {
+	m.tick++
+	active := m.voices[:0]
+	for _, v := range m.voices {
+		if v.end > m.tick {
+			active = append(active, v)
+		}
+	}
+	m.voices = active
+}
+
+// Play triggers a sound effect centred in the stereo field.
+func (m *Mixer) Play(s Sound) {
+	m.play(s, 1, 0)
+}
+
+// PlayAt triggers a sound effect emitted at pos, heard by a listener at
+// listener: it is panned by the horizontal offset and quieter the further
+// away it is.
+func (m *Mixer) PlayAt(s Sound, pos, listener Vector) {
+	dx, dy := pos.X-listener.X, pos.Y-listener.Y
+	pan := math.Max(-1, math.Min(1, dx/(ScreenWidth/2)))
+	falloff := math.Max(0, 1-math.Hypot(dx, dy)/AudioFalloffDistance)
+	m.play(s, AudioMinGain+(1-AudioMinGain)*falloff, pan)
+}
+
+func (m *Mixer) play(s Sound, gain, pan float64) {
+	if !m.claimVoice(s) {
+		return
+	}
+	step := int(math.Round(pan * PanSteps))
+	key := panKey{s, step}
+	pcm, ok := m.panned[key]
+	if !ok {
+		// Equal-power panning keeps the loudness steady across the field.
+		angle := (float64(step)/PanSteps + 1) * math.Pi / 4
+		pcm = encodeStereo(m.samples[s], math.Cos(angle)*math.Sqrt2, math.Sin(angle)*math.Sqrt2)
+		m.panned[key] = pcm
+	}
+	m.backend.PlaySFX(Voice{Sound: s, PCM: pcm, Volume: m.settings.sfxVolume() * gain, Pan: float64(step) / PanSteps})
+}
+
+// claimVoice reserves a voice for s, refusing when the same sound already
+// started this tick, when s has used up its own voices, or when every
+// voice is busy. Dropping the extras keeps ten simultaneous splits from
+// stacking into clipping.
+func (m *Mixer) claimVoice(s Sound) bool {
+	same := 0
+	for _, v := range m.voices {
+		if v.sound == s {
+			if v.start == m.tick {
+				return false
+			}
+			same++
+		}
+	}
+	if same >= MaxVoicesPerSound || len(m.voices) >= MaxVoices {
+		return false
+	}
+	m.voices = append(m.voices, activeVoice{sound: s, start: m.tick, end: m.tick + m.soundTicks(s)})
+	return true
+}
+
+// soundTicks is how many frames s lasts.
+func (m *Mixer) soundTicks(s Sound) int {
+	return int(math.Ceil(float64(len(m.samples[s])) / SampleRate * 60))
+}
+
+// StartMusic starts the background music loop.
+func (m *Mixer) StartMusic() {
+	m.backend.PlayMusic(encodeStereo(musicLoop(), 1, 1), m.settings.musicVolume())
+}
+
+// ToggleMute mutes or unmutes everything.
+func (m *Mixer) ToggleMute() {
+	m.settings.Muted = !m.settings.Muted
+	m.Refresh()
+}
+
+// Refresh applies volume changes to sounds that are already playing.
+func (m *Mixer) Refresh() {
+	m.backend.SetMusicVolume(m.settings.musicVolume())
+}
+
+// NullAudio is a silent backend that records which sounds were triggered.
+type NullAudio struct {
+	Played []Voice
+}
+
+func (n *NullAudio) PlaySFX(v Voice) {
+	v.PCM = nil
+	n.Played = append(n.Played, v)
+}
+
+func (n *NullAudio) PlayMusic(pcm []byte, volume float64) {}
+
+func (n *NullAudio) SetMusicVolume(volume float64) {}
+
+// Count returns how many times s was played.
+func (n *NullAudio) Count(s Sound) int {
+	c := 0
+	for _, p := range n.Played {
+		if p.Sound == s {
+			c++
+		}
+	}
+	return c
+}
+
+// EbitenAudio plays sounds through ebiten's audio package.
+type EbitenAudio struct {
+	ctx   *audio.Context
+	music *audio.Player
+}
+
+// NewEbitenAudio opens the audio device.
+func NewEbitenAudio() *EbitenAudio {
+	return &EbitenAudio{ctx: audio.NewContext(SampleRate)}
+}
+
+func (a *EbitenAudio) PlaySFX(v Voice) {
+	if v.Volume <= 0 {
+		return
+	}
+	p := a.ctx.NewPlayerF32FromBytes(v.PCM)
+	p.SetVolume(v.Volume)
+	p.Play()
+}
+
+func (a *EbitenAudio) PlayMusic(pcm []byte, volume float64) {
+	if a.music != nil {
+		a.music.Close()
+	}
+	loop := audio.NewInfiniteLoopF32(bytes.NewReader(pcm), int64(len(pcm)))
+	p, err := a.ctx.NewPlayerF32(loop)
+	if err != nil {
+		return
+	}
+	a.music = p
+	p.SetVolume(volume)
+	p.Play()
+}
+
+func (a *EbitenAudio) SetMusicVolume(volume float64) {
+	if a.music != nil {
+		a.music.SetVolume(volume)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import "testing"
+
+func TestSoundsTriggeredByGameplay(t *testing.T) {
+	t.Run("tiro", func(t *testing.T) {
+		g, null := newTestGame()
+		g.fireBullet(&g.players[0])
+		if null.Count(SoundFire) != 1 {
+			t.Errorf("SoundFire tocou %d vezes; esperado 1", null.Count(SoundFire))
+		}
+	})
+
+	t.Run("tiro de outra nave", func(t *testing.T) {
+		g, null := newTestGame()
+		g.ResetRun(g.versusConfig(1))
+		g.players[0].position = Vector{X: 200, Y: ScreenHeight / 2}
+		g.players[1].position = Vector{X: ScreenWidth - 200, Y: ScreenHeight / 2}
+		g.fireBullet(&g.players[0])
+		g.mixer.Update()
+		g.fireBullet(&g.players[1])
+		own, enemy := null.Played[0], null.Played[1]
+		if own.Pan != 0 || enemy.Pan <= 0 || enemy.Volume >= own.Volume {
+			t.Errorf("tiro próprio: pan %v, volume %v; do inimigo: pan %v, volume %v; esperado o inimigo à direita e mais baixo", own.Pan, own.Volume, enemy.Pan, enemy.Volume)
+		}
+	})
+
+	t.Run("explosão por tamanho", func(t *testing.T) {
+		sizes := map[float64]Sound{90: SoundExplosionLarge, 50: SoundExplosionMedium, 25: SoundExplosionSmall}
+		for size, want := range sizes {
+			g, null := newTestGame()
+			pos := Vector{X: 300, Y: 300}
+			g.asteroids = append(g.asteroids, Asteroid{position: pos, size: size})
+			b := g.bulletPool.Get()
+			b.position = pos
+			g.bullets = append(g.bullets, b)
+			g.updateBullets()
+			if null.Count(want) != 1 {
+				t.Errorf("asteroide de tamanho %v: som %d tocou %d vezes; esperado 1", size, want, null.Count(want))
+			}
+		}
+	})
+
+	t.Run("explosão por outra nave", func(t *testing.T) {
+		g, null := newTestGame()
+		g.ResetRun(g.versusConfig(1))
+		g.asteroids = g.asteroids[:0]
+		g.players[0].position = Vector{X: 200, Y: ScreenHeight / 2}
+		g.players[1].position = Vector{X: ScreenWidth - 200, Y: ScreenHeight / 2}
+		pos := Vector{X: ScreenWidth - 200, Y: 200}
+		g.asteroids = append(g.asteroids, Asteroid{position: pos, size: 50})
+		b := g.bulletPool.Get()
+		b.position, b.owner = pos, 1
+		g.bullets = append(g.bullets, b)
+		g.updateBullets()
+		for _, v := range null.Played {
+			if v.Sound == SoundExplosionMedium && v.Pan <= 0 {
+				t.Errorf("explosão com pan %v; esperado à direita de quem joga", v.Pan)
+			}
+		}
+		if null.Count(SoundExplosionMedium) != 1 {
+			t.Errorf("sons tocados = %v; esperado uma explosão", null.Played)
+		}
+	})
+
+	t.Run("power-up", func(t *testing.T) {
+		g, null := newTestGame()
+		g.applyPowerUp(&g.players[0], PowerUpShield)
+		if null.Count(SoundPowerUp) != 1 {
+			t.Errorf("SoundPowerUp tocou %d vezes; esperado 1", null.Count(SoundPowerUp))
+		}
+	})
+
+	t.Run("dano e fim de jogo", func(t *testing.T) {
+		g, null := newTestGame()
+		g.settings.Effects.SlowMotion.Enabled = false
+		g.players[0].health = 1
+		g.asteroids = append(g.asteroids, Asteroid{position: g.players[0].position, size: 60})
+		g.updatePlaying(0)
+		if null.Count(SoundDamage) != 1 || null.Count(SoundGameOver) != 1 {
+			t.Errorf("sons tocados = %v; esperado dano e fim de jogo", null.Played)
+		}
+	})
+}
+
+func TestMixerVolumes(t *testing.T) {
+	s := AudioSettings{Master: 0.5, SFX: 0.8, Music: 0.4}
+	if v := s.sfxVolume(); v != 0.4 {
+		t.Errorf("sfxVolume = %v; esperado 0.4", v)
+	}
+	if v := s.musicVolume(); v != 0.2 {
+		t.Errorf("musicVolume = %v; esperado 0.2", v)
+	}
+	s.Muted = true
+	if s.sfxVolume() != 0 || s.musicVolume() != 0 {
+		t.Error("volumes devem ser zero com o som mudo")
+	}
+}
+
+func TestPositionalAudio(t *testing.T) {
+	settings := AudioSettings{Master: 1, SFX: 1}
+	null := &NullAudio{}
+	m := NewMixer(&settings, null)
+	listener := Vector{X: ScreenWidth / 2, Y: ScreenHeight / 2}
+
+	m.PlayAt(SoundExplosionLarge, Vector{X: 0, Y: listener.Y}, listener)
+	m.PlayAt(SoundExplosionMedium, Vector{X: ScreenWidth, Y: listener.Y}, listener)
+	m.PlayAt(SoundExplosionSmall, listener, listener)
+
+	left, right, centre := null.Played[0], null.Played[1], null.Played[2]
+	if left.Pan != -1 || right.Pan != 1 || centre.Pan != 0 {
+		t.Errorf("pan = %v, %v, %v; esperado -1, 1, 0", left.Pan, right.Pan, centre.Pan)
+	}
+	if centre.Volume != 1 || left.Volume >= centre.Volume {
+		t.Errorf("volume perto = %v, longe = %v; esperado som distante mais baixo", centre.Volume, left.Volume)
+	}
+}
+
+func TestVoiceLimit(t *testing.T) {
+	settings := AudioSettings{Master: 1, SFX: 1}
+	null := &NullAudio{}
+	m := NewMixer(&settings, null)
+
+	// Ten splits in the same frame collapse into one voice.
+	for i := 0; i < 10; i++ {
+		m.Play(SoundExplosionSmall)
+	}
+	if c := null.Count(SoundExplosionSmall); c != 1 {
+		t.Errorf("explosões no mesmo quadro = %d; esperado 1", c)
+	}
+
+	// Over consecutive frames a sound keeps at most MaxVoicesPerSound voices.
+	for i := 0; i < 10; i++ {
+		m.Update()
+		m.Play(SoundExplosionSmall)
+	}
+	if c := null.Count(SoundExplosionSmall); c != MaxVoicesPerSound {
+		t.Errorf("explosões simultâneas = %d; esperado %d", c, MaxVoicesPerSound)
+	}
+}
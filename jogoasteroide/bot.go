@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"sync"
+	"text/tabwriter"
+)
+
+// BotSkill selects how well a bot plays.
+type BotSkill int
+
+const (
+	BotEasy BotSkill = iota
+	BotNormal
+	BotHard
+	botSkillCount
+)
+
+var botSkillNames = [botSkillCount]string{"easy", "normal", "hard"}
+
+func (s BotSkill) String() string {
+	return botSkillNames[s.normalize()]
+}
+
+func (s BotSkill) normalize() BotSkill {
+	if s < 0 || s >= botSkillCount {
+		return BotNormal
+	}
+	return s
+}
+
+// ParseBotSkill returns the skill called name, and whether there is one.
+func ParseBotSkill(name string) (BotSkill, bool) {
+	i := slices.Index(botSkillNames[:], name)
+	return BotSkill(i), i >= 0
+}
+
+// BotPreset holds what a skill level changes.
+type BotPreset struct {
+	Reaction  int     // steps between decisions; nothing new is noticed in between
+	AimError  float64 // radians an aim may be off by, at random
+	Tolerance float64 // radians off its aim the bot still fires
+	Lookahead int     // steps ahead collisions are seen coming
+	Reach     float64 // how far it goes out of its way for a power-up
+}
+
+var botPresets = [botSkillCount]BotPreset{
+	BotEasy:   {Reaction: 30, AimError: 0.3, Tolerance: 0.25, Lookahead: 15},
+	BotNormal: {Reaction: 10, AimError: 0.08, Tolerance: 0.1, Lookahead: 30, Reach: 400},
+	BotHard:   {Reaction: 3, AimError: 0.01, Tolerance: 0.05, Lookahead: 60, Reach: 200},
+}
+
+// Preset returns the values for s.
+func (s BotSkill) Preset() BotPreset {
+	return botPresets[s.normalize()]
+}
+
+const (
+	// botMargin is how much room, beyond touching, a bot wants between its
+	// ship and an asteroid going past.
+	botMargin = 24.0
+	// botHeadings is how many ways out a bot weighs when cornered.
+	botHeadings = 16
+	// botSampleSteps is how often, in steps, a bot checks a predicted path
+	// for asteroids.
+	botSampleSteps = 3
+)
+
+// Bot plays a ship by producing the same inputs a player would, from what
+// is on screen. Its decisions depend only on the run and its seed, so a run
+// it plays in replays like any other.
+type Bot struct {
+	Skill  BotSkill
+	player int
+	rng    *RNG
+	wait   int     // steps until the next decision
+	aim    float64 // the heading wanted
+	thrust bool    // thrust once roughly facing the aim
+	fire   bool    // fire once facing the aim
+}
+
+// NewBot returns a bot of the given skill for ship player; seed varies
+// its aim.
+func NewBot(skill BotSkill, player int, seed uint64) *Bot {
+	return &Bot{Skill: skill.normalize(), player: player, rng: NewRNG(seed ^ uint64(player+1)*0x9e3779b97f4a7c15)}
+}
+
+// Input decides the bot's input for the next step of g.
+func (b *Bot) Input(g *Game) Input {
+	p := &g.players[b.player]
+	if p.out || !g.running || g.dying {
+		return 0
+	}
+	if b.wait--; b.wait <= 0 {
+		b.wait = b.Skill.Preset().Reaction
+		b.decide(g, p)
+	}
+	var in Input
+	off := math.Remainder(b.aim-p.angle, 2*math.Pi)
+	if off > PlayerTurnRate/2 {
+		in = in.With(ActionRotateRight)
+	} else if off < -PlayerTurnRate/2 {
+		in = in.With(ActionRotateLeft)
+	}
+	if b.thrust && math.Abs(off) < math.Pi/3 {
+		in = in.With(ActionThrust)
+	}
+	if b.fire && math.Abs(off) < b.Skill.Preset().Tolerance {
+		in = in.With(ActionFire)
+	}
+	return in
+}
+
+// decide picks what to do until the next decision: get out of the way of
+// asteroids coming too close, else fetch a power-up, else shoot.
+func (b *Bot) decide(g *Game, p *Player) {
+	pre := b.Skill.Preset()
+	b.thrust, b.fire = false, false
+	if room := b.room(g, p, 0, false, pre.Lookahead); room < botMargin {
+		// Of the headings around, thrust along the one that keeps furthest
+		// from every asteroid, firing at whatever is in the way.
+		best := room
+		for i := range botHeadings {
+			dir := p.angle + 2*math.Pi*float64(i)/botHeadings
+			if r := b.room(g, p, dir, true, pre.Lookahead); r > best {
+				best, b.aim, b.thrust, b.fire = r, dir, true, true
+			}
+		}
+		if b.thrust {
+			return
+		}
+	}
+	reach := pre.Reach
+	var powerUp *PowerUp
+	for _, pw := range g.powerUps {
+		if d := wrapDelta(p.position, pw.position).Len(); d < reach {
+			reach, powerUp = d, pw
+		}
+	}
+	if powerUp != nil {
+		b.aim = heading(wrapDelta(p.position, powerUp.position))
+		b.thrust = p.velocity.Len() < PlayerMaxSpeed/2
+		return
+	}
+	if aim, ok := b.target(g, p); ok {
+		b.aim = aim + (b.rng.Float64()*2-1)*pre.AimError
+		b.fire = true
+		return
+	}
+	// Nothing in range: turn towards the nearest asteroid.
+	nearest := math.Inf(1)
+	for i := range g.asteroids {
+		d := wrapDelta(p.position, g.asteroids[i].position)
+		if l := d.Len(); l < nearest {
+			nearest, b.aim = l, heading(d)
+		}
+	}
+}
+
+// room predicts the ship over the next steps, turning towards dir and
+// thrusting once roughly facing it if thrust is set, and returns the
+// closest any asteroid comes to it, moving as it is, beyond touching.
+func (b *Bot) room(g *Game, p *Player, dir float64, thrust bool, steps int) float64 {
+	pos, vel, angle := p.position, p.velocity, p.angle
+	room := math.Inf(1)
+	for t := 1; t <= steps; t++ {
+		if thrust {
+			off := math.Remainder(dir-angle, 2*math.Pi)
+			angle += max(-PlayerTurnRate, min(off, PlayerTurnRate))
+			if math.Abs(off) < math.Pi/3 {
+				vel.Add(Vector{math.Sin(angle) * PlayerAccel, -math.Cos(angle) * PlayerAccel})
+			}
+		}
+		vel = vel.Scaled(1 - PlayerFriction)
+		if l := vel.Len(); l > PlayerMaxSpeed {
+			vel = vel.Scaled(PlayerMaxSpeed / l)
+		}
+		pos.Add(vel)
+		if t%botSampleSteps != 0 && t != 1 {
+			continue
+		}
+		for i := range g.asteroids {
+			a := &g.asteroids[i]
+			at := a.position
+			at.Add(a.velocity.Scaled(float64(t)))
+			room = min(room, wrapDelta(pos, at).Len()-a.size/2-p.width/2)
+		}
+	}
+	return room
+}
+
+// target returns the heading to fire along to hit whatever a bullet would
+// reach soonest: an asteroid or, in versus, another ship.
+func (b *Bot) target(g *Game, p *Player) (float64, bool) {
+	best, aim := math.Inf(1), 0.0
+	try := func(pos, vel Vector) {
+		d := Vector{pos.X - p.position.X, pos.Y - p.position.Y}
+		t, ok := intercept(d, vel, BulletSpeed, p.height/2)
+		if !ok || t > BulletMaxAge || t >= best {
+			return
+		}
+		at := Vector{d.X + vel.X*t, d.Y + vel.Y*t}
+		if at.X+p.position.X < 0 || at.X+p.position.X > ScreenWidth || at.Y+p.position.Y < 0 || at.Y+p.position.Y > ScreenHeight {
+			return // bullets leave the screen rather than wrap
+		}
+		if g.config.FriendlyFire && !b.clear(g, p, at) {
+			return
+		}
+		best, aim = t, heading(at)
+	}
+	for i := range g.asteroids {
+		try(g.asteroids[i].position, g.asteroids[i].velocity)
+	}
+	if g.config.Mode == ModeVersus {
+		for i := range g.players {
+			if q := &g.players[i]; i != b.player && !q.out {
+				try(q.position, q.velocity)
+			}
+		}
+	}
+	return aim, best < math.Inf(1)
+}
+
+// clear reports whether a shot from p to at, relative to p, misses every
+// other ship.
+func (b *Bot) clear(g *Game, p *Player, at Vector) bool {
+	dist := at.Len()
+	dir := at.Scaled(1 / dist)
+	for i := range g.players {
+		q := &g.players[i]
+		if i == b.player || q.out {
+			continue
+		}
+		d := Vector{q.position.X - p.position.X, q.position.Y - p.position.Y}
+		along := d.X*dir.X + d.Y*dir.Y
+		across := math.Abs(d.X*dir.Y - d.Y*dir.X)
+		if along > 0 && along < dist && across < q.width/2+botMargin {
+			return false
+		}
+	}
+	return true
+}
+
+// intercept returns when a shot at speed, starting lead ahead of the
+// shooter, meets something at d moving with vel, if it can.
+func intercept(d, vel Vector, speed, lead float64) (float64, bool) {
+	// |d + vel t| = speed t + lead
+	a := vel.X*vel.X + vel.Y*vel.Y - speed*speed
+	b := 2 * (d.X*vel.X + d.Y*vel.Y - speed*lead)
+	c := d.X*d.X + d.Y*d.Y - lead*lead
+	if c <= 0 {
+		return 0, true
+	}
+	if math.Abs(a) < 1e-9 {
+		if b >= 0 {
+			return 0, false
+		}
+		return -c / b, true
+	}
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return 0, false
+	}
+	sq := math.Sqrt(disc)
+	t1, t2 := (-b-sq)/(2*a), (-b+sq)/(2*a)
+	if t1 > t2 {
+		t1, t2 = t2, t1
+	}
+	switch {
+	case t1 > 0:
+		return t1, true
+	case t2 > 0:
+		return t2, true
+	}
+	return 0, false
+}
+
+// heading is the ship angle that points along v.
+func heading(v Vector) float64 {
+	return math.Atan2(v.X, -v.Y)
+}
+
+// wrapDelta is the shortest way from a to b on the wrapping screen.
+func wrapDelta(a, b Vector) Vector {
+	d := Vector{b.X - a.X, b.Y - a.Y}
+	if d.X > ScreenWidth/2 {
+		d.X -= ScreenWidth
+	} else if d.X < -ScreenWidth/2 {
+		d.X += ScreenWidth
+	}
+	if d.Y > ScreenHeight/2 {
+		d.Y -= ScreenHeight
+	} else if d.Y < -ScreenHeight/2 {
+		d.Y += ScreenHeight
+	}
+	return d
+}
+
+// BotMaxSteps bounds a benchmark game: ten minutes of play.
+const BotMaxSteps = 10 * 60 * TicksPerSecond
+
+// PlayBot plays a run with config cfg without drawing anything, every
+// ship a bot of the given skill, until it ends or maxSteps have passed.
+func PlayBot(assets *AssetStore, cfg RunConfig, skill BotSkill, maxSteps int) SimResult {
+	g := newSimGame(assets)
+	g.ResetRun(cfg)
+	bots := make([]*Bot, len(g.players))
+	for i := range bots {
+		bots[i] = NewBot(skill, i, cfg.Seed)
+	}
+	in := make([]Input, len(bots))
+	for g.running && !g.dying && g.tick < maxSteps {
+		for i, b := range bots {
+			in[i] = b.Input(g)
+		}
+		g.updatePlaying(in...)
+	}
+	return SimResult{Score: g.score, Ticks: g.tick, Over: g.dying || !g.running}
+}
+
+// BotStats sums up the runs of a benchmark.
+type BotStats struct {
+	Games                      int
+	Mean                       float64 // score
+	Min, P10, Median, P90, Max int
+	Seconds                    float64 // mean time survived
+	Survived                   int     // games still going at the step limit
+}
+
+// BotBench plays games single-player runs of difficulty d with a bot of
+// the given skill, seeded from seed on, on every CPU at once.
+func BotBench(assets *AssetStore, d Difficulty, skill BotSkill, games, maxSteps int, seed uint64) BotStats {
+	results := make([]SimResult, games)
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for range runtime.GOMAXPROCS(0) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				cfg := RunConfig{Mode: ModeClassic, Seed: seed + uint64(i), Difficulty: d}
+				results[i] = PlayBot(assets, cfg, skill, maxSteps)
+			}
+		}()
+	}
+	for i := range games {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	s := BotStats{Games: games}
+	if games == 0 {
+		return s
+	}
+	scores := make([]int, games)
+	for i, r := range results {
+		scores[i] = r.Score
+		s.Mean += float64(r.Score) / float64(games)
+		s.Seconds += float64(r.Ticks) / TicksPerSecond / float64(games)
+		if !r.Over {
+			s.Survived++
+		}
+	}
+	slices.Sort(scores)
+	pct := func(p int) int { return scores[(games-1)*p/100] }
+	s.Min, s.P10, s.Median, s.P90, s.Max = scores[0], pct(10), pct(50), pct(90), scores[games-1]
+	return s
+}
+
+// benchBots runs games bot games on every difficulty with the built-in
+// assets, writes a table of the scores to w and returns the exit status.
+func benchBots(w io.Writer, games int, skill string) int {
+	s, ok := ParseBotSkill(skill)
+	if !ok {
+		fmt.Fprintf(w, "unknown bot skill %q\n", skill)
+		return 2
+	}
+	assets, err := NewAssetStore()
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return 1
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "difficulty\tgames\tmean\tmin\tp10\tmedian\tp90\tmax\tseconds\tsurvived\t\n")
+	for d := range difficultyCount {
+		st := BotBench(assets, d, s, games, BotMaxSteps, 1)
+		fmt.Fprintf(tw, "%s\t%d\t%.0f\t%d\t%d\t%d\t%d\t%d\t%.1f\t%d\t\n",
+			d, st.Games, st.Mean, st.Min, st.P10, st.Median, st.P90, st.Max, st.Seconds, st.Survived)
+	}
+	if err := tw.Flush(); err != nil {
+		return 1
+	}
+	return 0
+}
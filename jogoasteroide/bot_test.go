@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntercept(t *testing.T) {
+	tests := []struct {
+		name   string
+		d, vel Vector
+		want   float64 // steps, or -1 for no shot
+	}{
+		{"parado à frente", Vector{0, -140}, Vector{}, 10},
+		{"cruzando", Vector{0, -300}, Vector{5, 0}, 0},
+		{"fugindo mais rápido que a bala", Vector{0, -300}, Vector{0, -20}, -1},
+		{"bem perto", Vector{0, -5}, Vector{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := intercept(tt.d, tt.vel, BulletSpeed, 0)
+			if tt.want < 0 {
+				if ok {
+					t.Errorf("tiro em %v; esperado nenhum", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("nenhum tiro; esperado um")
+			}
+			// The bullet and the target must end up in the same place.
+			hit := Vector{tt.d.X + tt.vel.X*got, tt.d.Y + tt.vel.Y*got}
+			if math.Abs(hit.Len()-BulletSpeed*got) > 1e-6 {
+				t.Errorf("em %v a bala está a %v e o alvo a %v", got, BulletSpeed*got, hit.Len())
+			}
+			if tt.want > 0 && math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("tiro em %v passos, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotAims(t *testing.T) {
+	g, _ := newTestGame()
+	// One asteroid crossing well above the ship, out of its way.
+	g.asteroids = append(g.asteroids, Asteroid{id: g.newID(), position: Vector{200, 100}, velocity: Vector{3, 0}, size: 40})
+	b := NewBot(BotHard, 0, 1)
+	for g.tick < 300 && len(g.asteroids) == 1 && g.asteroids[0].size == 40 {
+		g.updatePlaying(b.Input(g))
+	}
+	if len(g.asteroids) == 1 && g.asteroids[0].size == 40 {
+		t.Error("o robô não acertou o asteroide")
+	}
+	if g.players[0].health != g.maxHealth() {
+		t.Errorf("o robô levou dano: vida %d", g.players[0].health)
+	}
+}
+
+func TestBotSkills(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var means [botSkillCount]float64
+	for s := range botSkillCount {
+		st := BotBench(assets, DifficultyNormal, s, 16, 60*TicksPerSecond, 1)
+		if st.Games != 16 || st.Min > st.P10 || st.P10 > st.Median || st.Median > st.P90 || st.P90 > st.Max {
+			t.Errorf("%v: estatísticas incoerentes %+v", s, st)
+		}
+		means[s] = st.Mean
+	}
+	if means[BotEasy] >= means[BotNormal] || means[BotNormal] >= means[BotHard] {
+		t.Errorf("médias por nível %v; esperado fácil < normal < difícil", means)
+	}
+}
+
+func TestBotCoopPartner(t *testing.T) {
+	g, _ := newTestGame()
+	g.settings.Coop.CPU = "hard"
+	cfg := g.classicConfig(3)
+	if cfg.Players != 2 || cfg.CPU != "hard" {
+		t.Fatalf("configuração %+v; esperado 2 naves, a segunda do robô", cfg)
+	}
+	g.ResetRun(cfg)
+	if got := g.shipName(1); got != g.loc.T("hud.cpu") {
+		t.Errorf("nave 2 se chama %q", got)
+	}
+	// The player sits still while the bot plays on.
+	for g.running && g.tick < 30*TicksPerSecond {
+		g.updatePlaying(g.readInputs()...)
+	}
+	if g.players[1].score == 0 {
+		t.Error("o robô não marcou pontos")
+	}
+	sim, err := Simulate(g.assets, g.replay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sim.Ticks != g.tick || sim.Score != g.score {
+		t.Errorf("simulação: %+v; esperado passo %d com %d pontos", sim, g.tick, g.score)
+	}
+}
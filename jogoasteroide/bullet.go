@@ -3,9 +3,11 @@ package main
 import "github.com/hajimehoshi/ebiten/v2"
 
 type Bullet struct {
+	id       uint32
 	position Vector
 	velocity Vector
 	age      int
+	owner    int // index of the player who fired it
 }
 
 func (b *Bullet) Update() {
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Camera effect tuning.
+const (
+	ShakeMaxOffset   = 18.0  // pixels at full trauma
+	ShakeMaxAngle    = 0.035 // radians at full trauma
+	TraumaDecay      = 0.025 // trauma lost per frame
+	HitStopFrames    = 5
+	HitStopMinSize   = 70.0 // asteroids at least this big trigger hit-stop
+	FlashDecay       = 0.08
+	SlowMotionFrames = 90
+	SlowMotionRate   = 3 // the simulation advances once every SlowMotionRate frames
+)
+
+// EffectSetting is the player's choice for one effect. Intensity scales
+// the effect from 0 (none) to 1 (full); Enabled is the accessibility
+// toggle for players sensitive to motion or flashing light.
+type EffectSetting struct {
+	Enabled   bool    `json:"enabled"`
+	Intensity float64 `json:"intensity"`
+}
+
+// amount returns the effective strength of the effect.
+func (e EffectSetting) amount() float64 {
+	if !e.Enabled {
+		return 0
+	}
+	return math.Max(0, math.Min(e.Intensity, 1))
+}
+
+// EffectsSettings holds one EffectSetting per camera effect.
+type EffectsSettings struct {
+	Shake      EffectSetting `json:"shake"`
+	HitStop    EffectSetting `json:"hit_stop"`
+	Flash      EffectSetting `json:"flash"`
+	SlowMotion EffectSetting `json:"slow_motion"`
+}
+
+// defaultEffects turns every effect on at full intensity.
+func defaultEffects() EffectsSettings {
+	on := EffectSetting{Enabled: true, Intensity: 1}
+	return EffectsSettings{Shake: on, HitStop: on, Flash: on, SlowMotion: on}
+}
+
+// Camera applies screen shake, hit-stop, damage flash and slow motion.
+// Effects are requested by game events and read back by Update and Draw.
+type Camera struct {
+	settings *EffectsSettings
+	trauma   float64
+	hitStop  int
+	flash    float64
+	slowMo   int
+	time     float64
+	flashImg *ebiten.Image
+}
+
+// NewCamera returns a camera that reads its tuning from settings.
+func NewCamera(settings *EffectsSettings) *Camera {
+	return &Camera{settings: settings}
+}
+
+// AddTrauma adds shake; the visible shake grows with the square of trauma
+// so small hits stay subtle and big ones stack up.
+func (c *Camera) AddTrauma(amount float64) {
+	if c.settings.Shake.amount() == 0 {
+		return
+	}
+	c.trauma = math.Min(c.trauma+amount, 1)
+}
+
+// HitStop freezes the simulation for up to the given number of frames.
+func (c *Camera) HitStop(frames int) {
+	frames = int(math.Round(float64(frames) * c.settings.HitStop.amount()))
+	if frames > c.hitStop {
+		c.hitStop = frames
+	}
+}
+
+// Flash whites out the screen briefly.
+func (c *Camera) Flash() {
+	c.flash = c.settings.Flash.amount()
+}
+
+// SlowMotion slows the simulation down for up to the given number of
+// frames. It reports whether the effect was started.
+func (c *Camera) SlowMotion(frames int) bool {
+	c.slowMo = int(math.Round(float64(frames) * c.settings.SlowMotion.amount()))
+	return c.slowMo > 0
+}
+
+// SlowMotionActive reports whether a slow-motion effect is still running.
+func (c *Camera) SlowMotionActive() bool {
+	return c.slowMo > 0
+}
+
+// Reset clears every running effect.
+func (c *Camera) Reset() {
+	c.trauma, c.hitStop, c.flash, c.slowMo = 0, 0, 0, 0
+}
+
+// Update advances the effects by one frame and reports whether the game
+// simulation should step this frame.
+func (c *Camera) Update() bool {
+	c.time++
+	c.trauma = math.Max(c.trauma-TraumaDecay, 0)
+	c.flash = math.Max(c.flash-FlashDecay, 0)
+	if c.hitStop > 0 {
+		c.hitStop--
+		return false
+	}
+	if c.slowMo > 0 {
+		c.slowMo--
+		return c.slowMo%SlowMotionRate == 0
+	}
+	return true
+}
+
+// Offset returns the current shake translation and rotation.
+func (c *Camera) Offset() (dx, dy, angle float64) {
+	shake := c.trauma * c.trauma * c.settings.Shake.amount()
+	if shake == 0 {
+		return 0, 0, 0
+	}
+	// Layered sines give smooth, non-repeating motion without touching the
+	// game's random number generator.
+	n := func(seed float64) float64 {
+		t := c.time + seed
+		return (math.Sin(t*0.9) + math.Sin(t*2.3+1.7) + math.Sin(t*4.1+3.1)) / 3
+	}
+	return ShakeMaxOffset * shake * n(0), ShakeMaxOffset * shake * n(100), ShakeMaxAngle * shake * n(200)
+}
+
+// DrawWorld draws the world layer onto screen with the shake applied.
+func (c *Camera) DrawWorld(screen, world *ebiten.Image) {
+	dx, dy, angle := c.Offset()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-ScreenWidth/2, -ScreenHeight/2)
+	op.GeoM.Rotate(angle)
+	op.GeoM.Translate(ScreenWidth/2+dx, ScreenHeight/2+dy)
+	screen.DrawImage(world, op)
+}
+
+// DrawFlash overlays the damage flash, if any.
+func (c *Camera) DrawFlash(screen *ebiten.Image) {
+	alpha := c.flash * 0.8
+	if alpha <= 0 {
+		return
+	}
+	if c.flashImg == nil {
+		c.flashImg = ebiten.NewImage(ScreenWidth, ScreenHeight)
+		c.flashImg.Fill(color.White)
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.ColorScale.ScaleAlpha(float32(alpha))
+	screen.DrawImage(c.flashImg, op)
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func countSteps(c *Camera, frames int) int {
+	steps := 0
+	for i := 0; i < frames; i++ {
+		if c.Update() {
+			steps++
+		}
+	}
+	return steps
+}
+
+func TestCameraHitStop(t *testing.T) {
+	settings := defaultEffects()
+	c := NewCamera(&settings)
+	c.HitStop(HitStopFrames)
+	if steps := countSteps(c, 10); steps != 10-HitStopFrames {
+		t.Errorf("passos com hit-stop = %d; esperado %d", steps, 10-HitStopFrames)
+	}
+
+	settings.HitStop.Enabled = false
+	c.HitStop(HitStopFrames)
+	if steps := countSteps(c, 10); steps != 10 {
+		t.Errorf("passos com hit-stop desativado = %d; esperado 10", steps)
+	}
+}
+
+func TestCameraSlowMotion(t *testing.T) {
+	settings := defaultEffects()
+	c := NewCamera(&settings)
+	if !c.SlowMotion(SlowMotionFrames) {
+		t.Fatal("SlowMotion não iniciou")
+	}
+	if steps := countSteps(c, SlowMotionFrames); steps != SlowMotionFrames/SlowMotionRate {
+		t.Errorf("passos em câmera lenta = %d; esperado %d", steps, SlowMotionFrames/SlowMotionRate)
+	}
+	if c.SlowMotionActive() {
+		t.Error("câmera lenta ainda ativa depois do fim")
+	}
+
+	settings.SlowMotion.Intensity = 0
+	if c.SlowMotion(SlowMotionFrames) {
+		t.Error("SlowMotion iniciou com intensidade zero")
+	}
+}
+
+func TestCameraShakeIntensity(t *testing.T) {
+	settings := defaultEffects()
+	c := NewCamera(&settings)
+	c.AddTrauma(1)
+	c.Update()
+	full, _, _ := c.Offset()
+
+	settings.Shake.Intensity = 0.5
+	half, _, _ := c.Offset()
+	if full == 0 || half != full/2 {
+		t.Errorf("deslocamento com metade da intensidade = %v; esperado %v", half, full/2)
+	}
+
+	settings.Shake.Enabled = false
+	if dx, dy, angle := c.Offset(); dx != 0 || dy != 0 || angle != 0 {
+		t.Errorf("tremor desativado ainda desloca a tela: %v, %v, %v", dx, dy, angle)
+	}
+}
@@ -1,16 +1,14 @@
 package main
 
-import (
-	"image/color"
-
-	"github.com/hajimehoshi/ebiten/v2"
-)
+import "github.com/hajimehoshi/ebiten/v2"
 
 // Game configuration constants
 const (
 	ScreenWidth  = 1280
 	ScreenHeight = 720
 	Scale        = 1.0
+	// TicksPerSecond is the simulation rate (ebiten's default TPS).
+	TicksPerSecond = 60
 )
 
 // Player configuration
@@ -18,6 +16,7 @@ const (
 	PlayerMaxSpeed = 6.5
 	PlayerAccel    = 0.35
 	PlayerFriction = 0.06
+	PlayerTurnRate = 0.09 // radians a step
 	PlayerWidth    = 64
 	PlayerHeight   = 64
 )
@@ -32,7 +31,7 @@ const (
 
 // Asteroid configuration
 const (
-	MaxAsteroids   = 12
+	MaxAsteroids    = 12
 	MinAsteroidSize = 20.0
 )
 
@@ -46,20 +45,13 @@ const (
 	PowerUpMaxAge = 600 // 10 seconds at 60fps
 )
 
-// Colors
-var (
-	BgColor        = color.White
-	TextColor      = color.RGBA{107, 114, 128, 255}
-	BulletColor    = color.RGBA{0, 0, 0, 255}
-	ExplosionColor = color.RGBA{255, 69, 0, 160}
-)
-
 // Images (to be loaded)
 var (
-	ImgPlayer   *ebiten.Image
-	ImgAsteroid *ebiten.Image
-	ImgBullet   *ebiten.Image
+	ImgPlayer    *ebiten.Image
+	ImgAsteroid  *ebiten.Image
+	ImgBullet    *ebiten.Image
 	ImgExplosion *ebiten.Image
-	ImgHealthBg  *ebiten.Image
-	ImgCooldownBg *ebiten.Image
+	ImgThrust    *ebiten.Image
+	ImgShield    *ebiten.Image
+	ImgPowerUps  [powerUpCount]*ebiten.Image
 )
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// MaxPlayers is how many ships can share the screen.
+const MaxPlayers = 4
+
+// PadStickThreshold is how far a gamepad's left stick must be pushed to
+// steer.
+const PadStickThreshold = 0.4
+
+// CoopSettings are the options for local co-op runs.
+type CoopSettings struct {
+	Players      int  `json:"players"`
+	SharedLives  bool `json:"shared_lives"`  // one health pool for the team
+	FriendlyFire bool `json:"friendly_fire"` // bullets hurt other players
+	// Bindings are the keys of players 2 to MaxPlayers; player 1 uses the
+	// main bindings. Player n also steers with the nth connected gamepad.
+	Bindings [MaxPlayers - 1]Bindings `json:"bindings"`
+	// Rounds is the length of a versus match: best of Rounds.
+	Rounds int `json:"rounds"`
+	// CPU, a bot skill, adds a ship the game plays; empty for none.
+	CPU string `json:"cpu,omitempty"`
+}
+
+// DefaultCoopSettings returns single player, with keys for the others on
+// the rest of the keyboard.
+func DefaultCoopSettings() CoopSettings {
+	return CoopSettings{
+		Players: 1,
+		Rounds:  DefaultRounds,
+		Bindings: [MaxPlayers - 1]Bindings{
+			{
+				ActionRotateLeft:  {ebiten.KeyA},
+				ActionRotateRight: {ebiten.KeyD},
+				ActionThrust:      {ebiten.KeyW},
+				ActionFire:        {ebiten.KeyShiftLeft},
+			},
+			{
+				ActionRotateLeft:  {ebiten.KeyJ},
+				ActionRotateRight: {ebiten.KeyL},
+				ActionThrust:      {ebiten.KeyI},
+				ActionFire:        {ebiten.KeyK},
+			},
+			{
+				ActionRotateLeft:  {ebiten.KeyNumpad4},
+				ActionRotateRight: {ebiten.KeyNumpad6},
+				ActionThrust:      {ebiten.KeyNumpad8},
+				ActionFire:        {ebiten.KeyNumpad0},
+			},
+		},
+	}
+}
+
+// playerCount is how many ships the run has. Versus needs two at least.
+func (c RunConfig) playerCount() int {
+	if c.Mode == ModeVersus {
+		return max(2, min(c.Players, MaxPlayers))
+	}
+	return max(1, min(c.Players, MaxPlayers))
+}
+
+// health returns the health a hit on p takes from: its own, or the
+// team's when lives are shared.
+func (g *Game) health(p *Player) *int {
+	if g.config.SharedLives {
+		return &g.sharedHealth
+	}
+	return &p.health
+}
+
+// maxHealth is the most health a ship, or the shared pool, can have.
+func (g *Game) maxHealth() int {
+	if g.config.SharedLives {
+		return g.config.preset().Health * len(g.players)
+	}
+	return g.config.preset().Health
+}
+
+// playersLeft counts the ships still in the run.
+func (g *Game) playersLeft() int {
+	n := 0
+	for _, p := range g.players {
+		if !p.out {
+			n++
+		}
+	}
+	return n
+}
+
+// notify shows a message about p, naming the player when there are
+// several.
+func (g *Game) notify(p *Player, id string) {
+	g.message = g.loc.T(id)
+	if len(g.players) > 1 {
+		g.message = g.shipName(p.index) + ": " + g.message
+	}
+	g.messageTimer = 120
+}
+
+// shipName names ship i in messages and on the hud.
+func (g *Game) shipName(i int) string {
+	if g.bot != nil && i == g.bot.player {
+		return g.loc.T("hud.cpu")
+	}
+	return g.loc.T("hud.player", i+1)
+}
+
+// hostile reports whether bullets hurt ships other than the shooter's.
+func (c RunConfig) hostile() bool {
+	return c.FriendlyFire || c.Mode == ModeVersus
+}
+
+// shipHit hits the first other ship in b's way and reports whether there
+// was one.
+func (g *Game) shipHit(b *Bullet) bool {
+	for i := range g.players {
+		p := &g.players[i]
+		if i == b.owner || p.out {
+			continue
+		}
+		if circleCollision(b.position.X, b.position.Y, 5, p.position.X, p.position.Y, p.width/2) {
+			g.hurt(p, b.owner)
+			return true
+		}
+	}
+	return false
+}
+
+// readInputs samples every player's keys and gamepad.
+func (g *Game) readInputs() []Input {
+	pads := ebiten.AppendGamepadIDs(nil)
+	slices.Sort(pads)
+	in := make([]Input, len(g.players))
+	for i := range in {
+		if g.bot != nil && i == g.bot.player {
+			in[i] = g.bot.Input(g)
+			continue
+		}
+		keys := g.settings.Bindings
+		if i > 0 {
+			keys = g.settings.Coop.Bindings[i-1]
+		}
+		in[i] = keys.Read()
+		if i < len(pads) {
+			in[i] |= readGamepad(pads[i])
+		}
+	}
+	return in
+}
+
+// readGamepad maps a standard gamepad to the ship: the left stick or
+// d-pad turns, up or A thrusts, X or the right trigger fires.
+func readGamepad(id ebiten.GamepadID) Input {
+	var in Input
+	if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+		return in
+	}
+	button := func(b ebiten.StandardGamepadButton) bool {
+		return ebiten.IsStandardGamepadButtonPressed(id, b)
+	}
+	x := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	y := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	if x < -PadStickThreshold || button(ebiten.StandardGamepadButtonLeftLeft) {
+		in = in.With(ActionRotateLeft)
+	}
+	if x > PadStickThreshold || button(ebiten.StandardGamepadButtonLeftRight) {
+		in = in.With(ActionRotateRight)
+	}
+	if y < -PadStickThreshold || button(ebiten.StandardGamepadButtonLeftTop) || button(ebiten.StandardGamepadButtonRightBottom) {
+		in = in.With(ActionThrust)
+	}
+	if button(ebiten.StandardGamepadButtonRightLeft) || button(ebiten.StandardGamepadButtonFrontBottomRight) {
+		in = in.With(ActionFire)
+	}
+	return in
+}
+
+// playerColor is p's colour, or nil in a single-player run where the ship
+// keeps its own colours.
+func (g *Game) playerColor(p *Player) color.Color {
+	if len(g.players) == 1 {
+		return nil
+	}
+	return g.theme.Players[p.index]
+}
+
+// drawPlayers draws the ships still in the run. With several players each
+// ship is tinted and numbered, so colour is not the only cue.
+func (g *Game) drawPlayers(world *ebiten.Image) {
+	for i := range g.players {
+		p := &g.players[i]
+		if p.out {
+			continue
+		}
+		tint := g.playerColor(p)
+		p.Draw(world, tint)
+		if tint != nil {
+			label := fmt.Sprint(p.index + 1)
+			w, h := MeasureText(g.fonts.Small, label)
+			DrawText(world, label, g.fonts.Small, p.position.X-w/2, p.position.Y-p.height/2-h-4, AlignLeft, tint)
+		}
+	}
+}
+
+// drawPlayerPanels draws each player's health and cooldown bars along the
+// bottom of the screen, with a name and score (or versus wins) over them
+// when there are several players.
+func (g *Game) drawPlayerPanels(screen *ebiten.Image) {
+	panelW := float64(ScreenWidth-48) / float64(len(g.players))
+	barW, barH := math.Min(200, panelW-24), 20.0
+	for i := range g.players {
+		p := &g.players[i]
+		left := 24 + float64(i)*panelW
+
+		x, y := AnchorBottomLeft.Place(barW, barH, left, 54)
+		fillRect(screen, x, y, barW, barH, g.theme.HealthEmpty, 1)
+		health := math.Max(float64(*g.health(p))/float64(g.maxHealth()), 0)
+		fillRect(screen, x, y, barW*health, barH, g.theme.HealthFill, 1)
+
+		x, y = AnchorBottomLeft.Place(barW, barH, left, 24)
+		fillRect(screen, x, y, barW, barH, g.theme.CooldownEmpty, 1)
+		if p.fireCooldown > 0 {
+			fillRect(screen, x, y, barW*float64(p.fireCooldown)/FireCooldown, barH, g.theme.CooldownFill, 1)
+		}
+
+		if clr := g.playerColor(p); clr != nil {
+			label := g.shipName(p.index) + "  " + fmt.Sprint(p.score)
+			if g.config.Mode == ModeVersus {
+				label = g.shipName(p.index) + "  " + g.loc.T("hud.wins", g.wins[p.index])
+			}
+			if p.out {
+				label += "  " + g.loc.T("hud.out")
+			}
+			drawAnchoredText(screen, label, g.fonts.Small, AnchorBottomLeft, left, 84, clr)
+		}
+	}
+}
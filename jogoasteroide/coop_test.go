@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newCoopGame returns a game running an empty co-op run.
+func newCoopGame(cfg RunConfig) *Game {
+	g, _ := newTestGame()
+	cfg.Mode = ModeClassic
+	g.ResetRun(cfg)
+	g.asteroids = g.asteroids[:0]
+	return g
+}
+
+func TestCoopInputs(t *testing.T) {
+	g := newCoopGame(RunConfig{Players: 2})
+	g.updatePlaying(0, Input(0).With(ActionThrust))
+	if g.players[0].velocity != (Vector{}) {
+		t.Errorf("jogador 1 andou: velocidade %v", g.players[0].velocity)
+	}
+	if g.players[1].velocity == (Vector{}) {
+		t.Error("jogador 2 não acelerou")
+	}
+	g.updatePlaying()
+	if len(g.inputs) != 4 {
+		t.Errorf("%d entradas gravadas; esperado 2 por passo", len(g.inputs))
+	}
+}
+
+func TestCoopLives(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared bool
+	}{
+		{"vidas separadas", false},
+		{"vidas compartilhadas", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newCoopGame(RunConfig{Players: 2, SharedLives: tt.shared})
+			health := g.config.preset().Health
+			for i := 0; i < health; i++ {
+				g.hurt(&g.players[0], -1)
+			}
+			if tt.shared {
+				if g.players[0].out || *g.health(&g.players[1]) != health {
+					t.Errorf("fora = %v, vida do time = %d; esperado %d para os dois", g.players[0].out, *g.health(&g.players[1]), health)
+				}
+			} else if !g.players[0].out || g.dying || !g.running {
+				t.Errorf("fora = %v, fim = %v; esperado só o jogador 1 fora", g.players[0].out, g.dying || !g.running)
+			}
+			for i := 0; i < health; i++ {
+				g.hurt(&g.players[1], -1)
+			}
+			if !g.dying && g.running {
+				t.Error("a partida continuou sem vidas")
+			}
+		})
+	}
+}
+
+func TestFriendlyFire(t *testing.T) {
+	for _, ff := range []bool{false, true} {
+		g := newCoopGame(RunConfig{Players: 2, FriendlyFire: ff})
+		b := g.bulletPool.Get()
+		b.position = g.players[1].position
+		b.owner = 0
+		g.bullets = append(g.bullets, b)
+		g.updateBullets()
+		want := g.config.preset().Health
+		if ff {
+			want--
+		}
+		if got := g.players[1].health; got != want {
+			t.Errorf("fogo amigo %v: vida %d; esperado %d", ff, got, want)
+		}
+	}
+}
+
+func TestCoopScoreGoesToShooter(t *testing.T) {
+	g := newCoopGame(RunConfig{Players: 3})
+	pos := Vector{X: 300, Y: 100}
+	g.asteroids = append(g.asteroids, Asteroid{position: pos, size: 25})
+	b := g.bulletPool.Get()
+	b.position = pos
+	b.owner = 2
+	g.bullets = append(g.bullets, b)
+	g.updateBullets()
+	if g.score == 0 || g.players[2].score != g.score || g.players[0].score+g.players[1].score != 0 {
+		t.Errorf("pontos do time %d, dos jogadores %d/%d/%d; esperado tudo para o jogador 3",
+			g.score, g.players[0].score, g.players[1].score, g.players[2].score)
+	}
+}
+
+func TestCoopReplayAndSave(t *testing.T) {
+	cfg := RunConfig{Mode: ModeClassic, Seed: 7, Players: 2, FriendlyFire: true}
+	g, _ := newTestGame()
+	g.ResetRun(cfg)
+	for i := 0; i < 300; i++ {
+		g.updatePlaying(script(i), script(i+45))
+	}
+	saved, err := g.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := newTestGame()
+	if err := h.restore(saved); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := h.snapshot(); !reflect.DeepEqual(saved, again) {
+		t.Error("a partida restaurada difere da salva")
+	}
+
+	for i := 300; !g.dying; i++ {
+		if i == 50000 {
+			t.Fatal("as naves não foram destruídas")
+		}
+		g.updatePlaying(script(i), script(i+45))
+	}
+	sim, err := Simulate(g.assets, g.replay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sim.Over || sim.Score != g.score {
+		t.Errorf("simulação: %+v; esperado fim com %d pontos", sim, g.score)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DailyVersion is the daily challenge log format written by this build.
+const DailyVersion = 1
+
+// DailyBoardSize is how many past challenges the daily leaderboard shows.
+const DailyBoardSize = 10
+
+// DailyConfig returns the daily challenge for date (YYYY-MM-DD). Everyone
+// gets the same seed and modifiers on the same day.
+func DailyConfig(date string) RunConfig {
+	h := fnv.New64a()
+	h.Write([]byte("jogoasteroide daily " + date))
+	seed := h.Sum64()
+	// Pick one or two modifiers with a generator of their own, so the
+	// run's random sequence starts fresh from the seed.
+	rng := NewRNG(seed ^ 0x6a09e667f3bcc909)
+	var mods Modifiers
+	for n := 1 + rng.IntN(2); n > 0; n-- {
+		mods = mods.With(Modifier(rng.IntN(int(modifierCount))))
+	}
+	return RunConfig{Mode: ModeDaily, Date: date, Seed: seed, Difficulty: DifficultyNormal, Modifiers: mods}
+}
+
+// today is the local date, which picks the daily challenge.
+func today() string {
+	return time.Now().Format(time.DateOnly)
+}
+
+// DailyEntry is the player's attempt at one day's challenge. The attempt
+// is used up as soon as the run starts.
+type DailyEntry struct {
+	Date      string    `json:"date"`
+	Modifiers Modifiers `json:"modifiers"`
+	Finished  bool      `json:"finished"`
+	Score     int       `json:"score"`
+	Result    string    `json:"result,omitempty"` // path of the result file
+}
+
+// DailyLog is every daily challenge attempted on this profile.
+type DailyLog struct {
+	Version int                    `json:"version"`
+	Entries map[string]*DailyEntry `json:"entries"`
+}
+
+// Board returns the finished challenges, best score first, at most n.
+func (l DailyLog) Board(n int) []DailyEntry {
+	var board []DailyEntry
+	for _, e := range l.Entries {
+		if e.Finished {
+			board = append(board, *e)
+		}
+	}
+	sort.Slice(board, func(i, j int) bool {
+		if board[i].Score != board[j].Score {
+			return board[i].Score > board[j].Score
+		}
+		return board[i].Date > board[j].Date
+	})
+	return board[:min(n, len(board))]
+}
+
+// LoadDaily reads the daily log from path. A missing file is not an
+// error; a file from a newer version is, so it is not overwritten.
+func LoadDaily(path string) (DailyLog, error) {
+	l := DailyLog{Version: DailyVersion, Entries: map[string]*DailyEntry{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	var read DailyLog
+	if err := json.Unmarshal(data, &read); err != nil {
+		return l, fmt.Errorf("%s: %v", path, err)
+	}
+	if read.Version > DailyVersion {
+		return l, fmt.Errorf("%s: daily version %d is newer than this game (%d)", path, read.Version, DailyVersion)
+	}
+	if read.Entries == nil {
+		read.Entries = map[string]*DailyEntry{}
+	}
+	read.Version = DailyVersion
+	return read, nil
+}
+
+// SaveDaily writes l to path.
+func SaveDaily(path string, l DailyLog) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+func (g *Game) saveDaily() {
+	if g.dailyPath == "" {
+		return
+	}
+	if err := SaveDaily(g.dailyPath, g.daily); err != nil {
+		log.Printf("saving daily log: %v", err)
+	}
+}
+
+// startDaily uses up today's attempt and starts the challenge. It reports
+// false if today's challenge was already played.
+func (g *Game) startDaily() bool {
+	date := today()
+	if _, played := g.daily.Entries[date]; played {
+		return false
+	}
+	cfg := DailyConfig(date)
+	g.daily.Entries[date] = &DailyEntry{Date: date, Modifiers: cfg.Modifiers}
+	g.saveDaily()
+	g.beginRun(cfg)
+	return true
+}
+
+// finishDaily records the challenge's score and writes its result file.
+func (g *Game) finishDaily() {
+	date := g.config.Date
+	e, ok := g.daily.Entries[date]
+	if !ok {
+		e = &DailyEntry{Date: date, Modifiers: g.config.Modifiers}
+		g.daily.Entries[date] = e
+	}
+	e.Finished = true
+	e.Score = g.score
+	if g.dailyPath != "" {
+		path := filepath.Join(filepath.Dir(g.dailyPath), "results", "daily-"+date+".json")
+		r := RunResult{Version: ResultVersion, Name: g.playerName(), Score: g.score, Replay: g.replay()}
+		if err := SaveResult(path, r); err != nil {
+			log.Printf("saving daily result: %v", err)
+		} else {
+			e.Result = path
+		}
+	}
+	g.saveDaily()
+}
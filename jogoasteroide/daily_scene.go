@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DailyScene shows today's challenge, starts it and lists past scores.
+type DailyScene struct {
+	g      *Game
+	list   List
+	notice string
+}
+
+func newDailyScene(g *Game) *DailyScene {
+	s := &DailyScene{g: g}
+	s.list.Items = []Widget{
+		g.button("daily.play", s.play),
+		g.button("settings.back", func() { g.scenes.Pop(SlideOut) }),
+	}
+	return s
+}
+
+func (s *DailyScene) play() {
+	g := s.g
+	start := func() {
+		if !g.startDaily() {
+			s.notice = g.loc.T("daily.used")
+		}
+	}
+	if _, played := g.daily.Entries[today()]; played {
+		s.notice = g.loc.T("daily.used")
+	} else {
+		g.confirmNewRun(start)
+	}
+}
+
+// dailyStatus describes the attempt at date's challenge.
+func (g *Game) dailyStatus(date string) string {
+	e, ok := g.daily.Entries[date]
+	switch {
+	case !ok:
+		return g.loc.T("daily.ready")
+	case !e.Finished:
+		return g.loc.T("daily.unfinished")
+	}
+	return g.loc.N("daily.played", e.Score)
+}
+
+func (s *DailyScene) Update() error {
+	if s.g.nav.Back {
+		s.g.scenes.Pop(SlideOut)
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *DailyScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	date := today()
+	cfg := DailyConfig(date)
+	drawAnchoredText(screen, g.loc.T("daily.title", date), g.fonts.Large, AnchorTop, 0, 40, g.theme.Text)
+	info := g.loc.T("daily.modifiers", g.modifierNamesText(cfg.Modifiers)) + "\n" + g.dailyStatus(date)
+	drawAnchoredText(screen, info, g.fonts.Normal, AnchorTop, 0, 110, g.theme.Text)
+	s.list.Draw(screen, 200, g.menuStyle())
+
+	drawAnchoredText(screen, g.loc.T("daily.board"), g.fonts.Normal, AnchorTop, 0, 290, g.theme.Highlight)
+	var rows []statRow
+	for _, e := range g.daily.Board(DailyBoardSize) {
+		rows = append(rows, statRow{e.Date + "  " + g.modifierNamesText(e.Modifiers), fmt.Sprint(e.Score)})
+	}
+	if len(rows) == 0 {
+		rows = append(rows, statRow{g.loc.T("daily.board_empty"), ""})
+	}
+	drawStatRows(screen, rows, 330, g.menuStyle())
+
+	notice := s.notice
+	if e, ok := g.daily.Entries[date]; ok && notice == "" && e.Result != "" {
+		notice = g.loc.T("daily.result", e.Result)
+	}
+	if notice != "" {
+		drawAnchoredText(screen, notice, g.fonts.Small, AnchorBottom, 0, 20, g.theme.Message)
+	}
+}
+
+func (s *DailyScene) Overlay() bool { return false }
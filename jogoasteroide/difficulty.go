@@ -0,0 +1,44 @@
+package main
+
+// Difficulty selects one of the gameplay presets.
+type Difficulty int
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyNormal
+	DifficultyHard
+	difficultyCount
+)
+
+var difficultyNames = [difficultyCount]string{"easy", "normal", "hard"}
+
+func (d Difficulty) String() string {
+	return difficultyNames[d.normalize()]
+}
+
+// DifficultyPreset holds the values a difficulty changes.
+type DifficultyPreset struct {
+	Health          int     // hits the ship can take
+	AsteroidSpeed   float64 // multiplier on asteroid velocity
+	StartAsteroids  int     // asteroids on screen at the start of a run
+	PowerUpInterval int     // frames between power-up spawns
+}
+
+var difficultyPresets = [difficultyCount]DifficultyPreset{
+	DifficultyEasy:   {Health: 5, AsteroidSpeed: 0.75, StartAsteroids: MaxAsteroids - 4, PowerUpInterval: 400},
+	DifficultyNormal: {Health: 3, AsteroidSpeed: 1, StartAsteroids: MaxAsteroids, PowerUpInterval: 500},
+	DifficultyHard:   {Health: 2, AsteroidSpeed: 1.3, StartAsteroids: MaxAsteroids + 4, PowerUpInterval: 700},
+}
+
+// Preset returns the values for d, falling back to normal when d is out
+// of range (for example from a hand-edited settings file).
+func (d Difficulty) Preset() DifficultyPreset {
+	return difficultyPresets[d.normalize()]
+}
+
+func (d Difficulty) normalize() Difficulty {
+	if d < 0 || d >= difficultyCount {
+		return DifficultyNormal
+	}
+	return d
+}
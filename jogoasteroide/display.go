@@ -0,0 +1,121 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"golang.org/x/image/font"
+)
+
+// ScaleMode selects how the logical screen is fitted into the window.
+type ScaleMode int
+
+const (
+	ScaleSmooth  ScaleMode = iota // fractional scale with linear filtering
+	ScaleInteger                  // largest whole-number scale, pixel perfect
+)
+
+// DisplaySettings holds the window and scaling options changed at runtime.
+type DisplaySettings struct {
+	Fullscreen bool      `json:"fullscreen"`
+	ScaleMode  ScaleMode `json:"scale_mode"`
+}
+
+// apply pushes the settings to the window.
+func (d DisplaySettings) apply() {
+	ebiten.SetFullscreen(d.Fullscreen)
+}
+
+// fitScreen returns the scale and offsets that place the logical screen
+// centred inside an outside area of w x h pixels, leaving letterbox bars
+// on whichever axis has spare room.
+func fitScreen(w, h int, mode ScaleMode) (scale, offsetX, offsetY float64) {
+	scale = math.Min(float64(w)/ScreenWidth, float64(h)/ScreenHeight)
+	if mode == ScaleInteger && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	offsetX = math.Floor((float64(w) - ScreenWidth*scale) / 2)
+	offsetY = math.Floor((float64(h) - ScreenHeight*scale) / 2)
+	return scale, offsetX, offsetY
+}
+
+// Anchor is a reference point on the logical screen used to place HUD elements.
+type Anchor int
+
+const (
+	AnchorTopLeft Anchor = iota
+	AnchorTop
+	AnchorTopRight
+	AnchorLeft
+	AnchorCenter
+	AnchorRight
+	AnchorBottomLeft
+	AnchorBottom
+	AnchorBottomRight
+)
+
+// Place returns the top-left corner of a w x h element anchored at a.
+// The margin (mx, my) pushes the element inwards from the edges it is
+// attached to; for centred axes it is a plain offset.
+func (a Anchor) Place(w, h, mx, my float64) (x, y float64) {
+	switch a % 3 {
+	case 0:
+		x = mx
+	case 1:
+		x = (ScreenWidth-w)/2 + mx
+	case 2:
+		x = ScreenWidth - w - mx
+	}
+	switch a / 3 {
+	case 0:
+		y = my
+	case 1:
+		y = (ScreenHeight-h)/2 + my
+	case 2:
+		y = ScreenHeight - h - my
+	}
+	return x, y
+}
+
+// drawAnchoredText draws s so that its text box sits at the anchor, with
+// lines aligned towards the anchored edge.
+func drawAnchoredText(screen *ebiten.Image, s string, face font.Face, a Anchor, mx, my float64, clr color.Color) {
+	w, h := MeasureText(face, s)
+	x, y := a.Place(w, h, mx, my)
+	DrawText(screen, s, face, x, y, Align(a%3), clr)
+}
+
+// toggleFullscreen flips between window and fullscreen modes.
+func (g *Game) toggleFullscreen() {
+	g.settings.Display.Fullscreen = !g.settings.Display.Fullscreen
+	g.settings.Display.apply()
+}
+
+// toggleScaleMode switches between smooth and integer scaling.
+func (g *Game) toggleScaleMode() {
+	if g.settings.Display.ScaleMode == ScaleSmooth {
+		g.settings.Display.ScaleMode = ScaleInteger
+	} else {
+		g.settings.Display.ScaleMode = ScaleSmooth
+	}
+}
+
+// Layout keeps a fixed logical resolution; DrawFinalScreen does the scaling.
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return ScreenWidth, ScreenHeight
+}
+
+// DrawFinalScreen scales the logical screen into the window with letterboxing.
+func (g *Game) DrawFinalScreen(screen ebiten.FinalScreen, offscreen *ebiten.Image, geoM ebiten.GeoM) {
+	b := screen.Bounds()
+	scale, ox, oy := fitScreen(b.Dx(), b.Dy(), g.settings.Display.ScaleMode)
+	screen.Fill(g.theme.Letterbox)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(ox, oy)
+	if scale != math.Floor(scale) {
+		op.Filter = ebiten.FilterLinear
+	}
+	screen.DrawImage(offscreen, op)
+}
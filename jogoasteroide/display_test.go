@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestFitScreen(t *testing.T) {
+	tests := []struct {
+		name          string
+		w, h          int
+		mode          ScaleMode
+		scale, ox, oy float64
+	}{
+		{"nativo", 1280, 720, ScaleSmooth, 1, 0, 0},
+		{"ultrawide", 2560, 1080, ScaleSmooth, 1.5, 320, 0},
+		{"retrato", 1280, 1280, ScaleSmooth, 1, 0, 280},
+		{"inteiro", 3000, 1700, ScaleInteger, 2, 220, 130},
+		{"menor que nativo", 640, 360, ScaleInteger, 0.5, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, ox, oy := fitScreen(tt.w, tt.h, tt.mode)
+			if scale != tt.scale || ox != tt.ox || oy != tt.oy {
+				t.Errorf("fitScreen(%d, %d) = %v, %v, %v; esperado %v, %v, %v", tt.w, tt.h, scale, ox, oy, tt.scale, tt.ox, tt.oy)
+			}
+		})
+	}
+}
+
+func TestAnchorPlace(t *testing.T) {
+	tests := []struct {
+		anchor Anchor
+		mx, my float64
+		x, y   float64
+	}{
+		{AnchorTopLeft, 10, 20, 10, 20},
+		{AnchorTop, 0, 20, 590, 20},
+		{AnchorCenter, 0, 0, 590, 330},
+		{AnchorBottomRight, 10, 20, 1170, 640},
+	}
+
+	for _, tt := range tests {
+		x, y := tt.anchor.Place(100, 60, tt.mx, tt.my)
+		if x != tt.x || y != tt.y {
+			t.Errorf("anchor %d: Place = %v, %v; esperado %v, %v", tt.anchor, x, y, tt.x, tt.y)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+// EventKind is something that happened during a run that other systems,
+// such as statistics, want to know about.
+type EventKind int
+
+const (
+	EventShot              EventKind = iota // a bullet was fired
+	EventAsteroidDestroyed                  // a bullet destroyed an asteroid
+	EventPowerUp                            // the player collected a power-up
+	EventDamage                             // the player lost health
+	EventShieldBlock                        // the shield absorbed a hit
+	EventTick                               // one simulation step passed
+	eventKindCount
+)
+
+var eventNames = [eventKindCount]string{"shot", "asteroid_destroyed", "power_up", "damage", "shield_block", "tick"}
+
+func (k EventKind) String() string {
+	return eventNames[k]
+}
+
+// Event describes one EventKind. Only the fields that apply to the kind
+// are set.
+type Event struct {
+	Kind    EventKind
+	Size    float64     // asteroid size, for EventAsteroidDestroyed
+	PowerUp PowerUpType // for EventPowerUp
+}
+
+// emit reports an event from the simulation.
+func (g *Game) emit(e Event) {
+	g.stats.Record(e)
+	for _, a := range g.achievements.Record(e) {
+		g.unlocked(a)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Font sizes in pixels on the logical screen.
+const (
+	FontSizeSmall  = 14
+	FontSizeNormal = 20
+	FontSizeLarge  = 32
+	FontSizeTitle  = 48
+)
+
+// Fonts holds the faces used by the game, one per size.
+type Fonts struct {
+	Small  font.Face
+	Normal font.Face
+	Large  font.Face
+	Title  font.Face
+}
+
+// glyphSubstitutes lists stand-ins, best first, for characters that the
+// loaded fonts are unlikely to cover, mostly emoji.
+var glyphSubstitutes = map[rune][]rune{
+	'🌟': {'★', '*'},
+	'⭐': {'★', '*'},
+	'★': {'*'},
+	'❤': {'♥', '<'},
+	'🚀': {'▲', '^'},
+	'💥': {'✹', '*'},
+	'←': {'<'},
+	'→': {'>'},
+	'↑': {'^'},
+	'↓': {'v'},
+}
+
+// loadFonts builds the game faces. Packs may override the embedded Go
+// fonts through the "font-regular" and "font-bold" assets, and may add a
+// "font-fallback" font (an emoji or symbol font, say) that is consulted
+// for characters the main font lacks.
+func loadFonts(assets *AssetStore) Fonts {
+	regular := parseFont(assets, "font-regular", goregular.TTF)
+	bold := parseFont(assets, "font-bold", gobold.TTF)
+	var extra []*sfnt.Font
+	if data, err := assets.Data("font-fallback"); err == nil {
+		if f, err := opentype.Parse(data); err == nil {
+			extra = append(extra, f)
+		} else {
+			log.Printf("font-fallback: %v", err)
+		}
+	}
+	return Fonts{
+		Small:  newFallbackFace(FontSizeSmall, append([]*sfnt.Font{regular}, extra...)...),
+		Normal: newFallbackFace(FontSizeNormal, append([]*sfnt.Font{regular}, extra...)...),
+		Large:  newFallbackFace(FontSizeLarge, append([]*sfnt.Font{bold, regular}, extra...)...),
+		Title:  newFallbackFace(FontSizeTitle, append([]*sfnt.Font{bold, regular}, extra...)...),
+	}
+}
+
+// parseFont loads the named font asset, or the built-in one when the
+// asset is missing or broken.
+func parseFont(assets *AssetStore, name string, builtin []byte) *sfnt.Font {
+	if data, err := assets.Data(name); err == nil {
+		f, err := opentype.Parse(data)
+		if err == nil {
+			return f
+		}
+		log.Printf("%s: %v; using the built-in font", name, err)
+	}
+	f, err := opentype.Parse(builtin)
+	if err != nil {
+		panic(err) // the built-in fonts are known good
+	}
+	return f
+}
+
+// fallbackFace draws each character with the first font that has it,
+// then tries glyphSubstitutes, and finally falls back to a question mark.
+type fallbackFace struct {
+	fonts []*sfnt.Font
+	faces []font.Face
+	buf   sfnt.Buffer
+}
+
+func newFallbackFace(size float64, fonts ...*sfnt.Font) *fallbackFace {
+	f := &fallbackFace{fonts: fonts}
+	for _, fnt := range fonts {
+		face, err := opentype.NewFace(fnt, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			panic(err) // only fails for invalid options
+		}
+		f.faces = append(f.faces, face)
+	}
+	return f
+}
+
+// covers returns the first face whose font has a glyph for r.
+func (f *fallbackFace) covers(r rune) (font.Face, bool) {
+	for i, fnt := range f.fonts {
+		if idx, err := fnt.GlyphIndex(&f.buf, r); err == nil && idx != 0 {
+			return f.faces[i], true
+		}
+	}
+	return nil, false
+}
+
+// resolve returns the face and rune used to draw r.
+func (f *fallbackFace) resolve(r rune) (font.Face, rune) {
+	if face, ok := f.covers(r); ok {
+		return face, r
+	}
+	for _, sub := range glyphSubstitutes[r] {
+		if face, ok := f.covers(sub); ok {
+			return face, sub
+		}
+	}
+	return f.faces[0], '?'
+}
+
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	face, r := f.resolve(r)
+	return face.Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	face, r := f.resolve(r)
+	return face.GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	face, r := f.resolve(r)
+	return face.GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face0, r0 := f.resolve(r0)
+	face1, r1 := f.resolve(r1)
+	if face0 != face1 {
+		return 0
+	}
+	return face0.Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
+
+// Align is the horizontal alignment of each line in a text block.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignCenter
+	AlignRight
+)
+
+// lineHeight is the distance between baselines.
+func lineHeight(face font.Face) float64 {
+	return float64(face.Metrics().Height.Ceil())
+}
+
+// MeasureText returns the size of the box that s occupies, one line per
+// "\n", using advances so trailing spaces and accents are accounted for.
+func MeasureText(face font.Face, s string) (w, h float64) {
+	lines := strings.Split(s, "\n")
+	for _, line := range lines {
+		w = math.Max(w, float64(font.MeasureString(face, line).Ceil()))
+	}
+	return w, lineHeight(face) * float64(len(lines))
+}
+
+// DrawText draws s in a box whose top-left corner is (x, y), aligning
+// each line inside the width of the widest one.
+func DrawText(dst *ebiten.Image, s string, face font.Face, x, y float64, align Align, clr color.Color) {
+	boxW, _ := MeasureText(face, s)
+	ascent := float64(face.Metrics().Ascent.Ceil())
+	for i, line := range strings.Split(s, "\n") {
+		w := float64(font.MeasureString(face, line).Ceil())
+		lx := x
+		switch align {
+		case AlignCenter:
+			lx += (boxW - w) / 2
+		case AlignRight:
+			lx += boxW - w
+		}
+		text.Draw(dst, line, face, int(lx), int(y+ascent+float64(i)*lineHeight(face)), clr)
+	}
+}
+
+// DrawTextCentered draws s centred on (cx, cy).
+func DrawTextCentered(dst *ebiten.Image, s string, face font.Face, cx, cy float64, clr color.Color) {
+	w, h := MeasureText(face, s)
+	DrawText(dst, s, face, cx-w/2, cy-h/2, AlignCenter, clr)
+}
+
+// WrapText breaks s into lines no wider than maxWidth, splitting at
+// spaces. Existing line breaks are kept; a single word wider than
+// maxWidth gets a line of its own.
+func WrapText(face font.Face, s string, maxWidth float64) string {
+	var out []string
+	for _, para := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if line != "" && float64(font.MeasureString(face, candidate).Ceil()) > maxWidth {
+				out = append(out, line)
+				line = word
+			} else {
+				line = candidate
+			}
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
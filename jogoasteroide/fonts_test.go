@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+)
+
+func testFonts(t *testing.T) Fonts {
+	t.Helper()
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loadFonts(assets)
+}
+
+func TestFallbackFaceCoversPortuguese(t *testing.T) {
+	face := testFonts(t).Normal.(*fallbackFace)
+	for _, r := range "Você foi atingido! Tiro rápido, pontuação, começar ← → ↑" {
+		if _, ok := face.covers(r); !ok {
+			t.Errorf("fonte sem glifo para %q", r)
+		}
+	}
+}
+
+func TestFallbackFaceSubstitutes(t *testing.T) {
+	face := testFonts(t).Normal.(*fallbackFace)
+	if _, r := face.resolve('🌟'); r == '?' || r == '🌟' {
+		t.Errorf("resolve('🌟') = %q; esperado um substituto", r)
+	}
+	if _, r := face.resolve('\U0001F47E'); r != '?' {
+		t.Errorf("resolve de emoji sem substituto = %q; esperado '?'", r)
+	}
+	if _, ok := face.GlyphAdvance('🌟'); !ok {
+		t.Error("GlyphAdvance('🌟') deveria usar o substituto")
+	}
+}
+
+func TestMeasureText(t *testing.T) {
+	face := testFonts(t).Normal
+	w1, h1 := MeasureText(face, "abcd")
+	w2, h2 := MeasureText(face, "ab\nabcd")
+	if w1 != w2 {
+		t.Errorf("largura = %v; esperado a da linha mais longa, %v", w2, w1)
+	}
+	if h2 != 2*h1 {
+		t.Errorf("altura de duas linhas = %v; esperado %v", h2, 2*h1)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	face := testFonts(t).Normal
+	s := "Destrua os asteroides grandes para vê-los se dividirem em pedaços menores"
+	max := 200.0
+	wrapped := WrapText(face, s, max)
+	lines := strings.Split(wrapped, "\n")
+	if len(lines) < 2 {
+		t.Fatalf("WrapText não quebrou a linha: %q", wrapped)
+	}
+	for _, line := range lines {
+		if w := float64(font.MeasureString(face, line).Ceil()); w > max {
+			t.Errorf("linha %q tem largura %v; máximo %v", line, w, max)
+		}
+	}
+	if strings.Join(strings.Fields(wrapped), " ") != s {
+		t.Errorf("WrapText alterou o texto: %q", wrapped)
+	}
+}
@@ -1,37 +1,20 @@
 package main
 
 import (
-	"fmt"
-	"image/color"
-	"log"
 	"math"
-	"math/rand"
+	"math/rand/v2"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/text"
-	"golang.org/x/image/font"
-)
-
-
-
-
-
-type GameState int
-
-const (
-	StateMenu GameState = iota
-	StatePlaying
-	StatePaused
-	StateGameOver
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 type BulletPool struct {
-	pool []Bullet
+	pool []*Bullet
 }
 
 func (p *BulletPool) Get() *Bullet {
 	if len(p.pool) > 0 {
-		b := &p.pool[len(p.pool)-1]
+		b := p.pool[len(p.pool)-1]
 		p.pool = p.pool[:len(p.pool)-1]
 		return b
 	}
@@ -40,16 +23,16 @@ func (p *BulletPool) Get() *Bullet {
 
 func (p *BulletPool) Put(b *Bullet) {
 	*b = Bullet{} // reset
-	p.pool = append(p.pool, *b)
+	p.pool = append(p.pool, b)
 }
 
 type ExplosionPool struct {
-	pool []Explosion
+	pool []*Explosion
 }
 
 func (p *ExplosionPool) Get() *Explosion {
 	if len(p.pool) > 0 {
-		e := &p.pool[len(p.pool)-1]
+		e := p.pool[len(p.pool)-1]
 		p.pool = p.pool[:len(p.pool)-1]
 		return e
 	}
@@ -58,90 +41,163 @@ func (p *ExplosionPool) Get() *Explosion {
 
 func (p *ExplosionPool) Put(e *Explosion) {
 	*e = Explosion{} // reset
-	p.pool = append(p.pool, *e)
+	p.pool = append(p.pool, e)
 }
 
 type Game struct {
-	player             Player
-	bullets            []*Bullet
-	asteroids          []Asteroid
-	explosions         []*Explosion
-	powerUps           []*PowerUp
-	bulletPool         BulletPool
-	explosionPool      ExplosionPool
-	powerUpPool        PowerUpPool
-	score              int
-	highScore          int
-	state              GameState
-	frames             int
-	fontFace           font.Face
-	playerW            float64
-	playerH            float64
-	asteroidW          float64
-	asteroidH          float64
-	scale              float64
-	message            string
-	messageTimer       int
+	players             []Player
+	sharedHealth        int // the team's health when lives are shared
+	round               int // the versus round being played, from 1
+	wins                []int
+	kills               []int
+	roundEnding         int  // steps until a decided versus round ends
+	roundWinner         int  // of the last versus round, or -1 for a draw
+	headless            bool // a simulation: no scenes, files or sounds to update
+	lastID              uint32
+	bullets             []*Bullet
+	asteroids           []Asteroid
+	explosions          []*Explosion
+	powerUps            []*PowerUp
+	bulletPool          BulletPool
+	explosionPool       ExplosionPool
+	powerUpPool         PowerUpPool
+	score               int
+	highScore           int
+	scenes              SceneStack
+	navigator           navigator
+	nav                 Nav
+	quitting            bool
+	frames              int
+	tick                int
+	rng                 *RNG
+	config              RunConfig
+	inputs              []Input // every simulation step of the run, for its replay
+	running             bool
+	focused             bool
+	savePath            string
+	stats               RunStats
+	lifetime            LifetimeStats
+	statsPath           string
+	achievements        *Achievements
+	achievementsPath    string
+	toasts              []toast
+	daily               DailyLog
+	dailyPath           string
+	broadcast           *Broadcaster // spectators of the runs played here, if any
+	bot                 *Bot         // plays the last ship, if the run has a CPU player
+	local               int          // the ship played on this machine, which hears the sounds
+	lockstep            bool         // played in step with a peer: the camera must not decide how it ends
+	ghosts              Ghosts
+	ghostsPath          string
+	ghost               *Ghost // the best run on this seed, raced alongside
+	tutorial            TutorialProgress
+	tutorialPath        string
+	tutorialSteps       []TutorialStep
+	fonts               Fonts
+	loc                 *Localizer
+	playerW             float64
+	playerH             float64
+	asteroidW           float64
+	asteroidH           float64
+	settings            Settings
+	theme               *Theme
+	settingsPath        string
+	assets              *AssetStore
+	camera              *Camera
+	mixer               *Mixer
+	world               *ebiten.Image
+	dying               bool
+	message             string
+	messageTimer        int
 	currentMaxAsteroids int
 }
 
-func NewGame() *Game {
-	fontFace := loadFont()
+func NewGame(assets *AssetStore) *Game {
 	g := &Game{
-		fontFace: fontFace,
-		state:    StateMenu,
+		assets:   assets,
+		fonts:    loadFonts(assets),
+		focused:  true,
+		lifetime: LifetimeStats{Version: StatsVersion},
+		daily:    DailyLog{Version: DailyVersion, Entries: map[string]*DailyEntry{}},
+		ghosts:   Ghosts{Version: GhostVersion, Runs: map[string]RunResult{}},
+		tutorial: TutorialProgress{Version: TutorialVersion},
+		settings: DefaultSettings(),
 	}
+	g.loc = NewLocalizer(assets, g.settings.Language)
+	g.achievements = loadAchievements(assets)
+	g.tutorialSteps = loadTutorial(assets)
+	g.camera = NewCamera(&g.settings.Effects)
+	g.mixer = NewMixer(&g.settings.Audio, &NullAudio{})
 
-	var err error
-	ImgPlayer, err = loadImage("nave.png")
-	if err != nil {
-		log.Fatalf("failed to load nave.png: %v", err)
-	}
-	boundsPlayer := ImgPlayer.Bounds()
-	g.playerW = float64(boundsPlayer.Dx())
+	g.setTheme(g.settings.Theme)
+
+	g.scenes.Reset(newMenuScene(g), Cut)
+
+	return g
+}
+
+// loadImages (re)reads the sprites from the asset store and adjusts them
+// for the theme.
+func (g *Game) loadImages() {
+	ImgPlayer = brighten(g.assets.Image("player"), g.theme.SpriteBrightness)
 	g.playerW = 64
 	g.playerH = 64
-
-	ImgAsteroid, err = loadImage("asteroide.png")
-	if err != nil {
-		log.Fatalf("failed to load asteroide.png: %v", err)
+	for i := range g.players {
+		g.players[i].img = ImgPlayer
 	}
+
+	ImgAsteroid = brighten(g.assets.Image("asteroid"), g.theme.SpriteBrightness)
 	boundsAst := ImgAsteroid.Bounds()
 	g.asteroidW = float64(boundsAst.Dx())
 	g.asteroidH = float64(boundsAst.Dy())
+}
 
-	ImgBullet = generateCircleImage(12, BulletColor)
-	ImgExplosion = generateCircleImage(40, ExplosionColor)
-	ImgHealthBg = ebiten.NewImage(200, 20)
-	ImgHealthBg.Fill(color.RGBA{255, 0, 0, 255})
-	ImgCooldownBg = ebiten.NewImage(200, 20)
-	ImgCooldownBg.Fill(color.RGBA{0, 0, 255, 255})
+// Reset starts a new classic run with a random seed.
+func (g *Game) Reset() {
+	g.ResetSeed(rand.Uint64())
+}
 
-	g.player = Player{
-		position: Vector{ScreenWidth / 2, ScreenHeight / 2},
-		width:    g.playerW,
-		height:   g.playerH,
-		img:      ImgPlayer,
-		health:   3,
-	}
+// ResetSeed starts a new classic run whose random events all derive from
+// seed.
+func (g *Game) ResetSeed(seed uint64) {
+	g.ResetRun(g.classicConfig(seed))
+}
 
-	return g
+// classicConfig is a classic run at the chosen difficulty, with the
+// chosen co-op players.
+func (g *Game) classicConfig(seed uint64) RunConfig {
+	cfg := RunConfig{Mode: ModeClassic, Seed: seed, Difficulty: g.settings.Difficulty.normalize()}
+	c := g.settings.Coop
+	ships := max(1, min(c.Players, MaxPlayers))
+	if _, ok := ParseBotSkill(c.CPU); ok {
+		ships = min(ships+1, MaxPlayers)
+		cfg.CPU = c.CPU
+	}
+	if ships > 1 {
+		cfg.Players = ships
+		cfg.SharedLives = c.SharedLives
+		cfg.FriendlyFire = c.FriendlyFire
+	}
+	return cfg
 }
 
-func (g *Game) Reset() {
-	g.player = Player{
-		position:     Vector{ScreenWidth / 2, ScreenHeight / 2},
-		width:        g.playerW,
-		height:       g.playerH,
-		img:          ImgPlayer,
-		velocity:     Vector{0, 0},
-		angle:        0,
-		fireCooldown: 0,
-		health:       3,
-		shield:       0,
-		rapidFire:    0,
-		multiShot:    0,
+// ResetRun starts a new run with the given config.
+func (g *Game) ResetRun(cfg RunConfig) {
+	cfg.Difficulty = cfg.Difficulty.normalize()
+	g.config = cfg
+	g.inputs = nil
+	g.rng = NewRNG(cfg.Seed)
+	n := cfg.playerCount()
+	g.players = make([]Player, n)
+	g.resetPlayers()
+	g.bot = nil
+	if skill, ok := ParseBotSkill(cfg.CPU); ok && n > 1 {
+		g.bot = NewBot(skill, n-1, cfg.Seed)
 	}
+	g.round = 1
+	g.wins = make([]int, n)
+	g.kills = make([]int, n)
+	g.roundEnding = 0
 	g.bullets = make([]*Bullet, 0, MaxBullets)
 	g.asteroids = make([]Asteroid, 0, MaxAsteroids+50)
 	g.explosions = make([]*Explosion, 0, 20)
@@ -150,32 +206,59 @@ func (g *Game) Reset() {
 	g.explosionPool = ExplosionPool{}
 	g.powerUpPool = PowerUpPool{}
 	g.score = 0
-	g.state = StatePlaying
-	g.frames = 0
-	g.currentMaxAsteroids = MaxAsteroids
-	for i := 0; i < MaxAsteroids; i++ {
+	g.tick = 0
+	g.lastID = 0
+	g.stats = RunStats{}
+	g.achievements.StartRun()
+	g.running = true
+	g.currentMaxAsteroids = g.config.preset().StartAsteroids
+	g.dying = false
+	g.camera.Reset()
+	for i := 0; i < g.currentMaxAsteroids; i++ {
 		g.spawnAsteroid()
 	}
 }
 
+// resetPlayers puts fresh ships in a line across the middle of the screen.
+func (g *Game) resetPlayers() {
+	n := len(g.players)
+	for i := range g.players {
+		g.players[i] = Player{
+			index:    i,
+			position: Vector{ScreenWidth * float64(i+1) / float64(n+1), ScreenHeight / 2},
+			width:    g.playerW,
+			height:   g.playerH,
+			img:      ImgPlayer,
+			health:   g.config.preset().Health,
+		}
+	}
+	g.sharedHealth = g.maxHealth()
+}
+
 func (g *Game) spawnAsteroid() {
 	minSize := 40.0
 	maxSize := 96.0
-	size := minSize + rand.Float64()*(maxSize-minSize)
-	pos := Vector{X: rand.Float64() * float64(ScreenWidth), Y: rand.Float64()*float64(ScreenHeight)/4 - size}
-	speedMultiplier := 1.0 + float64(g.score)/5000.0 // Increase speed with score
-	vel := Vector{X: (rand.Float64()*2 - 1) * 1.5 * speedMultiplier, Y: rand.Float64()*2 + 1 * speedMultiplier}
-	rotSpeed := (rand.Float64()*2 - 1) * 0.04
-	g.asteroids = append(g.asteroids, Asteroid{position: pos, velocity: vel, size: size, rotSpeed: rotSpeed})
+	size := minSize + g.rng.Float64()*(maxSize-minSize)
+	pos := Vector{X: g.rng.Float64() * float64(ScreenWidth), Y: g.rng.Float64()*float64(ScreenHeight)/4 - size}
+	speedMultiplier := (1.0 + float64(g.score)/5000.0) * g.config.preset().AsteroidSpeed // Increase speed with score
+	vel := Vector{X: (g.rng.Float64()*2 - 1) * 1.5 * speedMultiplier, Y: g.rng.Float64()*2 + 1*speedMultiplier}
+	rotSpeed := (g.rng.Float64()*2 - 1) * 0.04
+	g.asteroids = append(g.asteroids, Asteroid{id: g.newID(), position: pos, velocity: vel, size: size, rotSpeed: rotSpeed})
 }
 
 func (g *Game) spawnPowerUp() {
-	pos := Vector{X: rand.Float64() * float64(ScreenWidth), Y: rand.Float64()*float64(ScreenHeight)/4 - 20}
-	vel := Vector{X: (rand.Float64()*2 - 1) * 1.0, Y: rand.Float64()*1.5 + 0.5}
-	powerType := PowerUpType(rand.Intn(4)) // Random type
+	pos := Vector{X: g.rng.Float64() * float64(ScreenWidth), Y: g.rng.Float64()*float64(ScreenHeight)/4 - 20}
+	vel := Vector{X: (g.rng.Float64()*2 - 1) * 1.0, Y: g.rng.Float64()*1.5 + 0.5}
+	powerType := PowerUpType(g.rng.IntN(int(powerUpCount))) // Random type
+	if g.config.Mode == ModeVersus {
+		// Drop it where the ships have to fight for it, drifting slowly.
+		pos = g.contestedPoint()
+		vel = vel.Scaled(0.25)
+	}
 	size := 20.0
 	maxAge := PowerUpMaxAge // 10 seconds at 60fps
 	pw := g.powerUpPool.Get()
+	pw.id = g.newID()
 	pw.position = pos
 	pw.velocity = vel
 	pw.powerType = powerType
@@ -187,106 +270,216 @@ func (g *Game) spawnPowerUp() {
 
 func (g *Game) Update() error {
 	g.frames++
-	switch g.state {
-	case StateMenu:
-		if ebiten.IsKeyPressed(ebiten.KeyEnter) {
-			g.Reset()
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyP) {
-			g.state = StatePaused
-		}
-	case StatePlaying:
-		if ebiten.IsKeyPressed(ebiten.KeyP) {
-			g.state = StatePaused
-		} else {
-			g.updatePlaying()
-		}
-	case StatePaused:
-		if ebiten.IsKeyPressed(ebiten.KeyP) {
-			g.state = StatePlaying
-		}
-	case StateGameOver:
-		if ebiten.IsKeyPressed(ebiten.KeyR) {
-			g.Reset()
+	g.mixer.Update()
+	g.updateToasts()
+	if g.frames%30 == 0 && g.assets.Poll() {
+		g.loadImages()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		g.toggleFullscreen()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF10) {
+		g.toggleScaleMode()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.mixer.ToggleMute()
+	}
+	if ebiten.IsWindowBeingClosed() {
+		g.autosave()
+		return ebiten.Termination
+	}
+	if focused := ebiten.IsFocused(); focused != g.focused {
+		g.focused = focused
+		if !focused {
+			g.pauseOnFocusLoss()
 		}
 	}
+	g.nav = g.navigator.read()
+	if err := g.scenes.Update(); err != nil {
+		return err
+	}
+	if g.quitting {
+		return ebiten.Termination
+	}
 	return nil
 }
 
-func (g *Game) updatePlaying() {
-	g.player.Update()
-	cooldown := FireCooldown
-	if g.player.rapidFire > 0 {
-		cooldown = 5
+// updatePlaying advances the run by one step, with one input per player
+// in order; missing inputs count as nothing held.
+func (g *Game) updatePlaying(in ...Input) {
+	if g.dying && !g.camera.SlowMotionActive() {
+		g.endRun()
+		return
 	}
-	if ebiten.IsKeyPressed(ebiten.KeySpace) && g.player.fireCooldown <= 0 && len(g.bullets) < MaxBullets {
-		g.fireBullet()
-		g.player.fireCooldown = cooldown
+	for i := range g.players {
+		var x Input
+		if i < len(in) {
+			x = in[i]
+		}
+		g.inputs = append(g.inputs, x)
+	}
+	in = g.inputs[len(g.inputs)-len(g.players):]
+	g.tick++
+	if g.messageTimer > 0 {
+		g.messageTimer--
+	}
+	distance := 0.0
+	for i := range g.players {
+		if p := &g.players[i]; !p.out {
+			p.Update(in[i])
+			distance += p.velocity.Len()
+		}
+	}
+	g.stats.Advance(distance)
+	g.emit(Event{Kind: EventTick})
+	for i := range g.players {
+		p := &g.players[i]
+		if p.out {
+			continue
+		}
+		if p.isAccelerating && g.tick%ThrustSoundInterval == 0 {
+			g.mixer.Play(SoundThrust)
+		}
+		cooldown := FireCooldown
+		if p.rapidFire > 0 {
+			cooldown = 5
+		}
+		if in[i].Has(ActionFire) && p.fireCooldown <= 0 && len(g.bullets) < MaxBullets {
+			g.fireBullet(p)
+			p.fireCooldown = cooldown
+		}
 	}
 	g.updateBullets()
 	g.updateAsteroids()
 	g.updateExplosions()
 	g.updatePowerUps()
-	for _, a := range g.asteroids {
-		if circleCollision(g.player.position.X, g.player.position.Y, g.player.width/2, a.position.X, a.position.Y, a.size/2) {
-			if g.player.shield <= 0 {
-				g.player.health--
-				if g.player.health <= 0 {
-					g.state = StateGameOver
-					if g.score > g.highScore {
-						g.highScore = g.score
-					}
-				} else {
-					g.message = "Você foi atingido!"
-					g.messageTimer = 120
-				}
-			} else {
-				g.message = "Escudo protegeu!"
-				g.messageTimer = 120
+	for i := range g.players {
+		p := &g.players[i]
+		for _, a := range g.asteroids {
+			if g.dying || p.out {
+				break
+			}
+			if circleCollision(p.position.X, p.position.Y, p.width/2, a.position.X, a.position.Y, a.size/2) {
+				g.hurt(p, -1)
+				break
 			}
-			break
 		}
 	}
 	// Progressive difficulty: increase max asteroids based on score
-	g.currentMaxAsteroids = MaxAsteroids + g.score/1000
-	if len(g.asteroids) < g.currentMaxAsteroids && g.frames%60 == 0 {
+	g.currentMaxAsteroids = g.config.preset().StartAsteroids + g.score/1000
+	if len(g.asteroids) < g.currentMaxAsteroids && g.tick%60 == 0 && g.config.Mode != ModeTutorial {
 		g.spawnAsteroid()
 	}
-	if g.frames%500 == 0 {
+	if interval := g.config.preset().PowerUpInterval; interval > 0 && g.tick%interval == 0 {
 		g.spawnPowerUp()
 	}
 	// Check powerup collection
-	for i, p := range g.powerUps {
-		if circleCollision(g.player.position.X, g.player.position.Y, g.player.width/2, p.position.X, p.position.Y, p.size/2) {
-			g.applyPowerUp(p.powerType)
-			g.powerUpPool.Put(p)
-			g.powerUps = append(g.powerUps[:i], g.powerUps[i+1:]...)
-			break
+	for i := range g.players {
+		p := &g.players[i]
+		if p.out {
+			continue
+		}
+		for j, pw := range g.powerUps {
+			if circleCollision(p.position.X, p.position.Y, p.width/2, pw.position.X, pw.position.Y, pw.size/2) {
+				g.applyPowerUp(p, pw.powerType)
+				g.powerUpPool.Put(pw)
+				g.powerUps = append(g.powerUps[:j], g.powerUps[j+1:]...)
+				break
+			}
+		}
+	}
+	if g.roundEnding > 0 {
+		g.roundEnding--
+		if g.roundEnding == 0 {
+			g.endRound()
 		}
 	}
 }
 
-func (g *Game) fireBullet() {
-	offsetX := math.Sin(g.player.angle) * g.player.height / 2
-	offsetY := -math.Cos(g.player.angle) * g.player.height / 2
-	bulletPos := Vector{X: g.player.position.X + offsetX, Y: g.player.position.Y + offsetY}
-	if g.player.multiShot > 0 {
-		angles := []float64{g.player.angle, g.player.angle - 0.2, g.player.angle + 0.2}
-		for _, ang := range angles {
-			bulletVel := Vector{X: math.Sin(ang) * BulletSpeed, Y: -math.Cos(ang) * BulletSpeed}
-			b := g.bulletPool.Get()
-			b.position = bulletPos
-			b.velocity = bulletVel
-			b.age = 0
-			g.bullets = append(g.bullets, b)
-		}
+// hurt hits p with an asteroid, or a bullet fired by player by (-1 for
+// asteroids): the shield takes it if it is up, otherwise p (or the team,
+// with shared lives) loses health.
+func (g *Game) hurt(p *Player, by int) {
+	if p.shield > 0 {
+		g.emit(Event{Kind: EventShieldBlock})
+		g.notify(p, "msg.shield_blocked")
+		return
+	}
+	health := g.health(p)
+	*health--
+	g.emit(Event{Kind: EventDamage})
+	g.mixer.Play(SoundDamage)
+	g.camera.AddTrauma(0.6)
+	g.camera.Flash()
+	if *health > 0 {
+		g.notify(p, "msg.hit")
+		return
+	}
+	if g.config.Mode == ModeVersus {
+		g.knockOut(p, by)
+		return
+	}
+	if !g.config.SharedLives && g.playersLeft() > 1 {
+		// The others play on; this ship is out of the run.
+		p.out = true
+		g.explode(p.position)
+		g.notify(p, "msg.player_out")
+		return
+	}
+	// Let the final hit play out in slow motion before the game-over
+	// screen, unless the effect is turned off. A peer-to-peer run is left
+	// dying either way, as each peer's camera differs; the session ends it.
+	slow := g.camera.SlowMotion(SlowMotionFrames)
+	if slow || g.lockstep {
+		g.dying = true
 	} else {
-		bulletVel := Vector{X: math.Sin(g.player.angle) * BulletSpeed, Y: -math.Cos(g.player.angle) * BulletSpeed}
+		g.endRun()
+	}
+}
+
+// endRun finishes the current run and records the high score.
+func (g *Game) endRun() {
+	g.dying = false
+	g.running = false
+	if g.headless {
+		return
+	}
+	g.deleteSave()
+	g.scenes.Push(newGameOverScene(g), Fade)
+	g.mixer.Play(SoundGameOver)
+	g.recordRun()
+	g.saveAchievements()
+	switch g.config.Mode {
+	case ModeDaily:
+		g.finishDaily()
+	case ModeVersus:
+		// A match has a winner rather than a score to beat.
+	default:
+		if g.score > g.highScore {
+			g.highScore = g.score
+		}
+		g.recordGhost()
+	}
+}
+
+func (g *Game) fireBullet(p *Player) {
+	offsetX := math.Sin(p.angle) * p.height / 2
+	offsetY := -math.Cos(p.angle) * p.height / 2
+	bulletPos := Vector{X: p.position.X + offsetX, Y: p.position.Y + offsetY}
+	g.mixer.PlayAt(SoundFire, bulletPos, g.listener())
+	angles := []float64{p.angle}
+	if p.multiShot > 0 {
+		angles = append(angles, p.angle-0.2, p.angle+0.2)
+	}
+	for _, ang := range angles {
 		b := g.bulletPool.Get()
+		b.id = g.newID()
 		b.position = bulletPos
-		b.velocity = bulletVel
+		b.velocity = Vector{X: math.Sin(ang) * BulletSpeed, Y: -math.Cos(ang) * BulletSpeed}
 		b.age = 0
+		b.owner = p.index
 		g.bullets = append(g.bullets, b)
+		g.emit(Event{Kind: EventShot})
 	}
 }
 
@@ -301,18 +494,23 @@ func (g *Game) updateBullets() {
 		hit := false
 		for j, a := range g.asteroids {
 			if circleCollision(b.position.X, b.position.Y, 5, a.position.X, a.position.Y, a.size/2) {
-				e := g.explosionPool.Get()
-				e.position = a.position
-				e.maxFrame = ExplosionFrames
-				g.explosions = append(g.explosions, e)
+				owner := &g.players[b.owner]
+				g.explode(a.position)
+				g.mixer.PlayAt(explosionSound(a.size), a.position, g.listener())
 				g.score += int(a.size) * 10
+				owner.score += int(a.size) * 10
+				g.emit(Event{Kind: EventAsteroidDestroyed, Size: a.size})
+				g.camera.AddTrauma(a.size / 300)
+				if a.size >= HitStopMinSize {
+					g.camera.HitStop(HitStopFrames)
+				}
 				if a.size > MinAsteroidSize {
 					// Split into 2 smaller asteroids
 					newSize := a.size * 0.6
 					for k := 0; k < 2; k++ {
-						angle := float64(k) * math.Pi + rand.Float64()*math.Pi/2
+						angle := float64(k)*math.Pi + g.rng.Float64()*math.Pi/2
 						vel := Vector{X: math.Cos(angle) * 2, Y: math.Sin(angle) * 2}
-						g.asteroids = append(g.asteroids, Asteroid{position: a.position, velocity: vel, size: newSize, rotSpeed: (rand.Float64()*2 - 1) * 0.04})
+						g.asteroids = append(g.asteroids, Asteroid{id: g.newID(), position: a.position, velocity: vel, size: newSize, rotSpeed: (g.rng.Float64()*2 - 1) * 0.04})
 					}
 				}
 				g.asteroids = append(g.asteroids[:j], g.asteroids[j+1:]...)
@@ -320,6 +518,9 @@ func (g *Game) updateBullets() {
 				break
 			}
 		}
+		if !hit && g.config.hostile() {
+			hit = g.shipHit(b)
+		}
 		if hit {
 			g.bulletPool.Put(b)
 		} else {
@@ -329,6 +530,30 @@ func (g *Game) updateBullets() {
 	g.bullets = active
 }
 
+// listener is where the sounds of the run are heard from: the ship played
+// on this machine.
+func (g *Game) listener() Vector {
+	if g.local < len(g.players) {
+		return g.players[g.local].position
+	}
+	return Vector{ScreenWidth / 2, ScreenHeight / 2}
+}
+
+// newID returns an id no other asteroid, bullet or power-up of the run
+// has had.
+func (g *Game) newID() uint32 {
+	g.lastID++
+	return g.lastID
+}
+
+// explode starts an explosion at pos.
+func (g *Game) explode(pos Vector) {
+	e := g.explosionPool.Get()
+	e.position = pos
+	e.maxFrame = ExplosionFrames
+	g.explosions = append(g.explosions, e)
+}
+
 func (g *Game) updateAsteroids() {
 	for i := range g.asteroids {
 		a := &g.asteroids[i]
@@ -362,121 +587,71 @@ func (g *Game) updatePowerUps() {
 	g.powerUps = active
 }
 
-func (g *Game) applyPowerUp(powerType PowerUpType) {
+func (g *Game) applyPowerUp(p *Player, powerType PowerUpType) {
+	g.mixer.Play(SoundPowerUp)
+	g.emit(Event{Kind: EventPowerUp, PowerUp: powerType})
 	switch powerType {
 	case PowerUpShield:
-		g.player.shield = 600 // 10 seconds
-		g.message = "Escudo ativado!"
-		g.messageTimer = 120
+		p.shield = 600 // 10 seconds
 	case PowerUpRapidFire:
-		g.player.rapidFire = 600
-		g.message = "Tiro rápido ativado!"
-		g.messageTimer = 120
+		p.rapidFire = 600
 	case PowerUpMultiShot:
-		g.player.multiShot = 600
-		g.message = "Tiro múltiplo ativado!"
-		g.messageTimer = 120
+		p.multiShot = 600
 	case PowerUpExtraLife:
-		g.player.health++
-		if g.player.health > 3 {
-			g.player.health = 3
-		}
-		g.message = "Vida extra!"
-		g.messageTimer = 120
+		health := g.health(p)
+		*health = min(*health+1, g.maxHealth())
 	}
+	g.notify(p, "powerup."+powerType.String())
 }
 
 func (g *Game) Draw(screen *ebiten.Image) {
-	screen.Fill(BgColor)
-	switch g.state {
-	case StateMenu:
-		g.drawMenu(screen)
-	case StatePlaying:
-		g.drawPlaying(screen)
-	case StatePaused:
-		g.drawPaused(screen)
-	case StateGameOver:
-		g.drawGameOver(screen)
-	}
-}
-
-func (g *Game) drawMenu(screen *ebiten.Image) {
-	title := "ASTEROIDES PROFISSIONAL"
-	instr := "Setas ← → para girar, ↑ para acelerar\nBarra de espaço para atirar\n\nPressione ENTER para começar"
-	y := ScreenHeight / 2
-	text.Draw(screen, title, g.fontFace, ScreenWidth/2-len(title)*7, y-80, TextColor)
-	text.Draw(screen, instr, g.fontFace, ScreenWidth/2-170, y-40, TextColor)
-	text.Draw(screen, fmt.Sprintf("Melhor pontuação: %d", g.highScore), g.fontFace, ScreenWidth/2-100, y-120, TextColor)
+	screen.Fill(g.theme.Background)
+	g.scenes.Draw(screen)
+	g.drawToasts(screen)
 }
 
 func (g *Game) drawPlaying(screen *ebiten.Image) {
-	g.player.Draw(screen)
+	// The world is drawn to its own layer so the camera can shake it
+	// without moving the HUD.
+	if g.world == nil {
+		g.world = ebiten.NewImage(ScreenWidth, ScreenHeight)
+	}
+	g.world.Clear()
+	if g.ghost != nil {
+		g.ghost.Draw(g.world, g.theme.Text)
+	}
+	g.drawPlayers(g.world)
 	for _, a := range g.asteroids {
-		a.Draw(screen)
+		a.Draw(g.world)
 	}
 	for _, b := range g.bullets {
-		b.Draw(screen)
+		b.Draw(g.world)
 	}
 	for _, e := range g.explosions {
-		e.Draw(screen)
+		e.Draw(g.world)
 	}
 	for _, p := range g.powerUps {
-		p.Draw(screen)
-	}
-	text.Draw(screen, fmt.Sprintf("Pontos: %d", g.score), g.fontFace, 24, 40, TextColor)
-	text.Draw(screen, fmt.Sprintf("Melhor: %d", g.highScore), g.fontFace, 24, 70, TextColor)
-
-	// Draw health bar
-	healthBarWidth := 200.0
-	healthBarHeight := 20.0
-	healthBarX := 24.0
-	healthBarY := 100.0
-	healthFg := ebiten.NewImage(int(healthBarWidth*float64(g.player.health)/3), int(healthBarHeight))
-	healthFg.Fill(color.RGBA{0, 255, 0, 255})
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(healthBarX, healthBarY)
-	screen.DrawImage(ImgHealthBg, op)
-	screen.DrawImage(healthFg, op)
-
-	// Draw cooldown bar
-	cooldownBarWidth := 200.0
-	cooldownBarHeight := 20.0
-	cooldownBarX := 24.0
-	cooldownBarY := 130.0
-	op2 := &ebiten.DrawImageOptions{}
-	op2.GeoM.Translate(cooldownBarX, cooldownBarY)
-	screen.DrawImage(ImgCooldownBg, op2)
-	if g.player.fireCooldown > 0 {
-		cooldownFg := ebiten.NewImage(int(cooldownBarWidth*float64(g.player.fireCooldown)/FireCooldown), int(cooldownBarHeight))
-		cooldownFg.Fill(color.RGBA{255, 255, 0, 255})
-		screen.DrawImage(cooldownFg, op2)
+		p.Draw(g.world)
 	}
+	g.camera.DrawWorld(screen, g.world)
 
-	// Draw message if any
-	if g.messageTimer > 0 {
-		bounds := text.BoundString(g.fontFace, g.message)
-		x := ScreenWidth/2 - bounds.Dx()/2
-		y := ScreenHeight/2 - bounds.Dy()/2
-		text.Draw(screen, g.message, g.fontFace, x, y, color.RGBA{255, 0, 0, 255})
-		g.messageTimer--
+	if g.config.Mode == ModeVersus {
+		round := g.loc.T("hud.round", g.round, g.config.roundCount())
+		drawAnchoredText(screen, round, g.fonts.Normal, AnchorTop, 0, 24, g.theme.Text)
+	} else {
+		drawAnchoredText(screen, g.loc.T("hud.score", g.score), g.fonts.Normal, AnchorTopLeft, 24, 24, g.theme.Text)
+		drawAnchoredText(screen, g.loc.T("hud.best", g.highScore), g.fonts.Normal, AnchorTopRight, 24, 24, g.theme.Text)
+		if g.ghost != nil {
+			g.drawGhostDelta(screen)
+		}
 	}
-}
 
-func (g *Game) drawGameOver(screen *ebiten.Image) {
-	lines := []string{"🌟 FIM DE JOGO 🌟", fmt.Sprintf("Pontos finais: %d", g.score), fmt.Sprintf("Melhor pontuação: %d", g.highScore), "Pressione R para tentar novamente"}
-	y := ScreenHeight / 2
-	for i, line := range lines {
-		bounds := text.BoundString(g.fontFace, line)
-		x := ScreenWidth/2 - bounds.Dx()/2
-		text.Draw(screen, line, g.fontFace, x, y+i*32, color.RGBA{255, 69, 0, 255})
-	}
-}
+	g.drawPlayerPanels(screen)
 
-func (g *Game) drawPaused(screen *ebiten.Image) {
-	text.Draw(screen, "PAUSADO - Pressione P para continuar", g.fontFace, ScreenWidth/2-150, ScreenHeight/2, TextColor)
-}
+	// Draw message if any
+	if g.messageTimer > 0 {
+		drawAnchoredText(screen, g.message, g.fonts.Normal, AnchorCenter, 0, 0, g.theme.Message)
+	}
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	g.scale = math.Min(float64(outsideWidth)/ScreenWidth, float64(outsideHeight)/ScreenHeight)
-	return outsideWidth, outsideHeight
+	g.camera.DrawFlash(screen)
 }
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+// newTestGame returns a game in the playing state with no asteroids and
+// a silent audio backend that records what was played.
+func newTestGame() (*Game, *NullAudio) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		panic(err)
+	}
+	g := NewGame(assets)
+	null := &NullAudio{}
+	g.mixer.SetBackend(null)
+	g.startRun()
+	g.asteroids = g.asteroids[:0]
+	return g, null
+}
+
+func TestEndRunKeepsHighScore(t *testing.T) {
+	g, _ := newTestGame()
+	g.score = 500
+	g.endRun()
+	g.Reset()
+	g.score = 200
+	g.endRun()
+	if g.highScore != 500 {
+		t.Errorf("highScore = %d; esperado 500", g.highScore)
+	}
+	if _, ok := g.scenes.Top().(*GameOverScene); !ok {
+		t.Errorf("cena = %T; esperado *GameOverScene", g.scenes.Top())
+	}
+}
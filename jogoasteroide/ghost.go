@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// GhostVersion is the ghost file format written by this build.
+const GhostVersion = 1
+
+// GhostAlpha is the opacity of the ghost ship.
+const GhostAlpha = 0x60
+
+// Ghosts are the player's best solo classic runs, one per difficulty,
+// kept to race against on the same seed.
+type Ghosts struct {
+	Version int                  `json:"version"`
+	Runs    map[string]RunResult `json:"runs"` // by difficulty name
+}
+
+// LoadGhosts reads the ghosts from path. Before the first finished run
+// there is no file and no ghosts; a file from a newer game is refused
+// rather than overwritten.
+func LoadGhosts(path string) (Ghosts, error) {
+	none := Ghosts{Version: GhostVersion, Runs: map[string]RunResult{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return none, nil
+	}
+	if err != nil {
+		return none, err
+	}
+	var gh Ghosts
+	if err := json.Unmarshal(data, &gh); err != nil {
+		return none, fmt.Errorf("%s: %v", path, err)
+	}
+	if gh.Version > GhostVersion {
+		return none, fmt.Errorf("%s: ghost version %d is newer than this game (%d)", path, gh.Version, GhostVersion)
+	}
+	if gh.Runs == nil {
+		gh.Runs = map[string]RunResult{}
+	}
+	gh.Version = GhostVersion
+	return gh, nil
+}
+
+// SaveGhosts writes gh to path, unindented as the replays make it long.
+func SaveGhosts(path string, gh Ghosts) error {
+	data, err := json.Marshal(gh)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// ghostable reports whether runs with cfg can leave, or race, a ghost.
+func ghostable(cfg RunConfig) bool {
+	return cfg.Mode == ModeClassic && cfg.playerCount() == 1
+}
+
+// recordGhost keeps the finished run as the ghost of its difficulty if it
+// beats the one there.
+func (g *Game) recordGhost() {
+	if !ghostable(g.config) {
+		return
+	}
+	key := g.config.Difficulty.String()
+	if best, ok := g.ghosts.Runs[key]; ok && best.Score >= g.score {
+		return
+	}
+	g.ghosts.Runs[key] = RunResult{Version: ResultVersion, Name: g.playerName(), Score: g.score, Replay: g.replay()}
+	if g.ghostsPath == "" {
+		return
+	}
+	if err := SaveGhosts(g.ghostsPath, g.ghosts); err != nil {
+		log.Printf("saving ghosts: %v", err)
+	}
+}
+
+// bestRun returns the ghost for the chosen difficulty, if there is one.
+func (g *Game) bestRun() (RunResult, bool) {
+	r, ok := g.ghosts.Runs[g.settings.Difficulty.normalize().String()]
+	return r, ok && r.Replay.Version == ReplayVersion && ghostable(r.Replay.Config)
+}
+
+// raceGhost starts a run on the seed of the best one at the chosen
+// difficulty, with its ghost. It reports false if there is none.
+func (g *Game) raceGhost() bool {
+	r, ok := g.bestRun()
+	if ok {
+		g.beginRun(r.Replay.Config)
+	}
+	return ok
+}
+
+// Ghost replays a best run alongside the one being played, step for step.
+type Ghost struct {
+	game   *Game
+	inputs []Input
+	Name   string
+}
+
+// NewGhost returns a ghost of r at its first step.
+func NewGhost(assets *AssetStore, r RunResult) *Ghost {
+	gh := &Ghost{game: newSimGame(assets), inputs: r.Replay.Inputs, Name: r.Name}
+	gh.game.ResetRun(r.Replay.Config)
+	return gh
+}
+
+// attachGhost gives the current run the ghost of the best run, if it
+// is played on that run's seed.
+func (g *Game) attachGhost() {
+	g.ghost = nil
+	if r, ok := g.ghosts.Runs[g.config.Difficulty.String()]; ok && r.Replay.Version == ReplayVersion && r.Replay.Config == g.config {
+		g.ghost = NewGhost(g.assets, r)
+		g.ghost.Sync(g.tick)
+	}
+}
+
+// Sync plays the ghost on (or back) to step tick. It stops where its run
+// ended.
+func (gh *Ghost) Sync(tick int) {
+	if gh.game.tick > tick {
+		gh.game.ResetRun(gh.game.config)
+	}
+	for gh.game.tick < tick && !gh.Done() {
+		gh.game.updatePlaying(gh.inputs[gh.game.tick])
+	}
+}
+
+// Done reports whether the ghost's run is over.
+func (gh *Ghost) Done() bool {
+	return gh.game.tick >= len(gh.inputs) || !gh.game.running
+}
+
+// Score is the ghost's score at the step it has reached.
+func (gh *Ghost) Score() int {
+	return gh.game.score
+}
+
+// Draw draws the ghost ship, see-through and without its flame or shield,
+// until its run ends.
+func (gh *Ghost) Draw(world *ebiten.Image, tint color.Color) {
+	if gh.Done() {
+		return
+	}
+	p := gh.game.players[0]
+	p.isAccelerating, p.shield = false, 0
+	r, g, b, _ := tint.RGBA()
+	p.Draw(world, color.NRGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), GhostAlpha})
+}
+
+// drawGhostDelta shows under the score how far ahead of the ghost, or
+// behind it, the run is at this point.
+func (g *Game) drawGhostDelta(screen *ebiten.Image) {
+	d := g.score - g.ghost.Score()
+	text, clr := g.loc.T("hud.ghost_even"), g.theme.Text
+	switch {
+	case d > 0:
+		text, clr = g.loc.T("hud.ghost_ahead", d), g.theme.HealthFill
+	case d < 0:
+		text, clr = g.loc.T("hud.ghost_behind", -d), g.theme.Message
+	}
+	drawAnchoredText(screen, text, g.fonts.Small, AnchorTopLeft, 24, 64, clr)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestGhostRace(t *testing.T) {
+	g, _ := newTestGame()
+	g.ghostsPath = filepath.Join(t.TempDir(), "ghosts.json")
+	g.beginRun(g.classicConfig(5))
+	cfg := g.config
+	if g.ghost != nil {
+		t.Fatal("fantasma numa partida sem melhor corrida")
+	}
+	// The best run: the bot plays 20 seconds.
+	b := NewBot(BotHard, 0, 1)
+	pos, scores := []Vector{g.players[0].position}, []int{0}
+	for g.running && !g.dying && g.tick < 20*TicksPerSecond {
+		g.updatePlaying(b.Input(g))
+		pos, scores = append(pos, g.players[0].position), append(scores, g.score)
+	}
+	if g.score == 0 {
+		t.Fatal("o robô não marcou pontos")
+	}
+	g.endRun()
+	best := g.score
+
+	if !g.raceGhost() || g.ghost == nil || g.config != cfg {
+		t.Fatalf("corrida: fantasma %v, config %+v; esperado a semente da melhor", g.ghost != nil, g.config)
+	}
+	// Sit still: the ghost flies the best run and pulls ahead.
+	for range 300 {
+		g.updatePlaying()
+		g.ghost.Sync(g.tick)
+		if got := g.ghost.game.players[0].position; got != pos[g.tick] {
+			t.Fatalf("passo %d: fantasma em %v; esperado %v", g.tick, got, pos[g.tick])
+		}
+		if got := g.ghost.Score(); got != scores[g.tick] {
+			t.Fatalf("passo %d: fantasma com %d pontos; esperado %d", g.tick, got, scores[g.tick])
+		}
+	}
+	g.ghost.Sync(10)
+	if got := g.ghost.game.players[0].position; got != pos[10] {
+		t.Errorf("de volta ao passo 10: fantasma em %v; esperado %v", got, pos[10])
+	}
+	g.ghost.Sync(len(pos) + 100)
+	if !g.ghost.Done() || g.ghost.Score() != best {
+		t.Errorf("fim do fantasma: acabou %v com %d pontos; esperado %d", g.ghost.Done(), g.ghost.Score(), best)
+	}
+
+	// A worse run keeps the ghost, and a retry races it again.
+	g.score = 10
+	g.endRun()
+	g.restart()
+	if g.ghost == nil || g.config != cfg {
+		t.Error("tentar de novo não correu contra o fantasma")
+	}
+	saved, err := LoadGhosts(g.ghostsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := saved.Runs["normal"]
+	if r.Score != best || r.Replay.Config != cfg || !slices.Equal(r.Replay.Inputs, g.ghost.inputs) {
+		t.Errorf("fantasma salvo com %d pontos e %d passos; esperado a melhor corrida, %d pontos", r.Score, len(r.Replay.Inputs), best)
+	}
+}
+
+func TestGhostOnlySolo(t *testing.T) {
+	g, _ := newTestGame()
+	g.settings.Coop.Players = 2
+	g.beginRun(g.classicConfig(5))
+	g.score = 1000
+	g.endRun()
+	if len(g.ghosts.Runs) != 0 {
+		t.Error("uma partida em dupla virou fantasma")
+	}
+	if g.raceGhost() {
+		t.Error("corrida sem fantasma")
+	}
+}
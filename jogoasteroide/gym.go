@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"slices"
+)
+
+const (
+	// GymRasterWidth and GymRasterHeight are the default size of a raster
+	// observation, the screen shrunk 20 times.
+	GymRasterWidth  = ScreenWidth / 20
+	GymRasterHeight = ScreenHeight / 20
+	// gymActions masks the actions an agent can take; pausing is not one.
+	gymActions = 1<<ActionPause - 1
+)
+
+// Raster observation channels, one byte per cell each.
+var gymChannels = []string{"ship", "asteroid", "bullet", "power_up"}
+
+// GymOptions set up an episode.
+type GymOptions struct {
+	Seed       uint64 `json:"seed"`
+	Difficulty string `json:"difficulty,omitempty"` // easy, normal (the default) or hard
+	Players    int    `json:"players,omitempty"`    // ships, each taking an action a step
+	// Observation is "entities", a list of everything on screen (the
+	// default), or "raster", a downsampled picture of it.
+	Observation  string `json:"observation,omitempty"`
+	RasterWidth  int    `json:"raster_width,omitempty"`
+	RasterHeight int    `json:"raster_height,omitempty"`
+	// FrameSkip repeats each action this many simulation steps, 1 if unset.
+	FrameSkip int `json:"frame_skip,omitempty"`
+	// MaxSteps cuts an episode off after this many simulation steps, 0 for
+	// no limit.
+	MaxSteps int `json:"max_steps,omitempty"`
+}
+
+// GymObservation is what an agent sees after a reset or step: Entities or
+// Raster, whichever the episode asked for.
+type GymObservation struct {
+	Entities *GymEntities `json:"entities,omitempty"`
+	Raster   *GymRaster   `json:"raster,omitempty"`
+}
+
+// GymEntities lists everything on screen, in pixels and pixels a step.
+type GymEntities struct {
+	Ships     []GymShip     `json:"ships"`
+	Asteroids []GymAsteroid `json:"asteroids"`
+	Bullets   []GymBullet   `json:"bullets"`
+	PowerUps  []GymPowerUp  `json:"power_ups"`
+}
+
+type GymShip struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	VX        float64 `json:"vx"`
+	VY        float64 `json:"vy"`
+	Angle     float64 `json:"angle"` // radians clockwise from up
+	Health    int     `json:"health"`
+	Shield    int     `json:"shield"` // steps of each power-up left
+	RapidFire int     `json:"rapid_fire"`
+	MultiShot int     `json:"multi_shot"`
+	Cooldown  int     `json:"cooldown"` // steps until it can fire
+	Out       bool    `json:"out"`
+}
+
+type GymAsteroid struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	VX   float64 `json:"vx"`
+	VY   float64 `json:"vy"`
+	Size float64 `json:"size"`
+}
+
+type GymBullet struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	VX    float64 `json:"vx"`
+	VY    float64 `json:"vy"`
+	Owner int     `json:"owner"`
+}
+
+type GymPowerUp struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Type string  `json:"type"`
+}
+
+// GymRaster is the screen shrunk to Width by Height cells, each holding
+// one byte per channel: how much of the cell that kind of thing covers,
+// from 0 to 255. Data is row by row, channels interleaved.
+type GymRaster struct {
+	Width    int      `json:"width"`
+	Height   int      `json:"height"`
+	Channels []string `json:"channels"`
+	Data     []byte   `json:"data"`
+}
+
+// GymInfo is extra detail about the episode, not meant as a reward.
+type GymInfo struct {
+	Score     int   `json:"score"`
+	Tick      int   `json:"tick"`
+	Health    []int `json:"health"`
+	Truncated bool  `json:"truncated,omitempty"` // cut off at MaxSteps rather than over
+}
+
+// GymEnv is the game as a reinforcement learning environment: a headless
+// run advanced by an agent's actions, rewarded with the points scored.
+type GymEnv struct {
+	assets *AssetStore
+	game   *Game
+	opts   GymOptions
+	done   bool
+}
+
+// NewGymEnv returns an environment simulated with assets. Reset it before
+// the first step.
+func NewGymEnv(assets *AssetStore) *GymEnv {
+	return &GymEnv{assets: assets}
+}
+
+// Reset starts an episode and returns the first observation.
+func (e *GymEnv) Reset(opts GymOptions) (GymObservation, GymInfo, error) {
+	d := DifficultyNormal
+	if opts.Difficulty != "" {
+		i := slices.Index(difficultyNames[:], opts.Difficulty)
+		if i < 0 {
+			return GymObservation{}, GymInfo{}, fmt.Errorf("unknown difficulty %q", opts.Difficulty)
+		}
+		d = Difficulty(i)
+	}
+	switch opts.Observation {
+	case "":
+		opts.Observation = "entities"
+	case "entities", "raster":
+	default:
+		return GymObservation{}, GymInfo{}, fmt.Errorf("unknown observation %q", opts.Observation)
+	}
+	if opts.RasterWidth <= 0 || opts.RasterHeight <= 0 {
+		opts.RasterWidth, opts.RasterHeight = GymRasterWidth, GymRasterHeight
+	}
+	if opts.RasterWidth > ScreenWidth || opts.RasterHeight > ScreenHeight {
+		return GymObservation{}, GymInfo{}, fmt.Errorf("a raster is at most %dx%d", ScreenWidth, ScreenHeight)
+	}
+	opts.FrameSkip = max(1, opts.FrameSkip)
+	e.opts = opts
+	e.done = false
+	e.game = newSimGame(e.assets)
+	e.game.ResetRun(RunConfig{Mode: ModeClassic, Seed: opts.Seed, Difficulty: d, Players: opts.Players})
+	return e.observe(), e.info(false), nil
+}
+
+// Step plays actions, one input per ship, for the episode's frame skip
+// and returns what the agent sees, the points scored meanwhile and
+// whether the episode is over.
+func (e *GymEnv) Step(actions []Input) (obs GymObservation, reward float64, done bool, info GymInfo, err error) {
+	if e.game == nil || e.done {
+		return obs, 0, true, info, errors.New("reset the environment first")
+	}
+	g := e.game
+	if len(actions) != len(g.players) {
+		return obs, 0, false, info, fmt.Errorf("%d actions for %d ships", len(actions), len(g.players))
+	}
+	before := g.score
+	truncated := false
+	for range e.opts.FrameSkip {
+		g.updatePlaying(actions...)
+		// The headless camera never ends the slow-motion death, so a
+		// dying run is as good as over.
+		if e.done = !g.running || g.dying; e.done {
+			break
+		}
+		if e.opts.MaxSteps > 0 && g.tick >= e.opts.MaxSteps {
+			e.done, truncated = true, true
+			break
+		}
+	}
+	return e.observe(), float64(g.score - before), e.done, e.info(truncated), nil
+}
+
+func (e *GymEnv) info(truncated bool) GymInfo {
+	g := e.game
+	info := GymInfo{Score: g.score, Tick: g.tick, Truncated: truncated}
+	for i := range g.players {
+		info.Health = append(info.Health, *g.health(&g.players[i]))
+	}
+	return info
+}
+
+func (e *GymEnv) observe() GymObservation {
+	if e.opts.Observation == "raster" {
+		return GymObservation{Raster: e.raster()}
+	}
+	g := e.game
+	ents := &GymEntities{
+		Ships:     []GymShip{},
+		Asteroids: []GymAsteroid{},
+		Bullets:   []GymBullet{},
+		PowerUps:  []GymPowerUp{},
+	}
+	for i := range g.players {
+		p := &g.players[i]
+		ents.Ships = append(ents.Ships, GymShip{
+			X: p.position.X, Y: p.position.Y, VX: p.velocity.X, VY: p.velocity.Y,
+			Angle: math.Mod(math.Mod(p.angle, 2*math.Pi)+2*math.Pi, 2*math.Pi), Health: *g.health(p),
+			Shield: p.shield, RapidFire: p.rapidFire, MultiShot: p.multiShot, Cooldown: p.fireCooldown, Out: p.out,
+		})
+	}
+	for _, a := range g.asteroids {
+		ents.Asteroids = append(ents.Asteroids, GymAsteroid{a.position.X, a.position.Y, a.velocity.X, a.velocity.Y, a.size})
+	}
+	for _, b := range g.bullets {
+		ents.Bullets = append(ents.Bullets, GymBullet{b.position.X, b.position.Y, b.velocity.X, b.velocity.Y, b.owner})
+	}
+	for _, pw := range g.powerUps {
+		ents.PowerUps = append(ents.PowerUps, GymPowerUp{pw.position.X, pw.position.Y, pw.powerType.String()})
+	}
+	return GymObservation{Entities: ents}
+}
+
+// raster draws circles for everything into the downsampled channels,
+// sampling each cell at its centre and corners.
+func (e *GymEnv) raster() *GymRaster {
+	g := e.game
+	w, h := e.opts.RasterWidth, e.opts.RasterHeight
+	r := &GymRaster{Width: w, Height: h, Channels: gymChannels, Data: make([]byte, w*h*len(gymChannels))}
+	cw, ch := float64(ScreenWidth)/float64(w), float64(ScreenHeight)/float64(h)
+	samples := [...]Vector{{0.5, 0.5}, {0.1, 0.1}, {0.9, 0.1}, {0.1, 0.9}, {0.9, 0.9}}
+	circle := func(channel int, c Vector, radius float64) {
+		x0, x1 := int(max(0, (c.X-radius)/cw)), int(min(float64(w-1), (c.X+radius)/cw))
+		y0, y1 := int(max(0, (c.Y-radius)/ch)), int(min(float64(h-1), (c.Y+radius)/ch))
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				hits := 0
+				for _, s := range samples {
+					dx, dy := (float64(x)+s.X)*cw-c.X, (float64(y)+s.Y)*ch-c.Y
+					if dx*dx+dy*dy <= radius*radius {
+						hits++
+					}
+				}
+				// Sub-cell things still show, at the least.
+				if hits == 0 && x == int(c.X/cw) && y == int(c.Y/ch) {
+					hits = 1
+				}
+				cell := &r.Data[(y*w+x)*len(gymChannels)+channel]
+				*cell = max(*cell, byte(hits*255/len(samples)))
+			}
+		}
+	}
+	for i := range g.players {
+		if p := &g.players[i]; !p.out {
+			circle(0, p.position, p.width/2)
+		}
+	}
+	for _, a := range g.asteroids {
+		circle(1, a.position, a.size/2)
+	}
+	for _, b := range g.bullets {
+		circle(2, b.position, 5)
+	}
+	for _, pw := range g.powerUps {
+		circle(3, pw.position, pw.size/2)
+	}
+	return r
+}
+
+// gymRequest is a line an agent sends: a reset, with its options, a step
+// with its action, or close.
+type gymRequest struct {
+	Cmd string `json:"cmd"`
+	GymOptions
+	// Action is an input bit set (1 rotate left, 2 rotate right, 4 thrust,
+	// 8 fire), or a list of them, one per ship.
+	Action json.RawMessage `json:"action,omitempty"`
+}
+
+// gymResponse is the line answering a request.
+type gymResponse struct {
+	Observation *GymObservation `json:"observation,omitempty"`
+	Reward      *float64        `json:"reward,omitempty"`
+	Done        *bool           `json:"done,omitempty"`
+	Info        *GymInfo        `json:"info,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// ServeGym runs the environment for an agent talking line-delimited JSON
+// on r and w, one response line per request line, until r ends or the
+// agent closes it.
+func ServeGym(assets *AssetStore, r io.Reader, w io.Writer) error {
+	env := NewGymEnv(assets)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, MaxSubmissionBytes)
+	enc := json.NewEncoder(w)
+	for sc.Scan() {
+		var req gymRequest
+		var resp gymResponse
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			resp.Error = err.Error()
+		} else {
+			switch req.Cmd {
+			case "reset":
+				obs, info, err := env.Reset(req.GymOptions)
+				if err != nil {
+					resp.Error = err.Error()
+					break
+				}
+				resp.Observation, resp.Info = &obs, &info
+			case "step":
+				actions, err := parseGymAction(req.Action)
+				if err != nil {
+					resp.Error = err.Error()
+					break
+				}
+				obs, reward, done, info, err := env.Step(actions)
+				if err != nil {
+					resp.Error = err.Error()
+					break
+				}
+				resp.Observation, resp.Reward, resp.Done, resp.Info = &obs, &reward, &done, &info
+			case "close":
+				return nil
+			default:
+				resp.Error = fmt.Sprintf("unknown command %q", req.Cmd)
+			}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// parseGymAction reads an action: one input, or a list of them. It reads
+// numbers rather than an []Input, which JSON would take as base64.
+func parseGymAction(raw json.RawMessage) ([]Input, error) {
+	var list []int
+	if err := json.Unmarshal(raw, &list); err != nil {
+		var one int
+		if json.Unmarshal(raw, &one) != nil {
+			return nil, errors.New("action: want an input or a list of them")
+		}
+		list = []int{one}
+	}
+	actions := make([]Input, len(list))
+	for i, v := range list {
+		if v < 0 || v > gymActions {
+			return nil, fmt.Errorf("action: %d is not an input", v)
+		}
+		actions[i] = Input(v)
+	}
+	return actions, nil
+}
+
+// runGym serves the environment on stdin and stdout with the built-in
+// assets and returns the exit status. Logs go to stderr, out of the way.
+func runGym() int {
+	assets, err := NewAssetStore()
+	if err == nil {
+		err = ServeGym(assets, os.Stdin, os.Stdout)
+	}
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	return 0
+}
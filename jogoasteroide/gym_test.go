@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gymSession sends the request lines to a gym and returns its responses.
+func gymSession(t *testing.T, requests ...string) []gymResponse {
+	t.Helper()
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	if err := ServeGym(assets, strings.NewReader(strings.Join(requests, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resps []gymResponse
+	sc := bufio.NewScanner(strings.NewReader(out.String()))
+	sc.Buffer(nil, MaxSubmissionBytes)
+	for sc.Scan() {
+		var r gymResponse
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("resposta %q: %v", sc.Text(), err)
+		}
+		resps = append(resps, r)
+	}
+	return resps
+}
+
+func TestGymProtocol(t *testing.T) {
+	resps := gymSession(t,
+		`{"cmd":"reset","seed":7,"frame_skip":4}`,
+		`{"cmd":"step","action":12}`,
+		`{"cmd":"step","action":[5]}`,
+		`{"cmd":"step","action":[1,2]}`,
+		`{"cmd":"step","action":16}`,
+		`{"cmd":"jump"}`,
+		`{"cmd":"reset","observation":"pixels"}`,
+		`not json`,
+		`{"cmd":"close"}`,
+		`{"cmd":"step","action":0}`,
+	)
+	if len(resps) != 8 {
+		t.Fatalf("%d respostas; esperado 8, nenhuma de close em diante", len(resps))
+	}
+	reset := resps[0]
+	if reset.Error != "" || reset.Observation == nil || reset.Observation.Entities == nil {
+		t.Fatalf("reset: %+v", reset)
+	}
+	if n := len(reset.Observation.Entities.Ships); n != 1 {
+		t.Errorf("reset: %d naves, esperado 1", n)
+	}
+	if len(reset.Observation.Entities.Asteroids) == 0 {
+		t.Error("reset: nenhum asteroide")
+	}
+	for i, want := range []int{4, 8} {
+		r := resps[1+i]
+		if r.Error != "" || r.Reward == nil || r.Done == nil || r.Info == nil {
+			t.Fatalf("passo %d: %+v", i+1, r)
+		}
+		if r.Info.Tick != want {
+			t.Errorf("passo %d: tick %d, esperado %d com frame_skip 4", i+1, r.Info.Tick, want)
+		}
+	}
+	for i, r := range resps[3:8] {
+		if r.Error == "" {
+			t.Errorf("pedido %d aceito; esperado erro", 4+i)
+		}
+	}
+}
+
+func TestGymDeterministic(t *testing.T) {
+	script := []string{`{"cmd":"reset","seed":3,"difficulty":"hard","frame_skip":2}`}
+	for i := range 300 {
+		script = append(script, `{"cmd":"step","action":`+string(rune('0'+i%10))+`}`)
+	}
+	a, b := gymSession(t, script...), gymSession(t, script...)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("a mesma semente e as mesmas ações deram episódios diferentes")
+	}
+	total := 0.0
+	for _, r := range a[1:] {
+		if r.Error != "" {
+			break
+		}
+		total += *r.Reward
+	}
+	last := a[len(a)-1]
+	if last.Info != nil && total != float64(last.Info.Score) {
+		t.Errorf("recompensas somam %v; esperado a pontuação %d", total, last.Info.Score)
+	}
+}
+
+func TestGymEpisodeEnds(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := NewGymEnv(assets)
+	if _, _, err := env.Reset(GymOptions{Seed: 1, MaxSteps: 50, FrameSkip: 8}); err != nil {
+		t.Fatal(err)
+	}
+	steps := 0
+	for {
+		_, _, done, info, err := env.Step([]Input{0})
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps++
+		if done {
+			if !info.Truncated || info.Tick != 50 {
+				t.Errorf("fim %+v; esperado cortado no passo 50", info)
+			}
+			break
+		}
+	}
+	if steps != 7 {
+		t.Errorf("%d passos; esperado 7 de 8 quadros até 50", steps)
+	}
+	if _, _, _, _, err := env.Step([]Input{0}); err == nil {
+		t.Error("passo depois do fim aceito")
+	}
+}
+
+func TestGymRaster(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := NewGymEnv(assets)
+	obs, _, err := env.Reset(GymOptions{Seed: 1, Observation: "raster", RasterWidth: 32, RasterHeight: 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := obs.Raster
+	if r == nil || r.Width != 32 || r.Height != 18 || len(r.Data) != 32*18*len(gymChannels) {
+		t.Fatalf("raster %+v", r)
+	}
+	// The ship starts in the middle of the screen.
+	if cell := r.Data[(9*32+16)*len(gymChannels)]; cell == 0 {
+		t.Error("a nave não aparece no centro")
+	}
+	filled := 0
+	for i := 1; i < len(r.Data); i += len(gymChannels) {
+		if r.Data[i] > 0 {
+			filled++
+		}
+	}
+	if filled == 0 {
+		t.Error("nenhum asteroide no raster")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// DefaultLanguage is used when no language is chosen, and as the fallback
+// for messages missing from another catalog.
+const DefaultLanguage = "pt-BR"
+
+// Languages lists the catalogs shipped with the game, in menu order.
+var Languages = []string{"pt-BR", "en-US"}
+
+// pluralRules picks the plural form for a count, per language.
+var pluralRules = map[string]func(n int) string{
+	// Portuguese treats 0 and 1 as singular ("0 ponto", "1 ponto").
+	"pt-BR": func(n int) string {
+		if n == 0 || n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+	"en-US": func(n int) string {
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	},
+}
+
+// Message is a catalog entry: a plain string, or one string per plural
+// form ("one", "other"). Strings are fmt formats.
+type Message struct {
+	Forms map[string]string
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		m.Forms = map[string]string{"other": s}
+		return nil
+	}
+	return json.Unmarshal(data, &m.Forms)
+}
+
+// Catalog maps message IDs to messages for one language.
+type Catalog map[string]Message
+
+// Localizer looks up translated messages for the current language.
+type Localizer struct {
+	lang     string
+	catalogs map[string]Catalog
+}
+
+// NewLocalizer loads every catalog from the asset store. A catalog that
+// fails to load is logged and left out, so its messages fall back to the
+// default language.
+func NewLocalizer(assets *AssetStore, lang string) *Localizer {
+	l := &Localizer{catalogs: map[string]Catalog{}}
+	for _, code := range Languages {
+		c, err := loadCatalog(assets, code)
+		if err != nil {
+			log.Printf("locale %s: %v", code, err)
+			continue
+		}
+		l.catalogs[code] = c
+	}
+	l.SetLanguage(lang)
+	return l
+}
+
+func loadCatalog(assets *AssetStore, lang string) (Catalog, error) {
+	data, err := assets.Data("locale-" + lang)
+	if err != nil {
+		return nil, err
+	}
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Language returns the current language code.
+func (l *Localizer) Language() string {
+	return l.lang
+}
+
+// SetLanguage switches language; unknown codes select DefaultLanguage.
+func (l *Localizer) SetLanguage(lang string) {
+	if _, ok := pluralRules[lang]; !ok {
+		lang = DefaultLanguage
+	}
+	l.lang = lang
+}
+
+// lookup finds the message in the current catalog, then the default one,
+// and reports the language it came from.
+func (l *Localizer) lookup(id string) (Message, string, bool) {
+	if m, ok := l.catalogs[l.lang][id]; ok {
+		return m, l.lang, true
+	}
+	m, ok := l.catalogs[DefaultLanguage][id]
+	return m, DefaultLanguage, ok
+}
+
+// T returns the message id formatted with args. Unknown IDs come back
+// as the ID itself so they stand out on screen.
+func (l *Localizer) T(id string, args ...any) string {
+	m, _, ok := l.lookup(id)
+	if !ok {
+		return id
+	}
+	return fmt.Sprintf(m.Forms["other"], args...)
+}
+
+// N returns the plural form of message id that matches n, formatted with
+// n followed by args.
+func (l *Localizer) N(id string, n int, args ...any) string {
+	m, lang, ok := l.lookup(id)
+	if !ok {
+		return id
+	}
+	form, ok := m.Forms[pluralRules[lang](n)]
+	if !ok {
+		form = m.Forms["other"]
+	}
+	return fmt.Sprintf(form, append([]any{n}, args...)...)
+}
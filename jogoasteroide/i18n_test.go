@@ -0,0 +1,100 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+var formatVerb = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z]`)
+
+func verbs(s string) []string {
+	v := formatVerb.FindAllString(s, -1)
+	sort.Strings(v)
+	return v
+}
+
+// TestCatalogsComplete fails when any catalog is missing a key that
+// another catalog has, lacks a plural form, or formats different
+// arguments than the default catalog.
+func TestCatalogsComplete(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	catalogs := map[string]Catalog{}
+	keys := map[string]bool{}
+	for _, lang := range Languages {
+		c, err := loadCatalog(assets, lang)
+		if err != nil {
+			t.Fatalf("catálogo %s: %v", lang, err)
+		}
+		if _, ok := pluralRules[lang]; !ok {
+			t.Errorf("catálogo %s sem regra de plural", lang)
+		}
+		catalogs[lang] = c
+		for id := range c {
+			keys[id] = true
+		}
+	}
+
+	for lang, c := range catalogs {
+		for id := range keys {
+			m, ok := c[id]
+			if !ok {
+				t.Errorf("catálogo %s: falta a chave %q", lang, id)
+				continue
+			}
+			if _, ok := m.Forms["other"]; !ok {
+				t.Errorf("catálogo %s: %q sem a forma \"other\"", lang, id)
+			}
+			def := catalogs[DefaultLanguage][id]
+			if len(def.Forms) > 1 && len(m.Forms) < 2 {
+				t.Errorf("catálogo %s: %q deveria ter formas de plural", lang, id)
+			}
+			for form, s := range m.Forms {
+				want := verbs(def.Forms["other"])
+				if got := verbs(s); len(got) != len(want) {
+					t.Errorf("catálogo %s: %q (%s) usa argumentos %v; esperado %v", lang, id, form, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestLocalizerPlural(t *testing.T) {
+	assets, _ := NewAssetStore()
+	l := NewLocalizer(assets, "pt-BR")
+	tests := []struct {
+		lang string
+		n    int
+		want string
+	}{
+		{"pt-BR", 0, "Pontuação final: 0 ponto"},
+		{"pt-BR", 1, "Pontuação final: 1 ponto"},
+		{"pt-BR", 2, "Pontuação final: 2 pontos"},
+		{"en-US", 0, "Final score: 0 points"},
+		{"en-US", 1, "Final score: 1 point"},
+	}
+
+	for _, tt := range tests {
+		l.SetLanguage(tt.lang)
+		if got := l.N("gameover.score", tt.n); got != tt.want {
+			t.Errorf("%s N(%d) = %q; esperado %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizerFallback(t *testing.T) {
+	assets, _ := NewAssetStore()
+	l := NewLocalizer(assets, "xx-XX")
+	if l.Language() != DefaultLanguage {
+		t.Errorf("idioma desconhecido = %q; esperado %q", l.Language(), DefaultLanguage)
+	}
+	if got := l.T("hud.score", 42); got != "Pontos: 42" {
+		t.Errorf("T = %q; esperado %q", got, "Pontos: 42")
+	}
+	if got := l.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("chave inexistente = %q; esperado o próprio ID", got)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Action is something the player can do with a bound key.
+type Action int
+
+const (
+	ActionRotateLeft Action = iota
+	ActionRotateRight
+	ActionThrust
+	ActionFire
+	ActionPause
+	actionCount
+)
+
+var actionNames = [actionCount]string{"rotate_left", "rotate_right", "thrust", "fire", "pause"}
+
+func (a Action) String() string {
+	return actionNames[a]
+}
+
+// Input is a snapshot of the actions held during one frame. It is a bit
+// set so frames stay small when stored or compared.
+type Input uint8
+
+// Has reports whether the action is held.
+func (in Input) Has(a Action) bool {
+	return in&(1<<a) != 0
+}
+
+// With returns the snapshot with the action held.
+func (in Input) With(a Action) Input {
+	return in | 1<<a
+}
+
+// Bindings maps each action to the keys that trigger it.
+type Bindings map[Action][]ebiten.Key
+
+// DefaultBindings returns the original arrow keys and space bar layout.
+func DefaultBindings() Bindings {
+	return Bindings{
+		ActionRotateLeft:  {ebiten.KeyLeft},
+		ActionRotateRight: {ebiten.KeyRight},
+		ActionThrust:      {ebiten.KeyUp},
+		ActionFire:        {ebiten.KeySpace},
+		ActionPause:       {ebiten.KeyP},
+	}
+}
+
+// Read samples the keyboard into an input snapshot.
+func (b Bindings) Read() Input {
+	var in Input
+	for a, keys := range b {
+		for _, k := range keys {
+			if ebiten.IsKeyPressed(k) {
+				in = in.With(a)
+			}
+		}
+	}
+	return in
+}
+
+// JustPressed reports whether a key bound to a went down this frame.
+func (b Bindings) JustPressed(a Action) bool {
+	for _, k := range b[a] {
+		if inpututil.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalJSON writes the bindings keyed by action name, with key names
+// as values, so the settings file stays readable.
+func (b Bindings) MarshalJSON() ([]byte, error) {
+	m := map[string][]ebiten.Key{}
+	for a, keys := range b {
+		m[a.String()] = keys
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON reads bindings written by MarshalJSON. Actions missing
+// from the data keep the bindings already in b.
+func (b *Bindings) UnmarshalJSON(data []byte) error {
+	var m map[string][]ebiten.Key
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if *b == nil {
+		*b = Bindings{}
+	}
+	for name, keys := range m {
+		a, ok := actionByName(name)
+		if !ok {
+			return fmt.Errorf("unknown action %q", name)
+		}
+		(*b)[a] = keys
+	}
+	return nil
+}
+
+func actionByName(name string) (Action, bool) {
+	for a, n := range actionNames {
+		if n == name {
+			return Action(a), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,401 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LeaderboardVersion is the server's board file format. Version 1 kept
+// one board per mode; version 2 has one per mode and difficulty.
+const LeaderboardVersion = 2
+
+const (
+	// LeaderboardKeep is how many runs each board keeps.
+	LeaderboardKeep = 1000
+	// LeaderboardMaxTop is the most runs a single GET returns.
+	LeaderboardMaxTop = 100
+	// MaxSubmissionBytes bounds a POSTed run, about three hours of play.
+	MaxSubmissionBytes = 1 << 20
+	// SubmitTimeout is how long the game waits for the server.
+	SubmitTimeout = 10 * time.Second
+)
+
+// BoardEntry is one accepted run on the leaderboard.
+type BoardEntry struct {
+	Name       string     `json:"name"`
+	Score      int        `json:"score"`
+	Mode       string     `json:"mode"`
+	Date       string     `json:"date,omitempty"`
+	Seed       uint64     `json:"seed"`
+	Difficulty Difficulty `json:"difficulty"`
+	Modifiers  Modifiers  `json:"modifiers"`
+	Ticks      int        `json:"ticks"`
+	Submitted  time.Time  `json:"submitted"`
+	// Run identifies the replay, so the same run cannot be ranked twice.
+	Run string `json:"run,omitempty"`
+}
+
+// runID is a digest of the replay's config and inputs.
+func runID(r Replay) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	enc.Encode(r.Config)
+	enc.Encode(r.Inputs)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// boardKey names the board of a mode and difficulty.
+func boardKey(mode string, d Difficulty) string {
+	return mode + "/" + d.String()
+}
+
+// Submitted is the server's answer to an accepted run.
+type Submitted struct {
+	Rank  int        `json:"rank,omitempty"` // 1 is the best score; 0 if the run did not make the board
+	Entry BoardEntry `json:"entry"`
+}
+
+type boardFile struct {
+	Version int                     `json:"version"`
+	Boards  map[string][]BoardEntry `json:"boards"` // by boardKey, best first
+}
+
+// split moves the entries of a version 1 file, one board per mode, onto
+// the board of their difficulty.
+func (f *boardFile) split() {
+	boards := map[string][]BoardEntry{}
+	for _, board := range f.Boards {
+		for _, e := range board {
+			key := boardKey(e.Mode, e.Difficulty)
+			boards[key] = append(boards[key], e)
+		}
+	}
+	f.Boards = boards
+}
+
+// LeaderboardServer accepts runs over HTTP after re-simulating their
+// replays, and serves the best ones by mode and difficulty:
+//
+//	POST /runs            a RunResult; 201 with Submitted, 422 if the
+//	                      replay does not back the score, 409 if the
+//	                      run is already on the board
+//	GET  /top/{mode}?n=10 the best runs of the mode, best first, on
+//	                      difficulty=normal unless another is given;
+//	                      daily boards take date=YYYY-MM-DD
+type LeaderboardServer struct {
+	assets *AssetStore
+	path   string // where the boards are saved; empty keeps them in memory
+	mux    *http.ServeMux
+
+	// mu serialises submissions, simulation included: the service is
+	// small, and the asset store is not safe for concurrent use.
+	mu     sync.Mutex
+	boards map[string][]BoardEntry
+}
+
+// NewLeaderboardServer returns a server that verifies runs with assets and
+// keeps its boards in path, loading any already there.
+func NewLeaderboardServer(assets *AssetStore, path string) (*LeaderboardServer, error) {
+	s := &LeaderboardServer{assets: assets, path: path, boards: map[string][]BoardEntry{}}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			var f boardFile
+			if err := json.Unmarshal(data, &f); err != nil {
+				return nil, fmt.Errorf("%s: %v", path, err)
+			}
+			if f.Version > LeaderboardVersion {
+				return nil, fmt.Errorf("%s: leaderboard version %d is newer than this server (%d)", path, f.Version, LeaderboardVersion)
+			}
+			if f.Version < 2 {
+				f.split()
+			}
+			if f.Boards != nil {
+				s.boards = f.Boards
+			}
+		}
+	}
+	s.mux = http.NewServeMux()
+	s.mux.HandleFunc("POST /runs", s.handleSubmit)
+	s.mux.HandleFunc("GET /top/{mode}", s.handleTop)
+	return s, nil
+}
+
+func (s *LeaderboardServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *LeaderboardServer) handleSubmit(w http.ResponseWriter, req *http.Request) {
+	var r RunResult
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, MaxSubmissionBytes)).Decode(&r); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, "run too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "bad run: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || len(r.Name) > 32 {
+		http.Error(w, "name must have 1 to 32 bytes", http.StatusBadRequest)
+		return
+	}
+
+	if r.Replay.Config.playerCount() > 1 {
+		http.Error(w, "co-op runs are not ranked", http.StatusBadRequest)
+		return
+	}
+
+	c := r.Replay.Config
+	e := BoardEntry{
+		Name:       r.Name,
+		Score:      r.Score,
+		Mode:       c.Mode,
+		Date:       c.Date,
+		Seed:       c.Seed,
+		Difficulty: c.Difficulty,
+		Modifiers:  c.Modifiers,
+		Ticks:      len(r.Replay.Inputs),
+		Run:        runID(r.Replay),
+	}
+	key := boardKey(e.Mode, e.Difficulty)
+	// A known run is turned away before paying for its verification,
+	// which runs without the lock so the board stays readable meanwhile.
+	s.mu.Lock()
+	known := s.onBoard(key, e.Run)
+	s.mu.Unlock()
+	if known {
+		http.Error(w, "run already on the board", http.StatusConflict)
+		return
+	}
+	if err := Verify(s.assets, r); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrResultMismatch) {
+			status = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.onBoard(key, e.Run) {
+		// Submitted again while it was being verified.
+		http.Error(w, "run already on the board", http.StatusConflict)
+		return
+	}
+	e.Submitted = time.Now().UTC()
+	board := append(s.boards[key], e)
+	sort.SliceStable(board, func(i, j int) bool { return board[i].Score > board[j].Score })
+	board = board[:min(len(board), LeaderboardKeep)]
+	s.boards[key] = board
+	rank := 1
+	for _, o := range board {
+		if o.Run == e.Run {
+			s.save()
+			writeJSON(w, http.StatusCreated, Submitted{Rank: rank, Entry: e})
+			return
+		}
+		if o.Date == e.Date {
+			rank++
+		}
+	}
+	// Verified, but too low to keep.
+	writeJSON(w, http.StatusCreated, Submitted{Entry: e})
+}
+
+// onBoard reports whether the run with id is on board key. s.mu must be
+// held.
+func (s *LeaderboardServer) onBoard(key, id string) bool {
+	return slices.ContainsFunc(s.boards[key], func(o BoardEntry) bool { return o.Run == id })
+}
+
+func (s *LeaderboardServer) handleTop(w http.ResponseWriter, req *http.Request) {
+	mode := req.PathValue("mode")
+	if mode != ModeClassic && mode != ModeDaily {
+		http.Error(w, "unknown mode", http.StatusNotFound)
+		return
+	}
+	n := 10
+	if q := req.URL.Query().Get("n"); q != "" {
+		v, err := strconv.Atoi(q)
+		if err != nil || v < 1 {
+			http.Error(w, "n must be a positive number", http.StatusBadRequest)
+			return
+		}
+		n = min(v, LeaderboardMaxTop)
+	}
+	d := DifficultyNormal
+	if q := req.URL.Query().Get("difficulty"); q != "" {
+		i := slices.Index(difficultyNames[:], q)
+		if i < 0 {
+			http.Error(w, "unknown difficulty", http.StatusBadRequest)
+			return
+		}
+		d = Difficulty(i)
+	}
+	date := req.URL.Query().Get("date")
+
+	s.mu.Lock()
+	top := []BoardEntry{}
+	for _, e := range s.boards[boardKey(mode, d)] {
+		if len(top) == n {
+			break
+		}
+		if date == "" || e.Date == date {
+			top = append(top, e)
+		}
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, top)
+}
+
+// save writes the boards, if there is somewhere to write them.
+func (s *LeaderboardServer) save() {
+	if s.path == "" {
+		return
+	}
+	data, err := json.Marshal(boardFile{Version: LeaderboardVersion, Boards: s.boards})
+	if err == nil {
+		err = writeFileAtomic(s.path, data)
+	}
+	if err != nil {
+		log.Printf("saving leaderboard: %v", err)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("leaderboard: %v", err)
+	}
+}
+
+// ErrRunRejected is returned when the server does not accept a run.
+var ErrRunRejected = errors.New("run rejected by the leaderboard")
+
+// LeaderboardClient talks to a LeaderboardServer.
+type LeaderboardClient struct {
+	URL  string // base URL, e.g. http://localhost:8080
+	HTTP *http.Client
+}
+
+// NewLeaderboardClient returns a client for the server at url.
+func NewLeaderboardClient(url string) *LeaderboardClient {
+	return &LeaderboardClient{URL: strings.TrimSuffix(url, "/"), HTTP: &http.Client{Timeout: SubmitTimeout}}
+}
+
+// Submit sends a finished run and returns its rank. A run the server
+// refuses gives an error wrapping ErrRunRejected; anything else means the
+// server could not be reached or misbehaved.
+func (c *LeaderboardClient) Submit(ctx context.Context, r RunResult) (Submitted, error) {
+	var out Submitted
+	body, err := json.Marshal(r)
+	if err != nil {
+		return out, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/runs", bytes.NewReader(body))
+	if err != nil {
+		return out, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	err = c.do(req, http.StatusCreated, &out)
+	return out, err
+}
+
+// Top returns the best n runs of mode on difficulty d, of one day if date
+// is set.
+func (c *LeaderboardClient) Top(ctx context.Context, mode string, d Difficulty, date string, n int) ([]BoardEntry, error) {
+	url := fmt.Sprintf("%s/top/%s?n=%d&difficulty=%s", c.URL, mode, n, d)
+	if date != "" {
+		url += "&date=" + date
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	var top []BoardEntry
+	err = c.do(req, http.StatusOK, &top)
+	return top, err
+}
+
+func (c *LeaderboardClient) do(req *http.Request, want int, out any) error {
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+		switch resp.StatusCode {
+		case http.StatusUnprocessableEntity, http.StatusBadRequest, http.StatusConflict:
+			err = fmt.Errorf("%w: %v", ErrRunRejected, err)
+		}
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// submission is the outcome of sending a run from the game.
+type submission struct {
+	result Submitted
+	err    error
+}
+
+// submitRun sends the finished run to the configured leaderboard in the
+// background. It returns nil when no server is configured, or for co-op
+// runs, which the boards do not rank.
+func (g *Game) submitRun() <-chan submission {
+	if g.settings.LeaderboardURL == "" || len(g.players) > 1 {
+		return nil
+	}
+	r := RunResult{Version: ResultVersion, Name: g.playerName(), Score: g.score, Replay: g.replay()}
+	c := NewLeaderboardClient(g.settings.LeaderboardURL)
+	done := make(chan submission, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), SubmitTimeout)
+		defer cancel()
+		res, err := c.Submit(ctx, r)
+		if err != nil {
+			log.Printf("leaderboard: %v", err)
+		}
+		done <- submission{res, err}
+	}()
+	return done
+}
+
+// serveLeaderboard runs the leaderboard service until it fails and
+// returns the exit status. Runs are verified with the built-in assets.
+func serveLeaderboard(addr, path string) int {
+	assets, err := NewAssetStore()
+	if err == nil {
+		var s *LeaderboardServer
+		if s, err = NewLeaderboardServer(assets, path); err == nil {
+			log.Printf("leaderboard listening on %s", addr)
+			err = http.ListenAndServe(addr, s)
+		}
+	}
+	log.Print(err)
+	return 1
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// classicResult plays a classic run with seed on difficulty d to the end
+// and returns it as a result to submit.
+func classicResult(t *testing.T, name string, seed uint64, d Difficulty) RunResult {
+	t.Helper()
+	g, _ := newTestGame()
+	g.ResetRun(RunConfig{Mode: ModeClassic, Seed: seed, Difficulty: d})
+	finishRun(t, g)
+	return RunResult{Version: ResultVersion, Name: name, Score: g.score, Replay: g.replay()}
+}
+
+func TestLeaderboard(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "leaderboard.json")
+	srv, err := NewLeaderboardServer(assets, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewLeaderboardClient(ts.URL)
+	ctx := context.Background()
+
+	runs := []RunResult{classicResult(t, "ana", 1, DifficultyNormal), classicResult(t, "bia", 2, DifficultyNormal)}
+	for _, r := range runs {
+		if _, err := c.Submit(ctx, r); err != nil {
+			t.Fatalf("Submit(%s) = %v", r.Name, err)
+		}
+	}
+	cheat := runs[0]
+	cheat.Score += 1000
+	if _, err := c.Submit(ctx, cheat); !errors.Is(err, ErrRunRejected) {
+		t.Errorf("Submit(pontos alterados) = %v; esperado ErrRunRejected", err)
+	}
+	copied := runs[0]
+	copied.Name = "cris"
+	if _, err := c.Submit(ctx, copied); !errors.Is(err, ErrRunRejected) {
+		t.Errorf("Submit(mesma partida com outro nome) = %v; esperado ErrRunRejected", err)
+	}
+	easy := classicResult(t, "dani", 1, DifficultyEasy)
+	if _, err := c.Submit(ctx, easy); err != nil {
+		t.Fatalf("Submit(fácil) = %v", err)
+	}
+	anonymous := runs[0]
+	anonymous.Name = " "
+	if _, err := c.Submit(ctx, anonymous); !errors.Is(err, ErrRunRejected) {
+		t.Errorf("Submit(sem nome) = %v; esperado ErrRunRejected", err)
+	}
+
+	best, worst := runs[0], runs[1]
+	if best.Score < worst.Score {
+		best, worst = worst, best
+	}
+	top, err := c.Top(ctx, ModeClassic, DifficultyNormal, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != 2 || top[0].Name != best.Name || top[1].Name != worst.Name {
+		t.Errorf("placar = %+v; esperado %s e depois %s", top, best.Name, worst.Name)
+	}
+	if top, err := c.Top(ctx, ModeClassic, DifficultyNormal, "", 1); err != nil || len(top) != 1 {
+		t.Errorf("Top(n=1) = %d partidas (%v); esperado 1", len(top), err)
+	}
+	if top, err := c.Top(ctx, ModeClassic, DifficultyEasy, "", 10); err != nil || len(top) != 1 || top[0].Name != easy.Name {
+		t.Errorf("Top(fácil) = %+v (%v); esperado só %s", top, err, easy.Name)
+	}
+	if top, err := c.Top(ctx, ModeDaily, DifficultyNormal, "", 10); err != nil || len(top) != 0 {
+		t.Errorf("Top(daily) = %d partidas (%v); esperado nenhuma", len(top), err)
+	}
+	if _, err := c.Top(ctx, "arcade", DifficultyNormal, "", 10); err == nil {
+		t.Error("Top(modo desconhecido) sem erro")
+	}
+
+	// The boards survive a restart.
+	again, err := NewLeaderboardServer(assets, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(again.boards[boardKey(ModeClassic, DifficultyNormal)]); n != 2 {
+		t.Errorf("%d partidas depois de reiniciar; esperado 2", n)
+	}
+}
+
+func TestLeaderboardSplitsOldBoards(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "leaderboard.json")
+	old := `{"version": 1, "boards": {"classic": [
+		{"name": "ana", "score": 900, "mode": "classic", "difficulty": 2},
+		{"name": "bia", "score": 500, "mode": "classic", "difficulty": 1},
+		{"name": "cris", "score": 300, "mode": "classic", "difficulty": 2}
+	]}}`
+	if err := os.WriteFile(path, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewLeaderboardServer(assets, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hard, normal := srv.boards[boardKey(ModeClassic, DifficultyHard)], srv.boards[boardKey(ModeClassic, DifficultyNormal)]
+	if len(hard) != 2 || hard[0].Name != "ana" || hard[1].Name != "cris" || len(normal) != 1 || normal[0].Name != "bia" {
+		t.Errorf("difícil %+v, normal %+v; esperado ana e cris, e bia", hard, normal)
+	}
+}
+
+func TestGameOverSubmits(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewLeaderboardServer(assets, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(srv)
+	down := httptest.NewServer(srv)
+	down.Close()
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+		args []any
+	}{
+		{"servidor no ar", ts.URL, "leaderboard.accepted", []any{1}},
+		{"servidor fora do ar", down.URL, "leaderboard.offline", nil},
+		{"sem servidor", "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGame()
+			g.settings.LeaderboardURL = tt.url
+			g.ResetRun(RunConfig{Mode: ModeClassic, Seed: 3})
+			finishRun(t, g)
+			s, ok := g.scenes.Top().(*GameOverScene)
+			if !ok {
+				t.Fatalf("cena = %T; esperado *GameOverScene", g.scenes.Top())
+			}
+			for deadline := time.Now().Add(5 * time.Second); s.submit != nil; {
+				if time.Now().After(deadline) {
+					t.Fatal("o envio não terminou")
+				}
+				time.Sleep(time.Millisecond)
+				s.Update()
+			}
+			want := ""
+			if tt.want != "" {
+				want = g.loc.T(tt.want, tt.args...)
+			}
+			if s.status != want {
+				t.Errorf("status = %q; esperado %q", s.status, want)
+			}
+		})
+	}
+}
+
+func TestLeaderboardFullBoard(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewLeaderboardServer(assets, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := boardKey(ModeClassic, DifficultyNormal)
+	for i := range LeaderboardKeep {
+		srv.boards[key] = append(srv.boards[key], BoardEntry{Name: "ana", Score: 1 << 20, Mode: ModeClassic, Run: fmt.Sprint(i)})
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewLeaderboardClient(ts.URL)
+
+	r := classicResult(t, "bia", 1, DifficultyNormal)
+	res, err := c.Submit(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Rank != 0 {
+		t.Errorf("partida fora do placar com posição %d; esperado nenhuma", res.Rank)
+	}
+	if board := srv.boards[key]; len(board) != LeaderboardKeep || board[len(board)-1].Name == "bia" {
+		t.Errorf("placar com %d partidas, a última de %s; esperado %d, sem bia", len(board), board[len(board)-1].Name, LeaderboardKeep)
+	}
+}
@@ -1,19 +1,200 @@
 package main
 
 import (
+	"cmp"
+	"flag"
+	"fmt"
 	"log"
-	"math/rand"
-	"time"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
+	packs := flag.String("assets", "", "comma-separated asset pack directories; later packs win")
+	dev := flag.Bool("dev", false, "reload asset packs when their files change")
+	lang := flag.String("lang", "", "interface language ("+strings.Join(Languages, ", ")+")")
+	name := flag.String("name", "", "player name written on shared results")
+	serve := flag.String("serve", "", "run the leaderboard server on this address (e.g. :8080) instead of the game")
+	board := flag.String("board", "", "file the leaderboard server keeps its boards in")
+	server := flag.String("leaderboard", "", "leaderboard server URL finished runs are sent to")
+	host := flag.String("host", "", "host a netplay run on this address (e.g. :7777) instead of the game")
+	players := flag.Int("players", 2, "ships in a hosted netplay run")
+	versus := flag.Bool("versus", false, "play a versus match rather than co-op, with -host or -p2p")
+	join := flag.String("join", "", "join the netplay run hosted at this address")
+	p2p := flag.String("p2p", "", "play peer to peer, listening on this address (e.g. :7000)")
+	peer := flag.String("peer", "", "the other peer's address in peer-to-peer play")
+	p2pPlayer := flag.Int("p2p-player", 1, "this peer's player, 1 (who chooses the run) or 2")
+	broadcast := flag.String("broadcast", "", "stream the runs played to spectators connecting to this address (e.g. :7100)")
+	delay := flag.Duration("broadcast-delay", 0, "how far behind the match spectators see it (e.g. 30s)")
+	spectate := flag.String("spectate", "", "watch the match broadcast at this address")
+	botBench := flag.Int("bot-bench", 0, "play this many bot games on every difficulty without a window, print the scores and exit")
+	botSkill := flag.String("bot-skill", "normal", "the skill of the -bot-bench bot: easy, normal or hard")
+	gym := flag.Bool("gym", false, "run as a reinforcement learning environment speaking json lines on stdin and stdout")
+	verify := flag.String("verify", "", "re-simulate a result file, report whether it holds up and exit")
+	flag.Parse()
+
+	if *verify != "" {
+		os.Exit(verifyResult(*verify))
+	}
+	if *gym {
+		os.Exit(runGym())
+	}
+	if *botBench > 0 {
+		os.Exit(benchBots(os.Stdout, *botBench, *botSkill))
+	}
+	if *serve != "" {
+		os.Exit(serveLeaderboard(*serve, *board))
+	}
+	var b *Broadcaster
+	if *broadcast != "" {
+		ln, err := net.Listen("tcp", *broadcast)
+		if err != nil {
+			log.Fatalf("broadcast: %v", err)
+		}
+		b = NewBroadcaster(ln, *delay)
+		defer b.Close()
+		log.Printf("broadcast: spectators can connect to %s", ln.Addr())
+	}
+	if *host != "" {
+		cfg := RunConfig{Mode: ModeClassic, Seed: rand.Uint64(), Players: *players, Rounds: DefaultRounds}
+		if *versus {
+			cfg.Mode = ModeVersus
+		}
+		os.Exit(hostNetplay(*host, cfg, b))
+	}
+
 	ebiten.SetWindowSize(ScreenWidth, ScreenHeight)
-	ebiten.SetWindowTitle("Asteroides Profissional - Clean & Elegant")
-	game := NewGame()
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	// Keep updating while unfocused so the game notices, pauses and saves
+	// the run; closing the window saves it too.
+	ebiten.SetRunnableOnUnfocused(true)
+	ebiten.SetWindowClosingHandled(true)
+	var dirs []string
+	if *packs != "" {
+		dirs = strings.Split(*packs, ",")
+	}
+	assets, err := NewAssetStore(dirs...)
+	if err != nil {
+		log.Printf("assets: %v", err)
+	}
+	assets.Dev = *dev
+	game := NewGame(assets)
+	game.broadcast = b
+	path, err := ConfigPath("settings.json")
+	if err != nil {
+		log.Printf("settings: %v", err)
+	} else {
+		settings, err := LoadSettings(path)
+		if err != nil {
+			// Keep the unreadable file for the player to fix rather than
+			// overwriting it with defaults.
+			log.Printf("settings: %v; changes will not be saved", err)
+		} else {
+			game.settingsPath = path
+		}
+		game.applySettings(settings)
+	}
+	if path, err := ConfigPath("save.json"); err == nil {
+		game.savePath = path
+	}
+	if path, err := ConfigPath("stats.json"); err == nil {
+		lifetime, err := LoadStats(path)
+		if err != nil {
+			log.Printf("stats: %v; runs will not be recorded", err)
+		} else {
+			game.lifetime = lifetime
+			game.statsPath = path
+		}
+		game.highScore = game.lifetime.BestScore
+	}
+	if path, err := ConfigPath("achievements.json"); err == nil {
+		if err := game.achievements.LoadProgress(path); err != nil {
+			log.Printf("achievements: %v; progress will not be saved", err)
+		} else {
+			game.achievementsPath = path
+		}
+	}
+	if path, err := ConfigPath("daily.json"); err == nil {
+		daily, err := LoadDaily(path)
+		if err != nil {
+			log.Printf("daily: %v; challenges will not be recorded", err)
+		} else {
+			game.daily = daily
+			game.dailyPath = path
+		}
+	}
+	if path, err := ConfigPath("tutorial.json"); err == nil {
+		progress, err := LoadTutorialProgress(path)
+		if err != nil {
+			log.Printf("tutorial: %v; progress will not be saved", err)
+		} else {
+			game.tutorial = progress
+			game.tutorialPath = path
+		}
+	}
+	if path, err := ConfigPath("ghosts.json"); err == nil {
+		ghosts, err := LoadGhosts(path)
+		if err != nil {
+			log.Printf("ghosts: %v; best runs will not be kept", err)
+		} else {
+			game.ghosts = ghosts
+			game.ghostsPath = path
+		}
+	}
+	if *name != "" || *server != "" {
+		game.settings.Name = cmp.Or(*name, game.settings.Name)
+		game.settings.LeaderboardURL = cmp.Or(*server, game.settings.LeaderboardURL)
+		game.saveSettings()
+	}
+	if *lang != "" {
+		game.setLanguage(*lang)
+	}
+	ebiten.SetWindowTitle(game.loc.T("window.title"))
+	game.mixer.SetBackend(NewEbitenAudio())
+	game.mixer.StartMusic()
+	if *join != "" {
+		if err := game.joinNet(*join); err != nil {
+			log.Printf("netplay: %v", err)
+		}
+	}
+	if *p2p != "" {
+		cfg := game.classicConfig(rand.Uint64())
+		if *versus {
+			cfg = game.versusConfig(rand.Uint64())
+		}
+		if err := game.playPeer(*p2p, *peer, *p2pPlayer-1, cfg); err != nil {
+			log.Printf("peer to peer: %v", err)
+		}
+	}
+	if *spectate != "" {
+		if err := game.spectate(*spectate); err != nil {
+			log.Printf("spectate: %v", err)
+		}
+	}
 	if err := ebiten.RunGame(game); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// verifyResult checks a result file with the built-in assets only, so
+// asset packs cannot change the outcome, and returns the exit status.
+func verifyResult(path string) int {
+	r, err := LoadResult(path)
+	if err == nil {
+		var assets *AssetStore
+		if assets, err = NewAssetStore(); err == nil {
+			err = Verify(assets, r)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return 1
+	}
+	c := r.Replay.Config
+	fmt.Printf("%s: ok — %s scored %d (%s %s, modifiers %v)\n", path, r.Name, r.Score, c.Mode, c.Date, c.Modifiers.List())
+	return 0
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// NetRetryFrames is how often a client repeats its hello until the server
+// answers.
+const NetRetryFrames = TicksPerSecond / 2
+
+// NetClient plays a run hosted by a NetServer. The local ship is predicted
+// from the player's inputs as they are sent, then corrected whenever a
+// snapshot shows where the server had it; everything else is drawn a
+// little in the past, interpolated between snapshots.
+type NetClient struct {
+	conn     net.PacketConn
+	server   net.Addr
+	name     string
+	packets  chan netPacket
+	Index    int       // the local player, -1 until the server accepts
+	Config   RunConfig // of the hosted run, once accepted
+	Rejected string    // why the server refused, if it did
+
+	frames     int
+	heard      int // frame the server was last heard from
+	seq        uint32
+	pending    []netInput // sent but not yet applied by the server
+	inputAck   uint32
+	states     [NetHistory]netState // received, by tick
+	newest     uint32               // tick of the newest snapshot
+	ship       Player               // the local ship, predicted
+	renderTick float64
+	lastBullet uint32 // the newest bullet heard, for the firing sound
+}
+
+type netInput struct {
+	seq uint32
+	in  Input
+}
+
+// DialNet starts joining the server at addr over conn as name.
+func DialNet(conn net.PacketConn, addr, name string) (*NetClient, error) {
+	server, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c := &NetClient{conn: conn, server: server, name: name, packets: make(chan netPacket, 256), Index: -1}
+	go readPackets(conn, c.packets)
+	return c, nil
+}
+
+// Close leaves the server.
+func (c *NetClient) Close() error {
+	return c.conn.Close()
+}
+
+// Started reports whether the run has begun: every player has joined and
+// a snapshot has arrived.
+func (c *NetClient) Started() bool {
+	return c.newest > 0
+}
+
+// Running reports whether the run is still going, as of the newest
+// snapshot.
+func (c *NetClient) Running() bool {
+	return c.Started() && c.states[c.newest%NetHistory].Running
+}
+
+// Lost reports whether the server has gone quiet.
+func (c *NetClient) Lost() bool {
+	return c.Index >= 0 && c.frames-c.heard > NetTimeout
+}
+
+// Update handles the packets that have arrived and sends the player's
+// input for this frame, once a frame.
+func (c *NetClient) Update(in Input) {
+	c.frames++
+	for len(c.packets) > 0 {
+		c.handle(<-c.packets)
+	}
+	switch {
+	case c.Rejected != "":
+		return
+	case c.Index < 0:
+		if c.frames%NetRetryFrames == 1 {
+			pkt := binary.AppendUvarint([]byte{msgHello}, NetVersion)
+			c.send(appendBytes(pkt, []byte(c.name)))
+		}
+		return
+	case !c.Running():
+		return
+	}
+	c.seq++
+	c.pending = append(c.pending, netInput{c.seq, in})
+	if len(c.pending) > NetHistory {
+		c.pending = c.pending[1:]
+	}
+	if !c.ship.out {
+		c.ship.Update(in)
+	}
+	n := min(len(c.pending), NetInputRedundancy)
+	pkt := binary.AppendUvarint([]byte{msgInput}, uint64(c.newest))
+	pkt = binary.AppendUvarint(pkt, uint64(c.seq))
+	pkt = append(pkt, byte(n))
+	for _, p := range c.pending[len(c.pending)-n:] {
+		pkt = append(pkt, byte(p.in))
+	}
+	c.send(pkt)
+
+	// Drift the render clock towards a little behind the newest snapshot,
+	// jumping only when it is far off.
+	c.renderTick++
+	target := float64(c.newest) - NetInterpDelay
+	if math.Abs(target-c.renderTick) > NetInterpDelay {
+		c.renderTick = target
+	} else {
+		c.renderTick += (target - c.renderTick) / 10
+	}
+}
+
+func (c *NetClient) handle(pkt netPacket) {
+	if pkt.addr.String() != c.server.String() {
+		return
+	}
+	r := netReader{b: pkt.data}
+	switch r.byte() {
+	case msgWelcome:
+		index := int(r.byte())
+		var cfg RunConfig
+		if err := json.Unmarshal(r.bytes(), &cfg); err != nil || r.err != nil || c.Index >= 0 {
+			return
+		}
+		c.Index, c.Config = index, cfg
+	case msgReject:
+		if reason := string(r.bytes()); r.err == nil && c.Index < 0 {
+			c.Rejected = reason
+		}
+	case msgSnapshot:
+		ack := uint32(r.uvarint())
+		baseTick := uint32(r.uvarint())
+		base := &netState{}
+		if baseTick > 0 {
+			if base = &c.states[baseTick%NetHistory]; base.Tick != baseTick {
+				return // a baseline we no longer have
+			}
+		}
+		s := readDelta(&r, base)
+		if r.err != nil || c.Index < 0 || c.Index >= len(s.Entities[kindShip]) {
+			return
+		}
+		c.heard = c.frames
+		c.states[s.Tick%NetHistory] = s
+		if s.Tick < c.newest {
+			return
+		}
+		if c.newest == 0 {
+			c.renderTick = float64(s.Tick) - NetInterpDelay
+		}
+		c.newest = s.Tick
+		if ack >= c.inputAck {
+			c.inputAck = ack
+			c.reconcile(&s)
+		}
+	}
+}
+
+// reconcile puts the local ship where the server had it, then replays the
+// inputs the server had not applied yet.
+func (c *NetClient) reconcile(s *netState) {
+	i := slices.IndexFunc(c.pending, func(p netInput) bool { return p.seq > c.inputAck })
+	if i < 0 {
+		i = len(c.pending)
+	}
+	c.pending = c.pending[i:]
+	e := s.Entities[kindShip][c.Index].Fields
+	c.ship = Player{
+		index:        c.Index,
+		position:     Vector{float64(e[fieldX]), float64(e[fieldY])},
+		velocity:     Vector{float64(e[shipVX]), float64(e[shipVY])},
+		angle:        float64(e[shipAngle]),
+		shield:       int(e[shipShield]),
+		rapidFire:    int(e[shipRapidFire]),
+		multiShot:    int(e[shipMultiShot]),
+		fireCooldown: int(e[shipCooldown]),
+		out:          int(e[shipFlags])&shipOut != 0,
+	}
+	for _, p := range c.pending {
+		if !c.ship.out {
+			c.ship.Update(p.in)
+		}
+	}
+}
+
+// around returns the snapshots either side of the render clock, and how
+// far it is from a to b.
+func (c *NetClient) around() (a, b *netState, t float64) {
+	for i := range c.states {
+		s := &c.states[i]
+		if s.Tick == 0 || c.newest-s.Tick >= NetHistory {
+			continue
+		}
+		if float64(s.Tick) <= c.renderTick && (a == nil || s.Tick > a.Tick) {
+			a = s
+		}
+		if float64(s.Tick) > c.renderTick && (b == nil || s.Tick < b.Tick) {
+			b = s
+		}
+	}
+	switch {
+	case a == nil:
+		return b, b, 0
+	case b == nil:
+		return a, a, 0
+	}
+	return a, b, (c.renderTick - float64(a.Tick)) / float64(b.Tick-a.Tick)
+}
+
+// lerpPosition interpolates e's position from its place in a, unless it has
+// only just appeared or wrapped around the screen.
+func lerpPosition(a *netState, k int, e *netEntity, t float64) Vector {
+	to := Vector{float64(e.Fields[fieldX]), float64(e.Fields[fieldY])}
+	old := a.find(k, e.ID)
+	if old == nil {
+		return to
+	}
+	from := Vector{float64(old.Fields[fieldX]), float64(old.Fields[fieldY])}
+	if math.Abs(to.X-from.X) > ScreenWidth/2 || math.Abs(to.Y-from.Y) > ScreenHeight/2 {
+		return to
+	}
+	return Vector{from.X + (to.X-from.X)*t, from.Y + (to.Y-from.Y)*t}
+}
+
+// lerpAngle interpolates an angle field the short way round.
+func lerpAngle(a *netState, k int, e *netEntity, field int, t float64) float64 {
+	to := float64(e.Fields[field])
+	if old := a.find(k, e.ID); old != nil {
+		from := float64(old.Fields[field])
+		return from + math.Remainder(to-from, 2*math.Pi)*t
+	}
+	return to
+}
+
+// Show puts the run as the client sees it into g, for drawing: the local
+// ship where it is predicted to be, the rest interpolated. Asteroids and
+// ships that are gone since the last call explode, and the explosions
+// already going on move a frame along.
+func (c *NetClient) Show(g *Game) {
+	if !c.Started() {
+		return
+	}
+	g.updateExplosions()
+	a, b, t := c.around()
+	newRound := b.Round != g.round
+	g.local = c.Index
+	g.tick = int(b.Tick)
+	g.score = b.Score
+	g.round = b.Round
+	for _, e := range b.Entities[kindShip] {
+		if int(e.ID) >= len(g.players) {
+			continue
+		}
+		p := &g.players[e.ID]
+		f := e.Fields
+		out := int(f[shipFlags])&shipOut != 0
+		if out && !p.out {
+			g.explode(p.position)
+			g.mixer.Play(SoundDamage)
+		}
+		p.out = out
+		p.position = lerpPosition(a, kindShip, &e, t)
+		p.angle = lerpAngle(a, kindShip, &e, shipAngle, t)
+		p.velocity = Vector{float64(f[shipVX]), float64(f[shipVY])}
+		p.isAccelerating = int(f[shipFlags])&shipThrust != 0
+		p.health = int(f[shipHealth])
+		g.sharedHealth = p.health
+		p.shield = int(f[shipShield])
+		p.rapidFire = int(f[shipRapidFire])
+		p.multiShot = int(f[shipMultiShot])
+		p.fireCooldown = int(f[shipCooldown])
+		p.score = int(f[shipScore])
+		g.wins[e.ID] = int(f[shipWins])
+		if int(e.ID) == c.Index && !out {
+			p.position, p.velocity, p.angle = c.ship.position, c.ship.velocity, c.ship.angle
+			p.isAccelerating = c.ship.isAccelerating
+		}
+	}
+
+	listener := g.listener()
+	old := map[uint32]Asteroid{}
+	for _, ast := range g.asteroids {
+		old[ast.id] = ast
+	}
+	g.asteroids = g.asteroids[:0]
+	for _, e := range b.Entities[kindAsteroid] {
+		delete(old, e.ID)
+		g.asteroids = append(g.asteroids, Asteroid{
+			id:       e.ID,
+			position: lerpPosition(a, kindAsteroid, &e, t),
+			angle:    lerpAngle(a, kindAsteroid, &e, asteroidAngle, t),
+			size:     float64(e.Fields[asteroidSize]),
+		})
+	}
+	for _, ast := range old {
+		if newRound {
+			break // cleared for the next round, not shot
+		}
+		g.explode(ast.position)
+		g.mixer.PlayAt(explosionSound(ast.size), ast.position, listener)
+	}
+
+	for _, bl := range g.bullets {
+		g.bulletPool.Put(bl)
+	}
+	g.bullets = g.bullets[:0]
+	for _, e := range b.Entities[kindBullet] {
+		bl := g.bulletPool.Get()
+		bl.id = e.ID
+		bl.position = lerpPosition(a, kindBullet, &e, t)
+		bl.owner = int(e.Fields[bulletOwner])
+		g.bullets = append(g.bullets, bl)
+		if e.ID > c.lastBullet {
+			c.lastBullet = e.ID
+			g.mixer.PlayAt(SoundFire, bl.position, listener)
+		}
+	}
+
+	for _, pw := range g.powerUps {
+		g.powerUpPool.Put(pw)
+	}
+	g.powerUps = g.powerUps[:0]
+	for _, e := range b.Entities[kindPowerUp] {
+		pw := g.powerUpPool.Get()
+		pw.id = e.ID
+		pw.position = lerpPosition(a, kindPowerUp, &e, t)
+		pw.powerType = PowerUpType(e.Fields[powerUpType])
+		if pw.powerType < 0 || pw.powerType >= powerUpCount {
+			pw.powerType = PowerUpShield
+		}
+		g.powerUps = append(g.powerUps, pw)
+	}
+}
+
+func (c *NetClient) send(pkt []byte) {
+	c.conn.WriteTo(pkt, c.server)
+}
+
+// NetPlayScene plays a run hosted on another machine.
+type NetPlayScene struct {
+	g      *Game
+	c      *NetClient
+	joined bool
+}
+
+// joinNet connects to the netplay server at addr and shows the run.
+func (g *Game) joinNet(addr string) error {
+	conn, err := net.ListenPacket("udp", ":0")
+	if err != nil {
+		return err
+	}
+	c, err := DialNet(conn, addr, g.playerName())
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	g.scenes.Reset(&NetPlayScene{g: g, c: c}, Fade)
+	return nil
+}
+
+func (s *NetPlayScene) Update() error {
+	g, c := s.g, s.c
+	if g.settings.Bindings.JustPressed(ActionPause) || g.nav.Back {
+		c.Close()
+		g.quitToMenu()
+		return nil
+	}
+	// The local player uses the main keys and the first gamepad.
+	in := g.settings.Bindings.Read()
+	if pads := ebiten.AppendGamepadIDs(nil); len(pads) > 0 {
+		slices.Sort(pads)
+		in |= readGamepad(pads[0])
+	}
+	c.Update(in)
+	if c.Index >= 0 && !s.joined {
+		// Set the screen up for the hosted run. It is only drawn here, not
+		// played, so it is never saved.
+		g.ResetRun(c.Config)
+		g.running = false
+		s.joined = true
+	}
+	if s.joined {
+		c.Show(g)
+	}
+	return nil
+}
+
+func (s *NetPlayScene) Draw(screen *ebiten.Image) {
+	g, c := s.g, s.c
+	if c.Started() {
+		g.drawPlaying(screen)
+	}
+	var status string
+	switch {
+	case c.Rejected != "":
+		status = g.loc.T("net.rejected_" + c.Rejected)
+	case c.Lost():
+		status = g.loc.T("net.lost")
+	case c.Index < 0:
+		status = g.loc.T("net.connecting", c.server)
+	case !c.Started():
+		status = g.loc.T("net.waiting", c.Index+1)
+	case !c.Running():
+		status = g.loc.T("net.over")
+	default:
+		return
+	}
+	drawAnchoredText(screen, status+"\n"+g.loc.T("net.leave"), g.fonts.Normal, AnchorCenter, 0, -120, g.theme.Message)
+}
+
+func (s *NetPlayScene) Overlay() bool { return false }
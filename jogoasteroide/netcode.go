@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// NetVersion is the network protocol spoken by this build. Clients and
+// servers of different versions cannot play together.
+const NetVersion = 1
+
+const (
+	// NetSnapshotInterval is how many simulation steps pass between the
+	// snapshots the server sends.
+	NetSnapshotInterval = 2
+	// NetHistory is how many recent snapshots each side keeps as delta
+	// baselines, and how far ahead of the server a client's inputs may run.
+	NetHistory = 64
+	// NetInputRedundancy is how many recent inputs every input packet
+	// repeats, so a lost packet rarely costs the server an input.
+	NetInputRedundancy = 8
+	// NetInterpDelay is how many steps behind the newest snapshot other
+	// ships, asteroids and bullets are drawn, so there is usually a
+	// snapshot on either side to interpolate between.
+	NetInterpDelay = 6
+	// NetTimeout is how many steps without a packet before the other side
+	// counts as gone.
+	NetTimeout = 3 * TicksPerSecond
+	// NetMaxPacket bounds a datagram.
+	NetMaxPacket = 64 << 10
+)
+
+// Packet types, the first byte of every datagram.
+const (
+	msgHello    byte = iota + 1 // client: version, name
+	msgWelcome                  // server: ship index, run config
+	msgReject                   // server: reason
+	msgInput                    // client: newest snapshot tick, newest input seq, recent inputs
+	msgSnapshot                 // server: newest input seq applied, baseline tick, state delta
+)
+
+// Reasons a server turns a client away.
+const (
+	rejectFull    = "full"
+	rejectVersion = "version"
+)
+
+// Kinds of entity in a snapshot.
+const (
+	kindShip = iota
+	kindAsteroid
+	kindBullet
+	kindPowerUp
+	netKinds
+)
+
+// Snapshot fields of each kind of entity. Every kind starts with its
+// position, which is what gets interpolated.
+const (
+	fieldX = iota
+	fieldY
+)
+
+const (
+	shipVX = iota + 2
+	shipVY
+	shipAngle
+	shipHealth
+	shipShield
+	shipRapidFire
+	shipMultiShot
+	shipCooldown
+	shipScore
+	shipWins
+	shipFlags
+	shipFields
+)
+
+const (
+	asteroidAngle = iota + 2
+	asteroidSize
+	asteroidFields
+)
+
+const (
+	bulletOwner = iota + 2
+	bulletFields
+)
+
+const (
+	powerUpType = iota + 2
+	powerUpFields
+)
+
+var netFields = [netKinds]int{shipFields, asteroidFields, bulletFields, powerUpFields}
+
+// Ship flags.
+const (
+	shipOut    = 1
+	shipThrust = 2
+)
+
+// netEntity is one ship, asteroid, bullet or power-up in a snapshot. Ships
+// are identified by player index, everything else by its id.
+type netEntity struct {
+	ID     uint32
+	Fields []float32
+}
+
+// netState is what clients are sent of a run: enough to draw it and to
+// predict their own ship, not to simulate it.
+type netState struct {
+	Tick     uint32
+	Running  bool
+	Score    int
+	Round    int
+	Entities [netKinds][]netEntity // each sorted by ID
+}
+
+// netState captures the run for clients.
+func (g *Game) netState() netState {
+	s := netState{Tick: uint32(g.tick), Running: g.running, Score: g.score, Round: g.round}
+	f := func(v ...float64) []float32 {
+		fields := make([]float32, len(v))
+		for i, x := range v {
+			fields[i] = float32(x)
+		}
+		return fields
+	}
+	for i := range g.players {
+		p := &g.players[i]
+		flags := 0
+		if p.out {
+			flags |= shipOut
+		}
+		if p.isAccelerating {
+			flags |= shipThrust
+		}
+		e := netEntity{uint32(i), f(p.position.X, p.position.Y, p.velocity.X, p.velocity.Y,
+			math.Remainder(p.angle, 2*math.Pi), float64(*g.health(p)), float64(p.shield),
+			float64(p.rapidFire), float64(p.multiShot), float64(p.fireCooldown), float64(p.score),
+			float64(g.wins[i]), float64(flags))}
+		s.Entities[kindShip] = append(s.Entities[kindShip], e)
+	}
+	for _, a := range g.asteroids {
+		e := netEntity{a.id, f(a.position.X, a.position.Y, math.Remainder(a.angle, 2*math.Pi), a.size)}
+		s.Entities[kindAsteroid] = append(s.Entities[kindAsteroid], e)
+	}
+	for _, b := range g.bullets {
+		s.Entities[kindBullet] = append(s.Entities[kindBullet], netEntity{b.id, f(b.position.X, b.position.Y, float64(b.owner))})
+	}
+	for _, pw := range g.powerUps {
+		s.Entities[kindPowerUp] = append(s.Entities[kindPowerUp], netEntity{pw.id, f(pw.position.X, pw.position.Y, float64(pw.powerType))})
+	}
+	for _, list := range s.Entities {
+		slices.SortFunc(list, func(a, b netEntity) int { return cmp.Compare(a.ID, b.ID) })
+	}
+	return s
+}
+
+// appendDelta writes s as changes to base: per entity only the fields
+// that differ, and the ids of entities that are gone. An empty base
+// writes the whole state.
+func (s *netState) appendDelta(b []byte, base *netState) []byte {
+	b = binary.AppendUvarint(b, uint64(s.Tick))
+	b = append(b, boolByte(s.Running))
+	b = binary.AppendVarint(b, int64(s.Score))
+	b = binary.AppendUvarint(b, uint64(s.Round))
+	for k, list := range s.Entities {
+		old := map[uint32][]float32{}
+		for _, e := range base.Entities[k] {
+			old[e.ID] = e.Fields
+		}
+		var changed []byte
+		n := 0
+		for _, e := range list {
+			prev, ok := old[e.ID]
+			delete(old, e.ID)
+			mask := uint64(0)
+			for i, v := range e.Fields {
+				if !ok || math.Float32bits(v) != math.Float32bits(prev[i]) {
+					mask |= 1 << i
+				}
+			}
+			if mask == 0 {
+				continue
+			}
+			n++
+			changed = binary.AppendUvarint(changed, uint64(e.ID))
+			changed = binary.AppendUvarint(changed, mask)
+			for i, v := range e.Fields {
+				if mask&(1<<i) != 0 {
+					changed = binary.LittleEndian.AppendUint32(changed, math.Float32bits(v))
+				}
+			}
+		}
+		b = binary.AppendUvarint(b, uint64(n))
+		b = append(b, changed...)
+		b = binary.AppendUvarint(b, uint64(len(old)))
+		for _, e := range base.Entities[k] {
+			if _, gone := old[e.ID]; gone {
+				b = binary.AppendUvarint(b, uint64(e.ID))
+			}
+		}
+	}
+	return b
+}
+
+// readDelta reads a state written by appendDelta against the same base.
+func readDelta(r *netReader, base *netState) netState {
+	s := netState{
+		Tick:    uint32(r.uvarint()),
+		Running: r.byte() != 0,
+		Score:   int(r.varint()),
+		Round:   int(r.uvarint()),
+	}
+	for k := range s.Entities {
+		byID := map[uint32][]float32{}
+		for _, e := range base.Entities[k] {
+			byID[e.ID] = e.Fields
+		}
+		for n := r.count(); n > 0; n-- {
+			id := uint32(r.uvarint())
+			mask := r.uvarint()
+			fields := make([]float32, netFields[k])
+			copy(fields, byID[id])
+			for i := range fields {
+				if mask&(1<<i) != 0 {
+					fields[i] = math.Float32frombits(r.uint32())
+				}
+			}
+			byID[id] = fields
+		}
+		for n := r.count(); n > 0; n-- {
+			delete(byID, uint32(r.uvarint()))
+		}
+		var list []netEntity
+		for id, fields := range byID {
+			list = append(list, netEntity{id, fields})
+		}
+		slices.SortFunc(list, func(a, b netEntity) int { return cmp.Compare(a.ID, b.ID) })
+		s.Entities[k] = list
+	}
+	return s
+}
+
+// find returns the entity of kind k with the given id, or nil.
+func (s *netState) find(k int, id uint32) *netEntity {
+	list := s.Entities[k]
+	i, ok := slices.BinarySearchFunc(list, id, func(e netEntity, id uint32) int { return cmp.Compare(e.ID, id) })
+	if !ok {
+		return nil
+	}
+	return &list[i]
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// errShortPacket is returned for a datagram that ends too soon.
+var errShortPacket = errors.New("short packet")
+
+// netReader decodes a datagram. The first error sticks and every read
+// after it returns zero, so a packet can be read through and checked once.
+type netReader struct {
+	b   []byte
+	err error
+}
+
+func (r *netReader) byte() byte {
+	if len(r.b) < 1 {
+		r.err = errShortPacket
+		return 0
+	}
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v
+}
+
+func (r *netReader) uint32() uint32 {
+	if len(r.b) < 4 {
+		r.err = errShortPacket
+		r.b = nil
+		return 0
+	}
+	v := binary.LittleEndian.Uint32(r.b)
+	r.b = r.b[4:]
+	return v
+}
+
+func (r *netReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.err = errShortPacket
+		r.b = nil
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *netReader) varint() int64 {
+	v, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.err = errShortPacket
+		r.b = nil
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+// count reads a number of items, each at least a byte long, so a bad
+// packet cannot ask for more than it holds.
+func (r *netReader) count() int {
+	n := r.uvarint()
+	if n > uint64(len(r.b)) {
+		r.err = errShortPacket
+		r.b = nil
+		return 0
+	}
+	return int(n)
+}
+
+func (r *netReader) bytes() []byte {
+	n := r.count()
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func appendBytes(b, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// netPacket is a datagram and who sent it.
+type netPacket struct {
+	addr net.Addr
+	data []byte
+}
+
+// readPackets hands the datagrams arriving on conn to out until conn is
+// closed. Packets are dropped if out is full, as the network would.
+func readPackets(conn net.PacketConn, out chan<- netPacket) {
+	buf := make([]byte, NetMaxPacket)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		select {
+		case out <- netPacket{addr, bytes.Clone(buf[:n])}:
+		default:
+		}
+	}
+}
+
+// LossyConn is a PacketConn that delays, reorders and drops the packets
+// it sends, like a poor network. It is for testing netplay on one machine.
+type LossyConn struct {
+	net.PacketConn
+	Latency time.Duration // added to every packet
+	Jitter  time.Duration // up to this much more, at random
+	Loss    float64       // the chance a packet is dropped, from 0 to 1
+
+	mu  sync.Mutex
+	rng *RNG
+}
+
+// NewLossyConn wraps conn; seed makes the losses and delays repeatable.
+func NewLossyConn(conn net.PacketConn, latency, jitter time.Duration, loss float64, seed uint64) *LossyConn {
+	return &LossyConn{PacketConn: conn, Latency: latency, Jitter: jitter, Loss: loss, rng: NewRNG(seed)}
+}
+
+func (c *LossyConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	c.mu.Lock()
+	drop := c.rng.Float64() < c.Loss
+	delay := c.Latency + time.Duration(c.rng.Float64()*float64(c.Jitter))
+	c.mu.Unlock()
+	if !drop {
+		data := bytes.Clone(p)
+		time.AfterFunc(delay, func() { c.PacketConn.WriteTo(data, addr) })
+	}
+	return len(p), nil
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"math"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNetDelta(t *testing.T) {
+	g := newCoopGame(RunConfig{Players: 2})
+	g.spawnAsteroid()
+	g.spawnAsteroid()
+	g.spawnPowerUp()
+	before := g.netState()
+	g.asteroids = g.asteroids[1:]
+	for range 5 {
+		g.updatePlaying(Input(0).With(ActionThrust).With(ActionFire))
+	}
+	after := g.netState()
+	full := after.appendDelta(nil, &netState{})
+
+	tests := []struct {
+		name string
+		base netState
+	}{
+		{"sem base", netState{}},
+		{"sem mudanças", after},
+		{"movidos, novos e removidos", before},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := after.appendDelta(nil, &tt.base)
+			r := netReader{b: data}
+			got := readDelta(&r, &tt.base)
+			if r.err != nil || len(r.b) != 0 {
+				t.Fatalf("erro %v, %d bytes sobrando", r.err, len(r.b))
+			}
+			if !reflect.DeepEqual(got, after) {
+				t.Errorf("estado lido:\n%+v\nesperado:\n%+v", got, after)
+			}
+			if tt.base.Tick != 0 && len(data) >= len(full) {
+				t.Errorf("delta de %d bytes; esperado menos que os %d do estado completo", len(data), len(full))
+			}
+		})
+	}
+
+	// A cut-off packet is an error, not a panic or a half-read state.
+	for n := range full {
+		r := netReader{b: full[:n]}
+		if readDelta(&r, &netState{}); r.err == nil {
+			t.Errorf("pacote cortado em %d de %d bytes lido sem erro", n, len(full))
+		}
+	}
+}
+
+// listenLossy opens a UDP socket on localhost behind a poor network.
+func listenLossy(t *testing.T, seed uint64) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("sem UDP local: %v", err)
+	}
+	return NewLossyConn(conn, 8*time.Millisecond, 8*time.Millisecond, 0.1, seed)
+}
+
+func TestNetPlay(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewNetServer(assets, listenLossy(t, 1), RunConfig{Mode: ModeClassic, Seed: 3, Players: 2})
+	defer server.Close()
+	// Shields up, so a stray asteroid cannot end the run early.
+	for i := range server.game.players {
+		server.game.players[i].shield = math.MaxInt32
+	}
+	addr := server.conn.LocalAddr().String()
+	dial := func(seed uint64) *NetClient {
+		c, err := DialNet(listenLossy(t, seed), addr, "nave")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+	a, b := dial(2), dial(3)
+	var late *NetClient
+
+	// The server runs a frame every 2ms, so the network's 8 to 16ms is a
+	// lag of several frames.
+	step := func(in Input) {
+		server.Step()
+		a.Update(in)
+		b.Update(0)
+		if late != nil {
+			late.Update(0)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	for i := 0; !a.Started() || !b.Started(); i++ {
+		if i == 2000 {
+			t.Fatal("a partida não começou")
+		}
+		step(0)
+	}
+	if a.Index+b.Index != 1 {
+		t.Errorf("jogadores %d e %d; esperado 0 e 1", a.Index, b.Index)
+	}
+	late = dial(4)
+	for range 60 {
+		step(0)
+	}
+	if late.Rejected != rejectFull {
+		t.Errorf("terceiro cliente: recusa %q; esperado %q", late.Rejected, rejectFull)
+	}
+
+	ship := &server.game.players[a.Index]
+	start := ship.position
+	for range 90 {
+		step(Input(0).With(ActionThrust).With(ActionRotateLeft))
+		// The prediction runs ahead of the server by the inputs still on
+		// their way, never behind it.
+		if len(a.pending) > NetHistory/2 {
+			t.Fatalf("%d entradas sem confirmação", len(a.pending))
+		}
+	}
+	// Let the ship come to rest so the server catches up with the
+	// prediction.
+	for range 300 {
+		step(0)
+	}
+	if d := math.Hypot(ship.position.X-start.X, ship.position.Y-start.Y); d < 50 {
+		t.Errorf("a nave andou %.0f no servidor; esperado as entradas do cliente aplicadas", d)
+	}
+	if d := math.Hypot(a.ship.position.X-ship.position.X, a.ship.position.Y-ship.position.Y); d > 1 {
+		t.Errorf("previsão em %v, servidor em %v", a.ship.position, ship.position)
+	}
+
+	// The other client sees the ship where the server has it.
+	view, _ := newTestGame()
+	view.ResetRun(b.Config)
+	b.Show(view)
+	seen := view.players[a.Index].position
+	if d := math.Hypot(seen.X-ship.position.X, seen.Y-ship.position.Y); d > 1 {
+		t.Errorf("o outro cliente vê a nave em %v; servidor em %v", seen, ship.position)
+	}
+	if len(view.asteroids) != len(server.game.asteroids) {
+		t.Errorf("o outro cliente vê %d asteroides; servidor tem %d", len(view.asteroids), len(server.game.asteroids))
+	}
+}
+
+// burstConn is a PacketConn that drops the next packets it is told to.
+type burstConn struct {
+	net.PacketConn
+	drop atomic.Int32
+}
+
+func (c *burstConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.drop.Add(-1) >= 0 {
+		return len(p), nil
+	}
+	c.drop.Store(0)
+	return c.PacketConn.WriteTo(p, addr)
+}
+
+func TestNetInputBurstLoss(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewNetServer(assets, listenLossy(t, 1), RunConfig{Mode: ModeClassic, Seed: 3})
+	defer server.Close()
+	server.game.players[0].shield = math.MaxInt32
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("sem UDP local: %v", err)
+	}
+	lossy := &burstConn{PacketConn: conn}
+	c, err := DialNet(lossy, server.conn.LocalAddr().String(), "nave")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	step := func(in Input) {
+		server.Step()
+		c.Update(in)
+		time.Sleep(2 * time.Millisecond)
+	}
+	for i := 0; !c.Started(); i++ {
+		if i == 2000 {
+			t.Fatal("a partida não começou")
+		}
+		step(0)
+	}
+
+	// Far more packets in a row than each one repeats inputs for.
+	lossy.drop.Store(3 * NetInputRedundancy)
+	ship := &server.game.players[0]
+	start := ship.position
+	for range 3 * NetInputRedundancy {
+		step(0)
+	}
+	for range 60 {
+		step(Input(0).With(ActionThrust))
+	}
+	for range 60 {
+		step(0)
+	}
+	if d := math.Hypot(ship.position.X-start.X, ship.position.Y-start.Y); d < 50 {
+		t.Errorf("a nave andou %.0f no servidor depois da perda; esperado as entradas aplicadas", d)
+	}
+	if p := server.peers[0]; c.seq-p.applied > NetInputRedundancy {
+		t.Errorf("servidor aplicou até a entrada %d de %d; esperado quase todas", p.applied, c.seq)
+	}
+	if len(c.pending) > NetHistory/2 {
+		t.Errorf("%d entradas sem confirmação", len(c.pending))
+	}
+}
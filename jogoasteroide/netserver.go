@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"maps"
+	"net"
+	"slices"
+	"time"
+)
+
+// NetLinger is how many steps the server keeps sending the final snapshot
+// after the run ends, so every client hears about it.
+const NetLinger = 2 * TicksPerSecond
+
+// NetServer hosts a run for players over UDP. It alone simulates the run:
+// clients send their inputs, it steps the simulation with them and sends
+// back snapshots, each a delta against the last one the client confirmed.
+type NetServer struct {
+	conn    net.PacketConn
+	game    *Game
+	peers   []*netPeer // by player index
+	packets chan netPacket
+	history [NetHistory]netState // recent snapshots by tick, as baselines
+	steps   int
+	ended   int // steps since the run ended
+
+	// Broadcast, if set, streams the run to spectators.
+	Broadcast *Broadcaster
+}
+
+// netPeer is a client that has joined.
+type netPeer struct {
+	addr    net.Addr
+	name    string
+	inputs  map[uint32]Input // received but not applied yet, by sequence
+	applied uint32           // sequence of the newest input applied
+	last    Input
+	acked   uint32 // the newest snapshot the client has
+	heard   int    // server step the client was last heard from
+}
+
+// NewNetServer hosts a run with config cfg on conn, simulated with assets.
+// The run starts once every ship has a player.
+func NewNetServer(assets *AssetStore, conn net.PacketConn, cfg RunConfig) *NetServer {
+	g := newSimGame(assets)
+	g.ResetRun(cfg)
+	s := &NetServer{conn: conn, game: g, packets: make(chan netPacket, 256)}
+	go readPackets(conn, s.packets)
+	return s
+}
+
+// Run steps the server in real time until the run is over and every
+// client has been told, or ctx is done.
+func (s *NetServer) Run(ctx context.Context) error {
+	t := time.NewTicker(time.Second / TicksPerSecond)
+	defer t.Stop()
+	for !s.Over() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			s.Step()
+		}
+	}
+	return nil
+}
+
+// Over reports whether the run has ended and the server is done with it.
+func (s *NetServer) Over() bool {
+	return s.ended > NetLinger
+}
+
+// Close stops the server listening.
+func (s *NetServer) Close() error {
+	return s.conn.Close()
+}
+
+// Step handles the packets that have arrived, advances the run by one
+// frame once everyone has joined, and sends snapshots.
+func (s *NetServer) Step() {
+	s.steps++
+	for len(s.packets) > 0 {
+		s.handle(<-s.packets)
+	}
+	g := s.game
+	if len(s.peers) < len(g.players) {
+		return
+	}
+	if !g.running {
+		s.ended++
+	} else if g.camera.Update() {
+		// The camera's hit-stops and slow motion hold the run back as they
+		// do for a local one; inputs wait until it moves on.
+		in := make([]Input, len(s.peers))
+		for i, p := range s.peers {
+			in[i] = s.next(p)
+		}
+		g.updatePlaying(in...)
+		s.Broadcast.Record(g)
+	}
+	state := g.netState()
+	s.history[state.Tick%NetHistory] = state
+	if g.tick%NetSnapshotInterval == 0 || !g.running {
+		for _, p := range s.peers {
+			s.sendSnapshot(p, &state)
+		}
+	}
+}
+
+// next returns p's input for this step. When it has not arrived the last
+// one is held, as if the keys had not changed; a client gone quiet lets
+// go of everything. Every packet repeats the inputs before it, so a
+// newer one here without the next means a burst of packets was lost:
+// those inputs are skipped rather than waited for.
+func (s *NetServer) next(p *netPeer) Input {
+	if s.steps-p.heard > NetTimeout {
+		p.last = 0
+	}
+	seq := p.applied + 1
+	if _, ok := p.inputs[seq]; !ok && len(p.inputs) > 0 {
+		seq = slices.Min(slices.Collect(maps.Keys(p.inputs)))
+	}
+	if in, ok := p.inputs[seq]; ok {
+		delete(p.inputs, seq)
+		p.applied = seq
+		p.last = in
+	}
+	return p.last
+}
+
+func (s *NetServer) handle(pkt netPacket) {
+	r := netReader{b: pkt.data}
+	switch r.byte() {
+	case msgHello:
+		version := r.uvarint()
+		name := string(r.bytes())
+		if r.err != nil {
+			return
+		}
+		if version != NetVersion {
+			s.reject(pkt.addr, rejectVersion)
+			return
+		}
+		i := s.peer(pkt.addr)
+		if i < 0 {
+			if len(s.peers) == len(s.game.players) {
+				s.reject(pkt.addr, rejectFull)
+				return
+			}
+			i = len(s.peers)
+			s.peers = append(s.peers, &netPeer{addr: pkt.addr, name: name, inputs: map[uint32]Input{}})
+			log.Printf("netplay: %s joined from %s as player %d", name, pkt.addr, i+1)
+		}
+		s.peers[i].heard = s.steps
+		// Welcome again on a repeated hello: the first welcome was lost.
+		cfg, _ := json.Marshal(s.game.config)
+		s.send(pkt.addr, appendBytes([]byte{msgWelcome, byte(i)}, cfg))
+	case msgInput:
+		i := s.peer(pkt.addr)
+		if i < 0 {
+			return
+		}
+		p := s.peers[i]
+		acked := uint32(r.uvarint())
+		newest := uint32(r.uvarint())
+		n := uint32(r.byte())
+		inputs := r.b
+		if r.err != nil || uint32(len(inputs)) < n || n > newest {
+			return
+		}
+		if newest > p.applied+NetHistory {
+			// Too far behind to catch up: give up on the inputs between.
+			p.applied = newest - NetHistory
+			maps.DeleteFunc(p.inputs, func(seq uint32, _ Input) bool { return seq <= p.applied })
+		}
+		for j := range n {
+			seq := newest - n + 1 + j
+			if seq > p.applied {
+				p.inputs[seq] = Input(inputs[j])
+			}
+		}
+		if acked > p.acked && acked <= uint32(s.game.tick) {
+			p.acked = acked
+		}
+		p.heard = s.steps
+	}
+}
+
+// peer returns the index of the client at addr, or -1.
+func (s *NetServer) peer(addr net.Addr) int {
+	for i, p := range s.peers {
+		if p.addr.String() == addr.String() {
+			return i
+		}
+	}
+	return -1
+}
+
+// sendSnapshot sends state to p as a delta against the newest snapshot p
+// has, or whole if that one is no longer kept.
+func (s *NetServer) sendSnapshot(p *netPeer, state *netState) {
+	base := &netState{}
+	if b := &s.history[p.acked%NetHistory]; p.acked > 0 && b.Tick == p.acked {
+		base = b
+	}
+	pkt := binary.AppendUvarint([]byte{msgSnapshot}, uint64(p.applied))
+	pkt = binary.AppendUvarint(pkt, uint64(base.Tick))
+	s.send(p.addr, state.appendDelta(pkt, base))
+}
+
+func (s *NetServer) reject(addr net.Addr, reason string) {
+	s.send(addr, appendBytes([]byte{msgReject}, []byte(reason)))
+}
+
+func (s *NetServer) send(addr net.Addr, pkt []byte) {
+	if _, err := s.conn.WriteTo(pkt, addr); err != nil {
+		log.Printf("netplay: %v", err)
+	}
+}
+
+// hostNetplay runs a server for one run on addr, simulated with the
+// built-in assets, and returns the exit status. The run is broadcast to
+// spectators with b, if it is not nil.
+func hostNetplay(addr string, cfg RunConfig, b *Broadcaster) int {
+	assets, err := NewAssetStore()
+	if err == nil {
+		var conn net.PacketConn
+		if conn, err = net.ListenPacket("udp", addr); err == nil {
+			s := NewNetServer(assets, conn, cfg)
+			defer s.Close()
+			log.Printf("netplay: hosting a %d player %s run on %s", cfg.playerCount(), cfg.Mode, conn.LocalAddr())
+			if err = s.Run(context.Background()); err == nil {
+				log.Printf("netplay: the run is over, %d points", s.game.score)
+				return 0
+			}
+		}
+	}
+	log.Print(err)
+	return 1
+}
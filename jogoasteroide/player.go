@@ -8,6 +8,9 @@ import (
 )
 
 type Player struct {
+	index          int // position in Game.players
+	score          int // points from this ship's bullets
+	out            bool
 	position       Vector
 	velocity       Vector
 	acceleration   Vector
@@ -22,14 +25,14 @@ type Player struct {
 	multiShot      int
 }
 
-func (p *Player) Update() {
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		p.angle -= 0.09
+func (p *Player) Update(in Input) {
+	if in.Has(ActionRotateLeft) {
+		p.angle -= PlayerTurnRate
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyRight) {
-		p.angle += 0.09
+	if in.Has(ActionRotateRight) {
+		p.angle += PlayerTurnRate
 	}
-	p.isAccelerating = ebiten.IsKeyPressed(ebiten.KeyUp)
+	p.isAccelerating = in.Has(ActionThrust)
 	if p.isAccelerating {
 		p.acceleration = Vector{X: math.Sin(p.angle) * PlayerAccel, Y: -math.Cos(p.angle) * PlayerAccel}
 	} else {
@@ -75,9 +78,13 @@ func (p *Player) Update() {
 	}
 }
 
-func (p *Player) Draw(screen *ebiten.Image) {
+// Draw draws the ship, tinted with tint unless it is nil.
+func (p *Player) Draw(screen *ebiten.Image, tint color.Color) {
 	sf := 64.0 / float64(p.img.Bounds().Dx())
 	op := &ebiten.DrawImageOptions{}
+	if tint != nil {
+		op.ColorScale.ScaleWithColor(tint)
+	}
 	op.GeoM.Translate(-float64(p.img.Bounds().Dx())/2, -float64(p.img.Bounds().Dy())/2)
 	op.GeoM.Rotate(p.angle)
 	op.GeoM.Scale(sf, sf)
@@ -87,21 +94,19 @@ func (p *Player) Draw(screen *ebiten.Image) {
 	if p.isAccelerating {
 		// Draw thruster flame
 		op := &ebiten.DrawImageOptions{}
-		r := 8.0
+		r := float64(ImgThrust.Bounds().Dx()) / 2
 		op.GeoM.Translate(-r, -r)
 		op.GeoM.Rotate(p.angle)
 		op.GeoM.Translate(p.position.X-math.Sin(p.angle)*35, p.position.Y+math.Cos(p.angle)*35)
-		img := generateCircleImage(int(r*2), color.RGBA{255, 165, 0, 200})
-		screen.DrawImage(img, op)
+		screen.DrawImage(ImgThrust, op)
 	}
 
 	if p.shield > 0 {
 		// Draw shield
 		op := &ebiten.DrawImageOptions{}
-		r := p.width/2 + 10
+		r := float64(ImgShield.Bounds().Dx()) / 2
 		op.GeoM.Translate(-r, -r)
 		op.GeoM.Translate(p.position.X, p.position.Y)
-		img := generateCircleImage(int(r*2), color.RGBA{0, 255, 255, 128})
-		screen.DrawImage(img, op)
+		screen.DrawImage(ImgShield, op)
 	}
 }
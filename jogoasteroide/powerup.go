@@ -1,10 +1,6 @@
 package main
 
-import (
-	"image/color"
-
-	"github.com/hajimehoshi/ebiten/v2"
-)
+import "github.com/hajimehoshi/ebiten/v2"
 
 type PowerUpType int
 
@@ -13,11 +9,19 @@ const (
 	PowerUpRapidFire
 	PowerUpMultiShot
 	PowerUpExtraLife
+	powerUpCount
 )
 
+var powerUpNames = [powerUpCount]string{"shield", "rapid_fire", "multi_shot", "extra_life"}
+
+func (t PowerUpType) String() string {
+	return powerUpNames[t]
+}
+
 type PowerUp struct {
-	position Vector
-	velocity Vector
+	id        uint32
+	position  Vector
+	velocity  Vector
 	powerType PowerUpType
 	size      float64
 	age       int
@@ -43,21 +47,9 @@ func (p *PowerUp) Update() {
 }
 
 func (p *PowerUp) Draw(screen *ebiten.Image) {
-	var col color.RGBA
-	switch p.powerType {
-	case PowerUpShield:
-		col = color.RGBA{0, 255, 255, 255} // Cyan
-	case PowerUpRapidFire:
-		col = color.RGBA{255, 255, 0, 255} // Yellow
-	case PowerUpMultiShot:
-		col = color.RGBA{255, 0, 255, 255} // Magenta
-	case PowerUpExtraLife:
-		col = color.RGBA{0, 255, 0, 255} // Green
-	}
-	img := generateCircleImage(int(p.size), col)
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(p.position.X-p.size/2, p.position.Y-p.size/2)
-	screen.DrawImage(img, op)
+	op.GeoM.Translate(p.position.X-PowerUpGlyphSize/2, p.position.Y-PowerUpGlyphSize/2)
+	screen.DrawImage(ImgPowerUps[p.powerType], op)
 }
 
 func (p *PowerUp) IsExpired() bool {
@@ -65,12 +57,12 @@ func (p *PowerUp) IsExpired() bool {
 }
 
 type PowerUpPool struct {
-	pool []PowerUp
+	pool []*PowerUp
 }
 
 func (p *PowerUpPool) Get() *PowerUp {
 	if len(p.pool) > 0 {
-		pw := &p.pool[len(p.pool)-1]
+		pw := p.pool[len(p.pool)-1]
 		p.pool = p.pool[:len(p.pool)-1]
 		return pw
 	}
@@ -79,5 +71,5 @@ func (p *PowerUpPool) Get() *PowerUp {
 
 func (p *PowerUpPool) Put(pw *PowerUp) {
 	*pw = PowerUp{} // reset
-	p.pool = append(p.pool, *pw)
+	p.pool = append(p.pool, pw)
 }
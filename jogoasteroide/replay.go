@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReplayVersion is the replay format written by this build. A replay only
+// reproduces its run on a build whose simulation matches, so other
+// versions are refused.
+const ReplayVersion = 1
+
+// Run modes.
+const (
+	ModeClassic = "classic"
+	ModeDaily   = "daily"
+	ModeVersus  = "versus"
+	// ModeTutorial spawns nothing by itself: the tutorial steps do.
+	ModeTutorial = "tutorial"
+)
+
+// Modifier changes the rules of a run.
+type Modifier int
+
+const (
+	ModNoPowerUps  Modifier = iota // no power-ups spawn
+	ModDoubleSpeed                 // asteroids move twice as fast
+	ModGlassCannon                 // the ship survives a single hit
+	ModSwarm                       // half as many asteroids again
+	modifierCount
+)
+
+var modifierNames = [modifierCount]string{"no_power_ups", "double_speed", "glass_cannon", "swarm"}
+
+func (m Modifier) String() string {
+	return modifierNames[m]
+}
+
+// Modifiers is a set of Modifier. It is written to JSON as a list of
+// names.
+type Modifiers uint8
+
+// Has reports whether m is in the set.
+func (s Modifiers) Has(m Modifier) bool {
+	return s&(1<<m) != 0
+}
+
+// With returns the set with m added.
+func (s Modifiers) With(m Modifier) Modifiers {
+	return s | 1<<m
+}
+
+// List returns the modifiers in the set, in order.
+func (s Modifiers) List() []Modifier {
+	var list []Modifier
+	for m := Modifier(0); m < modifierCount; m++ {
+		if s.Has(m) {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+func (s Modifiers) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	for _, m := range s.List() {
+		names = append(names, m.String())
+	}
+	return json.Marshal(names)
+}
+
+func (s *Modifiers) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*s = 0
+	for _, name := range names {
+		m := Modifier(indexOf(modifierNames[:], name))
+		if modifierNames[m] != name {
+			return fmt.Errorf("unknown modifier %q", name)
+		}
+		*s = s.With(m)
+	}
+	return nil
+}
+
+// RunConfig fixes everything that shapes a run apart from the player's
+// input: with the same config and inputs, a run plays out the same way.
+type RunConfig struct {
+	Mode       string     `json:"mode"`
+	Date       string     `json:"date,omitempty"` // the day of a daily challenge, YYYY-MM-DD
+	Seed       uint64     `json:"seed"`
+	Difficulty Difficulty `json:"difficulty"`
+	Modifiers  Modifiers  `json:"modifiers"`
+	// Players is how many ships take part; 0 means 1. Replays hold one
+	// input per player for every step, in player order.
+	Players      int  `json:"players,omitempty"`
+	SharedLives  bool `json:"shared_lives,omitempty"`
+	FriendlyFire bool `json:"friendly_fire,omitempty"`
+	// Rounds is the length of a versus match: best of Rounds.
+	Rounds int `json:"rounds,omitempty"`
+	// CPU is the skill of the bot playing the last ship, if one does.
+	CPU string `json:"cpu,omitempty"`
+}
+
+// preset returns the difficulty values adjusted by the modifiers.
+func (c RunConfig) preset() DifficultyPreset {
+	p := c.Difficulty.Preset()
+	if c.Mode == ModeTutorial {
+		p.StartAsteroids, p.PowerUpInterval = 0, 0
+	}
+	if c.Modifiers.Has(ModNoPowerUps) {
+		p.PowerUpInterval = 0
+	}
+	if c.Modifiers.Has(ModDoubleSpeed) {
+		p.AsteroidSpeed *= 2
+	}
+	if c.Modifiers.Has(ModGlassCannon) {
+		p.Health = 1
+	}
+	if c.Modifiers.Has(ModSwarm) {
+		p.StartAsteroids += p.StartAsteroids / 2
+	}
+	return p
+}
+
+// Replay is a run's config and the input of every simulation step.
+type Replay struct {
+	Version int       `json:"version"`
+	Config  RunConfig `json:"config"`
+	Inputs  []Input   `json:"inputs"`
+}
+
+// replay returns the replay of the current run so far.
+func (g *Game) replay() Replay {
+	return Replay{Version: ReplayVersion, Config: g.config, Inputs: append([]Input(nil), g.inputs...)}
+}
+
+// SimResult is the outcome of re-simulating a replay.
+type SimResult struct {
+	Score int
+	Ticks int
+	Over  bool // the ship was destroyed
+}
+
+// newSimGame returns a game with no window, sound or files, for
+// re-simulating replays.
+func newSimGame(assets *AssetStore) *Game {
+	g := &Game{
+		assets:   assets,
+		headless: true,
+		settings: DefaultSettings(),
+		playerW:  PlayerWidth,
+		playerH:  PlayerHeight,
+	}
+	g.loc = NewLocalizer(assets, DefaultLanguage)
+	g.camera = NewCamera(&g.settings.Effects)
+	g.mixer = newSilentMixer(&g.settings.Audio)
+	return g
+}
+
+// Simulate replays r without drawing anything. The camera is never
+// advanced, so the slow-motion death keeps the run going until the
+// replay's inputs run out, as it did while the run was played.
+func Simulate(assets *AssetStore, r Replay) (SimResult, error) {
+	if r.Version != ReplayVersion {
+		return SimResult{}, fmt.Errorf("replay version %d (this game reads %d)", r.Version, ReplayVersion)
+	}
+	if r.Config.Mode != ModeClassic && r.Config.Mode != ModeDaily && r.Config.Mode != ModeVersus {
+		return SimResult{}, fmt.Errorf("unknown mode %q", r.Config.Mode)
+	}
+	n := r.Config.playerCount()
+	if len(r.Inputs)%n != 0 {
+		return SimResult{}, fmt.Errorf("%d inputs do not split between %d players", len(r.Inputs), n)
+	}
+	g := newSimGame(assets)
+	g.ResetRun(r.Config)
+	for i := 0; i < len(r.Inputs); i += n {
+		if !g.running {
+			return SimResult{}, fmt.Errorf("input %d comes after the end of the run", i)
+		}
+		g.updatePlaying(r.Inputs[i : i+n]...)
+	}
+	return SimResult{Score: g.score, Ticks: g.tick, Over: g.dying || !g.running}, nil
+}
+
+// ResultVersion is the result file format written by this build.
+const ResultVersion = 1
+
+// RunResult is a finished run as shared with others: the claimed score
+// and the replay that proves it.
+type RunResult struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	Score   int    `json:"score"`
+	Replay  Replay `json:"replay"`
+}
+
+// ErrResultMismatch is returned when a result's replay does not produce
+// the score it claims.
+var ErrResultMismatch = errors.New("replay does not match the claimed result")
+
+// Verify re-simulates the result's replay and checks that it ends the run
+// with the claimed score. A daily challenge must also have that day's
+// seed and modifiers.
+func Verify(assets *AssetStore, r RunResult) error {
+	if r.Version != ResultVersion {
+		return fmt.Errorf("result version %d (this game reads %d)", r.Version, ResultVersion)
+	}
+	if c := r.Replay.Config; c.Mode == ModeDaily && c != DailyConfig(c.Date) {
+		return fmt.Errorf("%w: not the daily challenge of %q", ErrResultMismatch, c.Date)
+	}
+	sim, err := Simulate(assets, r.Replay)
+	if err != nil {
+		return err
+	}
+	if !sim.Over {
+		return fmt.Errorf("%w: the run did not end", ErrResultMismatch)
+	}
+	if sim.Score != r.Score {
+		return fmt.Errorf("%w: score %d, replay scores %d", ErrResultMismatch, r.Score, sim.Score)
+	}
+	return nil
+}
+
+// SaveResult writes r to path.
+func SaveResult(path string, r RunResult) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadResult reads a result file.
+func LoadResult(path string) (RunResult, error) {
+	var r RunResult
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("%s: %v", path, err)
+	}
+	return r, nil
+}
+
+// modifierNamesText lists the modifiers in the current language.
+func (g *Game) modifierNamesText(s Modifiers) string {
+	var names []string
+	for _, m := range s.List() {
+		names = append(names, g.loc.T("modifier."+m.String()))
+	}
+	if len(names) == 0 {
+		return g.loc.T("modifier.none")
+	}
+	return strings.Join(names, ", ")
+}
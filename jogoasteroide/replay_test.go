@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestModifiersJSON(t *testing.T) {
+	mods := Modifiers(0).With(ModNoPowerUps).With(ModSwarm)
+	data, err := json.Marshal(mods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["no_power_ups","swarm"]` {
+		t.Errorf("json = %s; esperado a lista de nomes", data)
+	}
+	var read Modifiers
+	if err := json.Unmarshal(data, &read); err != nil || read != mods {
+		t.Errorf("lido %v (%v); esperado %v", read.List(), err, mods.List())
+	}
+	if err := json.Unmarshal([]byte(`["no_gravity"]`), &read); err == nil {
+		t.Error("modificador desconhecido aceito")
+	}
+}
+
+func TestNoPowerUpsModifier(t *testing.T) {
+	g, _ := newTestGame()
+	g.ResetRun(RunConfig{Mode: ModeClassic, Seed: 1, Modifiers: Modifiers(0).With(ModNoPowerUps)})
+	g.players[0].health = 1000
+	for i := 0; i < 2000; i++ {
+		g.updatePlaying(script(i))
+	}
+	if len(g.powerUps) != 0 {
+		t.Errorf("%d power-ups apareceram; esperado nenhum", len(g.powerUps))
+	}
+}
+
+func TestDailyConfig(t *testing.T) {
+	a, b := DailyConfig("2024-05-01"), DailyConfig("2024-05-01")
+	if a != b {
+		t.Errorf("mesma data deu %+v e %+v", a, b)
+	}
+	if n := len(a.Modifiers.List()); n < 1 || n > 2 {
+		t.Errorf("%d modificadores; esperado 1 ou 2", n)
+	}
+	if c := DailyConfig("2024-05-02"); c.Seed == a.Seed {
+		t.Error("datas diferentes deram a mesma semente")
+	}
+}
+
+// playDaily plays today's challenge with the test script until the ship
+// is destroyed.
+func playDaily(t *testing.T, g *Game) {
+	t.Helper()
+	if !g.startDaily() {
+		t.Fatal("startDaily() = false; esperado o desafio de hoje")
+	}
+	finishRun(t, g)
+}
+
+// finishRun plays the current run with the test script until the ship is
+// destroyed.
+func finishRun(t *testing.T, g *Game) {
+	t.Helper()
+	for i := 0; !g.dying; i++ {
+		if i == 50000 {
+			t.Fatal("a nave não foi destruída")
+		}
+		g.updatePlaying(script(i))
+	}
+	g.endRun()
+}
+
+func TestDailyResultVerifies(t *testing.T) {
+	g, _ := newTestGame()
+	g.dailyPath = filepath.Join(t.TempDir(), "daily.json")
+	playDaily(t, g)
+
+	e := g.daily.Entries[today()]
+	if e == nil || !e.Finished || e.Result == "" {
+		t.Fatalf("registro = %+v; esperado o desafio concluído com resultado", e)
+	}
+	r, err := LoadResult(e.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Score != g.score || e.Score != g.score {
+		t.Errorf("pontos %d e %d; esperado %d", r.Score, e.Score, g.score)
+	}
+	if err := Verify(g.assets, r); err != nil {
+		t.Fatalf("Verify() = %v; esperado nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(r *RunResult)
+	}{
+		{"pontos alterados", func(r *RunResult) { r.Score += 10 }},
+		{"partida cortada", func(r *RunResult) { r.Replay.Inputs = r.Replay.Inputs[:len(r.Replay.Inputs)/2] }},
+		{"outra semente", func(r *RunResult) { r.Replay.Config.Seed++ }},
+		{"sem modificadores", func(r *RunResult) { r.Replay.Config.Modifiers = 0 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bad := r
+			bad.Replay.Inputs = append([]Input(nil), r.Replay.Inputs...)
+			tt.tamper(&bad)
+			if err := Verify(g.assets, bad); !errors.Is(err, ErrResultMismatch) {
+				t.Errorf("Verify() = %v; esperado ErrResultMismatch", err)
+			}
+		})
+	}
+}
+
+func TestDailyOneAttempt(t *testing.T) {
+	g, _ := newTestGame()
+	g.dailyPath = filepath.Join(t.TempDir(), "daily.json")
+	if !g.startDaily() {
+		t.Fatal("startDaily() = false; esperado a primeira tentativa")
+	}
+	// Quitting does not give the attempt back, in this game or the next.
+	g.quitToMenu()
+	if g.startDaily() {
+		t.Error("segunda tentativa aceita")
+	}
+	log, err := LoadDaily(g.dailyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := newTestGame()
+	h.daily = log
+	if h.startDaily() {
+		t.Error("segunda tentativa aceita depois de reabrir o jogo")
+	}
+	if len(log.Board(DailyBoardSize)) != 0 {
+		t.Error("tentativa não concluída entrou no placar")
+	}
+}
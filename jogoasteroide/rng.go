@@ -0,0 +1,27 @@
+package main
+
+import "math/rand/v2"
+
+// RNG is the random number generator used by a run. Unlike the global
+// source its state can be saved and restored, so a resumed run carries on
+// exactly as it would have.
+type RNG struct {
+	*rand.Rand
+	src *rand.PCG
+}
+
+// NewRNG returns a generator seeded with seed.
+func NewRNG(seed uint64) *RNG {
+	src := rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)
+	return &RNG{Rand: rand.New(src), src: src}
+}
+
+// MarshalBinary returns the generator state.
+func (r *RNG) MarshalBinary() ([]byte, error) {
+	return r.src.MarshalBinary()
+}
+
+// UnmarshalBinary restores a state returned by MarshalBinary.
+func (r *RNG) UnmarshalBinary(data []byte) error {
+	return r.src.UnmarshalBinary(data)
+}
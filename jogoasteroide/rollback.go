@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"net"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// RollbackWindow is how many steps a peer may run ahead of the last
+	// input it has from the other, predicting the rest. Past it the run
+	// waits for the other peer.
+	RollbackWindow = 12
+	// ChecksumInterval is how often, in steps, the peers compare the state
+	// of the run to catch a desync.
+	ChecksumInterval = 30
+	// DefaultInputDelay is how many steps a keypress waits before it
+	// takes effect, giving it time to reach the other peer so fewer steps
+	// are rolled back.
+	DefaultInputDelay = 2
+	// MaxInputDelay bounds the input delay setting.
+	MaxInputDelay = 8
+)
+
+// Peer-to-peer packet types, after the client/server ones.
+const (
+	msgPeerHello byte = iota + 16 // version, player, run config (player 1 only)
+	msgPeerInput                  // first step, inputs, newest step heard, checksum step and value
+)
+
+// RollbackSession plays a two-player run peer to peer. Both peers
+// simulate the whole run. Each step uses the local input and a prediction
+// of the other peer's (the last one heard); when the real input turns out
+// different, the run is put back to the state before that step and played
+// forward again. Runs are deterministic, so the peers stay in step, and
+// they compare state checksums to make sure.
+type RollbackSession struct {
+	game     *Game
+	Local    int       // this peer's player, 0 or 1
+	Config   RunConfig // of the run, once agreed
+	Rejected string    // why the other peer could not play, if so
+	Desync   int       // the first step whose checksums differed, 0 if none
+	// Rollbacks counts the steps played again after a misprediction.
+	Rollbacks int
+
+	delay   int
+	conn    net.PacketConn
+	peer    net.Addr
+	packets chan netPacket
+	frames  int
+	heard   int  // frame the peer was last heard from
+	started bool // the run is set up
+	joined  bool // the peer has started too
+
+	local   []Input // by step; [0] is unused
+	remote  []Input // every step's input from the peer heard so far
+	used    []Input // the peer input each step was played with
+	states  [RollbackWindow + 2]rollbackState
+	sums    map[int]uint32 // checksums after steps, final once confirmed
+	theirs  map[int]uint32 // the peer's final checksums
+	peerHas int            // the newest local step the peer has heard
+	// Sound and camera to swap in while steps are played again.
+	quietMixer  *Mixer
+	quietCamera *Camera
+	stopAt      int // a step not to play past, for tests; 0 for none
+}
+
+// rollbackState is the run as it was before a step.
+type rollbackState struct {
+	step   int
+	inputs int // the run's inputs then, a prefix of the current ones
+	save   SaveFile
+}
+
+// NewRollbackSession starts playing with the peer at addr over conn as
+// player local (0 or 1). Player 0 chooses the run, cfg; player 1 plays
+// whatever run player 0 sends. Both draw and play sound through g.
+func NewRollbackSession(g *Game, conn net.PacketConn, addr string, local int, cfg RunConfig, delay int) (*RollbackSession, error) {
+	peer, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Players, cfg.CPU = 2, ""
+	g.local, g.lockstep = local, true
+	s := &RollbackSession{
+		game:        g,
+		Local:       local,
+		Config:      cfg,
+		delay:       max(0, min(delay, MaxInputDelay)),
+		conn:        conn,
+		peer:        peer,
+		packets:     make(chan netPacket, 256),
+		sums:        map[int]uint32{},
+		theirs:      map[int]uint32{},
+		quietMixer:  newSilentMixer(&AudioSettings{}),
+		quietCamera: NewCamera(&EffectsSettings{}),
+	}
+	go readPackets(conn, s.packets)
+	return s, nil
+}
+
+// Close leaves the session.
+func (s *RollbackSession) Close() error {
+	return s.conn.Close()
+}
+
+// Game is the run being played.
+func (s *RollbackSession) Game() *Game {
+	return s.game
+}
+
+// Started reports whether both peers have agreed on the run.
+func (s *RollbackSession) Started() bool {
+	return s.started
+}
+
+// Step is the newest step simulated.
+func (s *RollbackSession) Step() int {
+	return len(s.used) - 1
+}
+
+// confirmed is the newest step with the peer's input known.
+func (s *RollbackSession) confirmed() int {
+	return len(s.remote) - 1
+}
+
+// Waiting reports whether the run is held up for the peer's input.
+func (s *RollbackSession) Waiting() bool {
+	return s.started && s.Step()-s.confirmed() >= RollbackWindow
+}
+
+// Over reports whether the run has ended, as both peers agree.
+func (s *RollbackSession) Over() bool {
+	g := s.game
+	return s.started && (!g.running || g.dying) && s.confirmed() >= s.Step()
+}
+
+// Lost reports whether the peer has gone quiet.
+func (s *RollbackSession) Lost() bool {
+	return s.frames-s.heard > NetTimeout
+}
+
+// Update handles the packets that have arrived and, unless the run is
+// waiting for the peer, plays the next step with in as the local input.
+func (s *RollbackSession) Update(in Input) {
+	s.frames++
+	for len(s.packets) > 0 {
+		s.handle(<-s.packets)
+	}
+	if !s.joined && s.frames%NetRetryFrames == 1 {
+		pkt := binary.AppendUvarint([]byte{msgPeerHello}, NetVersion)
+		pkt = append(pkt, byte(s.Local))
+		var cfg []byte
+		if s.Local == 0 {
+			cfg, _ = json.Marshal(s.Config)
+		}
+		s.send(appendBytes(pkt, cfg))
+	}
+	if !s.started || s.Rejected != "" {
+		return
+	}
+	g := s.game
+	if s.Desync == 0 && g.running && !g.dying && !s.Waiting() && (s.stopAt == 0 || s.Step() < s.stopAt) {
+		s.local = append(s.local, in)
+		s.advance(s.Step() + 1)
+	}
+	s.sendInputs()
+}
+
+// start sets the run up once the peers agree on it.
+func (s *RollbackSession) start() {
+	s.started = true
+	s.game.ResetRun(s.Config)
+	// The first steps have no local input: the delay pushes the first
+	// keypress to the step after them.
+	s.local = make([]Input, 1+s.delay)
+	s.remote = make([]Input, 1)
+	s.used = make([]Input, 1)
+}
+
+// advance plays step n, after saving the state before it.
+func (s *RollbackSession) advance(n int) {
+	g := s.game
+	st, err := g.state()
+	if err != nil {
+		log.Printf("rollback: %v", err)
+	}
+	s.states[n%len(s.states)] = rollbackState{n, len(g.inputs), st}
+	remote := s.remote[len(s.remote)-1]
+	if n < len(s.remote) {
+		remote = s.remote[n]
+	}
+	if n < len(s.used) {
+		s.used[n] = remote
+	} else {
+		s.used = append(s.used, remote)
+	}
+	in := []Input{s.local[n], remote}
+	if s.Local == 1 {
+		in[0], in[1] = in[1], in[0]
+	}
+	g.updatePlaying(in...)
+	if n%ChecksumInterval == 0 {
+		s.sums[n] = g.checksum()
+	}
+}
+
+// rollback puts the run back to before step n and plays it forward again
+// to the newest step, or until it ends, quietly: its sounds and shakes
+// were had the first time.
+func (s *RollbackSession) rollback(n int) {
+	g := s.game
+	st := s.states[n%len(s.states)]
+	if st.step != n {
+		log.Printf("rollback: no state kept for step %d", n)
+		return
+	}
+	mixer, camera := g.mixer, g.camera
+	g.mixer, g.camera = s.quietMixer, s.quietCamera
+	defer func() { g.mixer, g.camera = mixer, camera }()
+	newest := s.Step()
+	st.save.Inputs = g.inputs[:st.inputs]
+	if err := g.restore(st.save); err != nil {
+		log.Printf("rollback: %v", err)
+		return
+	}
+	s.used = s.used[:n]
+	for i := n; i <= newest && g.running && !g.dying; i++ {
+		s.advance(i)
+		s.Rollbacks++
+	}
+}
+
+func (s *RollbackSession) handle(pkt netPacket) {
+	if pkt.addr.String() != s.peer.String() {
+		return
+	}
+	s.heard = s.frames
+	r := netReader{b: pkt.data}
+	switch r.byte() {
+	case msgPeerHello:
+		version := r.uvarint()
+		player := int(r.byte())
+		cfg := r.bytes()
+		switch {
+		case r.err != nil || s.started:
+		case version != NetVersion:
+			s.Rejected = rejectVersion
+		case player == s.Local:
+			s.Rejected = rejectSamePlayer
+		case s.Local == 1:
+			var c RunConfig
+			if err := json.Unmarshal(cfg, &c); err != nil {
+				return
+			}
+			c.Players, c.CPU = 2, ""
+			s.Config = c
+			s.start()
+		default:
+			s.start()
+		}
+	case msgPeerInput:
+		if !s.started {
+			return
+		}
+		s.joined = true
+		first := int(r.uvarint())
+		inputs := r.bytes()
+		has := int(r.uvarint())
+		sumStep := int(r.uvarint())
+		sum := r.uint32()
+		if r.err != nil {
+			return
+		}
+		s.peerHas = max(s.peerHas, min(has, len(s.local)-1))
+		if sumStep > 0 {
+			s.theirs[sumStep] = sum
+		}
+		// Take the inputs that are new, and play again from the first
+		// step that was predicted wrong.
+		wrong := 0
+		for i, in := range inputs {
+			n := first + i
+			if n != len(s.remote) {
+				continue
+			}
+			s.remote = append(s.remote, Input(in))
+			if wrong == 0 && n < len(s.used) && s.used[n] != Input(in) {
+				wrong = n
+			}
+		}
+		if wrong > 0 {
+			s.rollback(wrong)
+		}
+		s.compareChecksums()
+	}
+}
+
+// compareChecksums checks the peer's checksums against the final local
+// ones.
+func (s *RollbackSession) compareChecksums() {
+	final := min(s.confirmed(), s.Step())
+	for n, sum := range s.theirs {
+		if n > final {
+			continue
+		}
+		if mine, ok := s.sums[n]; ok && mine != sum && (s.Desync == 0 || n < s.Desync) {
+			s.Desync = n
+			log.Printf("rollback: desync at step %d", n)
+		}
+		delete(s.theirs, n)
+	}
+}
+
+// sendInputs sends the local inputs the peer has not confirmed, with the
+// newest final checksum.
+func (s *RollbackSession) sendInputs() {
+	first := s.peerHas + 1
+	pkt := binary.AppendUvarint([]byte{msgPeerInput}, uint64(first))
+	pkt = appendBytes(pkt, s.bytesOf(s.local[first:]))
+	pkt = binary.AppendUvarint(pkt, uint64(s.confirmed()))
+	n := min(s.confirmed(), s.Step()) / ChecksumInterval * ChecksumInterval
+	pkt = binary.AppendUvarint(pkt, uint64(n))
+	pkt = binary.LittleEndian.AppendUint32(pkt, s.sums[n])
+	s.send(pkt)
+}
+
+func (s *RollbackSession) bytesOf(in []Input) []byte {
+	b := make([]byte, len(in))
+	for i, x := range in {
+		b[i] = byte(x)
+	}
+	return b
+}
+
+func (s *RollbackSession) send(pkt []byte) {
+	s.conn.WriteTo(pkt, s.peer)
+}
+
+// rejectSamePlayer is why two peers that both want the same ship cannot
+// play.
+const rejectSamePlayer = "same_player"
+
+// checksum hashes everything that shapes the rest of the run. The message
+// is left out: it is in each player's own language.
+func (g *Game) checksum() uint32 {
+	st, err := g.state()
+	if err != nil {
+		return 0
+	}
+	st.Message = ""
+	data, _ := json.Marshal(st)
+	h := fnv.New32a()
+	h.Write(data)
+	return h.Sum32()
+}
+
+// mirrorGame returns a game for a run driven from elsewhere, by a
+// peer-to-peer session or a broadcast: a headless one, so the run's end is
+// left to its driver, that looks and sounds like g.
+func (g *Game) mirrorGame() *Game {
+	p := newSimGame(g.assets)
+	p.fonts, p.theme, p.loc, p.mixer = g.fonts, g.theme, g.loc, g.mixer
+	p.camera = NewCamera(&g.settings.Effects)
+	p.highScore = g.highScore
+	return p
+}
+
+// RollbackScene plays a peer-to-peer run.
+type RollbackScene struct {
+	g *Game
+	s *RollbackSession
+}
+
+// playPeer starts a peer-to-peer run with the peer at addr, listening on
+// laddr as player local (0 or 1). Player 0's run config is played.
+func (g *Game) playPeer(laddr, addr string, local int, cfg RunConfig) error {
+	conn, err := net.ListenPacket("udp", laddr)
+	if err != nil {
+		return err
+	}
+	s, err := NewRollbackSession(g.mirrorGame(), conn, addr, local, cfg, g.settings.InputDelay)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	g.scenes.Reset(&RollbackScene{g: g, s: s}, Fade)
+	return nil
+}
+
+func (r *RollbackScene) Update() error {
+	g := r.g
+	if g.settings.Bindings.JustPressed(ActionPause) || g.nav.Back {
+		r.s.Close()
+		g.quitToMenu()
+		return nil
+	}
+	// Each peer uses the main keys and the first gamepad.
+	in := g.settings.Bindings.Read()
+	if pads := ebiten.AppendGamepadIDs(nil); len(pads) > 0 {
+		slices.Sort(pads)
+		in |= readGamepad(pads[0])
+	}
+	r.s.Update(in)
+	r.s.game.camera.Update()
+	return nil
+}
+
+func (r *RollbackScene) Draw(screen *ebiten.Image) {
+	g, s := r.g, r.s
+	if s.Started() {
+		s.game.drawPlaying(screen)
+	}
+	var status string
+	switch {
+	case s.Rejected != "":
+		status = g.loc.T("net.rejected_" + s.Rejected)
+	case s.Desync > 0:
+		status = g.loc.T("net.desync", s.Desync)
+	case s.Lost():
+		status = g.loc.T("net.lost")
+	case !s.Started():
+		status = g.loc.T("net.connecting", s.peer)
+	case s.Over():
+		status = g.loc.T("net.over")
+	case s.Waiting():
+		status = g.loc.T("net.peer_waiting")
+	default:
+		return
+	}
+	drawAnchoredText(screen, status+"\n"+g.loc.T("net.leave"), g.fonts.Normal, AnchorCenter, 0, -120, g.theme.Message)
+}
+
+func (r *RollbackScene) Overlay() bool { return false }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// runLoopback plays a run between two peers in one process, over
+// localhost behind a network with jitter and loss, each pressing random
+// keys, until both have played steps steps, the run ends or they fall out
+// of sync. tamper, if set, is called on peer 0's game once it has every
+// input up to step tamperAt, so no rollback undoes it.
+func runLoopback(t *testing.T, cfg RunConfig, steps, delay int, tamperAt int, tamper func(*Game)) [2]*RollbackSession {
+	t.Helper()
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := [2]*LossyConn{}
+	for i := range conns {
+		conns[i] = listenLossy(t, uint64(10+i)).(*LossyConn)
+		conns[i].Latency, conns[i].Jitter, conns[i].Loss = 2*time.Millisecond, 8*time.Millisecond, 0.05
+	}
+	var peers [2]*RollbackSession
+	for i := range peers {
+		s, err := NewRollbackSession(newSimGame(assets), conns[i], conns[1-i].LocalAddr().String(), i, cfg, delay)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { s.Close() })
+		s.stopAt = steps
+		peers[i] = s
+	}
+	rng := NewRNG(cfg.Seed)
+	keys := [2]Input{}
+	done := func(s *RollbackSession) bool {
+		return s.Over() || s.Desync > 0 || s.Step() == steps && s.confirmed() >= steps
+	}
+	for frame := 0; !done(peers[0]) || !done(peers[1]); frame++ {
+		if frame == 20*steps {
+			t.Fatalf("os pares pararam nos passos %d e %d", peers[0].Step(), peers[1].Step())
+		}
+		for i, s := range peers {
+			if frame%8 == 0 {
+				keys[i] = Input(rng.IntN(1 << actionCount))
+			}
+			s.Update(keys[i])
+			if i == 0 && tamper != nil && s.Step() >= tamperAt && s.confirmed() >= s.Step() {
+				tamper(s.game)
+				tamper = nil
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return peers
+}
+
+func TestRollbackLoopback(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   RunConfig
+		delay int
+	}{
+		{"co-op sem atraso", RunConfig{Mode: ModeClassic, Seed: 1}, 0},
+		{"co-op com atraso", RunConfig{Mode: ModeClassic, Seed: 2, FriendlyFire: true}, 2},
+		{"versus", RunConfig{Mode: ModeVersus, Seed: 3, Rounds: 3}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peers := runLoopback(t, tt.cfg, 600, tt.delay, 0, nil)
+			a, b := peers[0], peers[1]
+			if a.Desync != 0 || b.Desync != 0 {
+				t.Fatalf("divergência nos passos %d e %d", a.Desync, b.Desync)
+			}
+			if a.Step() != b.Step() {
+				t.Fatalf("pararam nos passos %d e %d", a.Step(), b.Step())
+			}
+			if a.Rollbacks == 0 && b.Rollbacks == 0 {
+				t.Error("nenhum passo refeito; esperado previsões erradas com a rede lenta")
+			}
+			if !slices.Equal(a.game.inputs, b.game.inputs) {
+				t.Error("os pares jogaram com entradas diferentes")
+			}
+			if a.game.checksum() != b.game.checksum() {
+				t.Error("os estados finais diferem")
+			}
+			// Rolled back or not, the run is the one its inputs replay to.
+			sim, err := Simulate(a.game.assets, a.game.replay())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sim.Score != a.game.score || sim.Ticks != a.game.tick {
+				t.Errorf("replay: %+v; jogo: %d pontos no passo %d", sim, a.game.score, a.game.tick)
+			}
+		})
+	}
+}
+
+func TestRollbackDetectsDesync(t *testing.T) {
+	peers := runLoopback(t, RunConfig{Mode: ModeClassic, Seed: 4}, 300, 1, 100, func(g *Game) { g.score += 10 })
+	if peers[0].Desync == 0 || peers[1].Desync == 0 {
+		t.Fatalf("divergência não detectada: %d e %d", peers[0].Desync, peers[1].Desync)
+	}
+	if d := peers[1].Desync; d < 100 || d > 100+2*ChecksumInterval {
+		t.Errorf("detectada no passo %d; esperado logo depois do passo 100", d)
+	}
+}
+
+func TestRollbackSceneUnevenFrames(t *testing.T) {
+	g, _ := newTestGame()
+	// The peers' cameras differ: only the other one has slow motion.
+	g.settings.Effects.SlowMotion.Enabled = false
+	cfg := RunConfig{Mode: ModeClassic, Seed: 8, SharedLives: true}
+	conns := [2]*LossyConn{}
+	for i := range conns {
+		conns[i] = listenLossy(t, uint64(20+i)).(*LossyConn)
+		conns[i].Latency, conns[i].Jitter, conns[i].Loss = 2*time.Millisecond, 8*time.Millisecond, 0.05
+	}
+	a, err := NewRollbackSession(g.mirrorGame(), conns[0], conns[1].LocalAddr().String(), 0, cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := NewRollbackSession(newSimGame(g.assets), conns[1], conns[0].LocalAddr().String(), 1, cfg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	scene := &RollbackScene{g: g, s: a}
+
+	// The scene is updated a varying number of times a frame, more than
+	// the run steps while it waits for the other peer, and the other peer
+	// keeps shooting so there are explosions to keep in step.
+	rng := NewRNG(cfg.Seed)
+	var keys Input
+	for frame := 0; !a.Over() || !b.Over(); frame++ {
+		if frame == 20000 || a.Desync > 0 || b.Desync > 0 {
+			t.Fatalf("quadro %d: passos %d e %d, divergência nos passos %d e %d", frame, a.Step(), b.Step(), a.Desync, b.Desync)
+		}
+		for range 1 + frame%3 {
+			scene.Update()
+		}
+		if frame%8 == 0 {
+			keys = Input(rng.IntN(1 << actionCount)).With(ActionFire)
+		}
+		b.Update(keys)
+		time.Sleep(time.Millisecond)
+	}
+	if a.Step() != b.Step() {
+		t.Fatalf("pararam nos passos %d e %d", a.Step(), b.Step())
+	}
+	if a.game.checksum() != b.game.checksum() {
+		t.Error("os estados finais diferem")
+	}
+	if a.game.dying != b.game.dying || a.game.running != b.game.running {
+		t.Errorf("fim da partida: morrendo %v e %v, em jogo %v e %v", a.game.dying, b.game.dying, a.game.running, b.game.running)
+	}
+}
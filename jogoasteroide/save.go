@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// SaveVersion is the run save format written by this build. Saves from
+// other versions are refused rather than guessed at: a run restored with
+// the wrong meaning for a field would be quietly broken.
+//
+// Version 2 replaced the difficulty with the run config and added the
+// inputs so far, so a resumed run keeps a complete replay. Version 3
+// holds every co-op player and who fired each bullet.
+const SaveVersion = 3
+
+// ErrSaveVersion is returned when a save was written by a different,
+// incompatible version of the game.
+var ErrSaveVersion = errors.New("incompatible save version")
+
+// SaveFile is a snapshot of a run in progress. Visual effects (shake,
+// flash, explosions' sounds) are not kept; everything that affects play
+// is, including the random number generator.
+type SaveFile struct {
+	Version      int              `json:"version"`
+	Config       RunConfig        `json:"config"`
+	Inputs       []Input          `json:"inputs"`
+	Score        int              `json:"score"`
+	Tick         int              `json:"tick"`
+	MaxAsteroids int              `json:"max_asteroids"`
+	RNG          []byte           `json:"rng"`
+	Message      string           `json:"message,omitempty"`
+	MessageTimer int              `json:"message_timer,omitempty"`
+	Players      []savedPlayer    `json:"players"`
+	SharedHealth int              `json:"shared_health"`
+	Round        int              `json:"round,omitempty"` // versus only, like the fields below
+	Wins         []int            `json:"wins,omitempty"`
+	Kills        []int            `json:"kills,omitempty"`
+	RoundEnding  int              `json:"round_ending,omitempty"`
+	Asteroids    []savedAsteroid  `json:"asteroids"`
+	Bullets      []savedBullet    `json:"bullets"`
+	Explosions   []savedExplosion `json:"explosions"`
+	PowerUps     []savedPowerUp   `json:"power_ups"`
+	Stats        RunStats         `json:"stats"`
+	// Achievements is the progress of per-run achievements.
+	Achievements map[string]AchievementProgress `json:"achievements,omitempty"`
+}
+
+type savedPlayer struct {
+	Position     Vector  `json:"position"`
+	Velocity     Vector  `json:"velocity"`
+	Angle        float64 `json:"angle"`
+	FireCooldown int     `json:"fire_cooldown"`
+	Health       int     `json:"health"`
+	Shield       int     `json:"shield"`
+	RapidFire    int     `json:"rapid_fire"`
+	MultiShot    int     `json:"multi_shot"`
+	Score        int     `json:"score"`
+	Out          bool    `json:"out,omitempty"`
+}
+
+type savedAsteroid struct {
+	Position Vector  `json:"position"`
+	Velocity Vector  `json:"velocity"`
+	Size     float64 `json:"size"`
+	Angle    float64 `json:"angle"`
+	RotSpeed float64 `json:"rot_speed"`
+}
+
+type savedBullet struct {
+	Position Vector `json:"position"`
+	Velocity Vector `json:"velocity"`
+	Age      int    `json:"age"`
+	Owner    int    `json:"owner"`
+}
+
+type savedExplosion struct {
+	Position Vector `json:"position"`
+	Frame    int    `json:"frame"`
+	MaxFrame int    `json:"max_frame"`
+}
+
+type savedPowerUp struct {
+	Position Vector      `json:"position"`
+	Velocity Vector      `json:"velocity"`
+	Type     PowerUpType `json:"type"`
+	Size     float64     `json:"size"`
+	Age      int         `json:"age"`
+	MaxAge   int         `json:"max_age"`
+}
+
+// snapshot captures the current run.
+func (g *Game) snapshot() (SaveFile, error) {
+	s, err := g.state()
+	s.Inputs = append([]Input(nil), g.inputs...)
+	return s, err
+}
+
+// state captures the current run without its inputs so far, which only
+// ever grow and can be kept by length instead.
+func (g *Game) state() (SaveFile, error) {
+	rng, err := g.rng.MarshalBinary()
+	if err != nil {
+		return SaveFile{}, err
+	}
+	s := SaveFile{
+		Version:      SaveVersion,
+		Config:       g.config,
+		Score:        g.score,
+		Tick:         g.tick,
+		MaxAsteroids: g.currentMaxAsteroids,
+		RNG:          rng,
+		Message:      g.message,
+		MessageTimer: g.messageTimer,
+		Stats:        g.stats,
+		Achievements: g.achievements.RunProgress(),
+		SharedHealth: g.sharedHealth,
+	}
+	if g.config.Mode == ModeVersus {
+		s.Round = g.round
+		s.Wins = append([]int(nil), g.wins...)
+		s.Kills = append([]int(nil), g.kills...)
+		s.RoundEnding = g.roundEnding
+	}
+	for _, p := range g.players {
+		s.Players = append(s.Players, savedPlayer{
+			Position:     p.position,
+			Velocity:     p.velocity,
+			Angle:        p.angle,
+			FireCooldown: p.fireCooldown,
+			Health:       p.health,
+			Shield:       p.shield,
+			RapidFire:    p.rapidFire,
+			MultiShot:    p.multiShot,
+			Score:        p.score,
+			Out:          p.out,
+		})
+	}
+	for _, a := range g.asteroids {
+		s.Asteroids = append(s.Asteroids, savedAsteroid{a.position, a.velocity, a.size, a.angle, a.rotSpeed})
+	}
+	for _, b := range g.bullets {
+		s.Bullets = append(s.Bullets, savedBullet{b.position, b.velocity, b.age, b.owner})
+	}
+	for _, e := range g.explosions {
+		s.Explosions = append(s.Explosions, savedExplosion{e.position, e.frame, e.maxFrame})
+	}
+	for _, pw := range g.powerUps {
+		s.PowerUps = append(s.PowerUps, savedPowerUp{pw.position, pw.velocity, pw.powerType, pw.size, pw.age, pw.maxAge})
+	}
+	return s, nil
+}
+
+// restore replaces the current run with a snapshot. A snapshot it cannot
+// use is rejected without changing anything.
+func (g *Game) restore(s SaveFile) error {
+	if s.Version != SaveVersion {
+		return fmt.Errorf("%w: %d (this game reads %d)", ErrSaveVersion, s.Version, SaveVersion)
+	}
+	rng := NewRNG(0)
+	if err := rng.UnmarshalBinary(s.RNG); err != nil {
+		return fmt.Errorf("random state: %v", err)
+	}
+	// Check everything before touching the run, so a bad save leaves it
+	// as it was.
+	n := s.Config.playerCount()
+	if len(s.Players) != n {
+		return fmt.Errorf("%d players saved for a %d player run", len(s.Players), n)
+	}
+	if s.Config.Mode == ModeVersus && (len(s.Wins) != n || len(s.Kills) != n) {
+		return fmt.Errorf("versus tallies for %d and %d players in a %d player match", len(s.Wins), len(s.Kills), n)
+	}
+	for _, b := range s.Bullets {
+		if b.Owner < 0 || b.Owner >= n {
+			return fmt.Errorf("bullet fired by unknown player %d", b.Owner)
+		}
+	}
+	g.ResetRun(s.Config)
+	g.rng = rng
+	g.inputs = s.Inputs
+	g.score = s.Score
+	g.tick = s.Tick
+	g.currentMaxAsteroids = s.MaxAsteroids
+	g.message = s.Message
+	g.messageTimer = s.MessageTimer
+	g.stats = s.Stats
+	g.achievements.SetRunProgress(s.Achievements)
+	g.sharedHealth = s.SharedHealth
+	if s.Config.Mode == ModeVersus {
+		g.round = s.Round
+		copy(g.wins, s.Wins)
+		copy(g.kills, s.Kills)
+		g.roundEnding = s.RoundEnding
+	}
+	for i, sp := range s.Players {
+		p := &g.players[i]
+		p.position = sp.Position
+		p.velocity = sp.Velocity
+		p.angle = sp.Angle
+		p.fireCooldown = sp.FireCooldown
+		p.health = sp.Health
+		p.shield = sp.Shield
+		p.rapidFire = sp.RapidFire
+		p.multiShot = sp.MultiShot
+		p.score = sp.Score
+		p.out = sp.Out
+	}
+
+	g.asteroids = g.asteroids[:0]
+	for _, a := range s.Asteroids {
+		g.asteroids = append(g.asteroids, Asteroid{id: g.newID(), position: a.Position, velocity: a.Velocity, size: a.Size, angle: a.Angle, rotSpeed: a.RotSpeed})
+	}
+	for _, b := range s.Bullets {
+		nb := g.bulletPool.Get()
+		*nb = Bullet{id: g.newID(), position: b.Position, velocity: b.Velocity, age: b.Age, owner: b.Owner}
+		g.bullets = append(g.bullets, nb)
+	}
+	for _, e := range s.Explosions {
+		ne := g.explosionPool.Get()
+		*ne = Explosion{position: e.Position, frame: e.Frame, maxFrame: e.MaxFrame}
+		g.explosions = append(g.explosions, ne)
+	}
+	for _, pw := range s.PowerUps {
+		np := g.powerUpPool.Get()
+		*np = PowerUp{id: g.newID(), position: pw.Position, velocity: pw.Velocity, powerType: pw.Type, size: pw.Size, age: pw.Age, maxAge: pw.MaxAge}
+		g.powerUps = append(g.powerUps, np)
+	}
+	return nil
+}
+
+// SaveRun writes s to path.
+func SaveRun(path string, s SaveFile) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// LoadRun reads a save written by SaveRun. Saves from another version
+// fail with an error wrapping ErrSaveVersion.
+func LoadRun(path string) (SaveFile, error) {
+	var s SaveFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	// Check the version first: a newer save may not even decode into
+	// this build's types.
+	var header struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	if header.Version != SaveVersion {
+		return s, fmt.Errorf("%s: %w: %d (this game reads %d)", path, ErrSaveVersion, header.Version, SaveVersion)
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
+
+// hasSave reports whether there is a saved run to resume.
+func (g *Game) hasSave() bool {
+	if g.savePath == "" {
+		return false
+	}
+	_, err := os.Stat(g.savePath)
+	return err == nil
+}
+
+// autosave saves the current run, if there is one worth resuming, along
+// with achievement progress.
+func (g *Game) autosave() {
+	if !g.running || g.dying {
+		return
+	}
+	g.saveAchievements()
+	if g.savePath == "" {
+		return
+	}
+	s, err := g.snapshot()
+	if err == nil {
+		err = SaveRun(g.savePath, s)
+	}
+	if err != nil {
+		log.Printf("saving run: %v", err)
+	}
+}
+
+// deleteSave removes the saved run once it can no longer be resumed.
+func (g *Game) deleteSave() {
+	if g.savePath == "" {
+		return
+	}
+	if err := os.Remove(g.savePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("removing save: %v", err)
+	}
+}
+
+// resumeRun loads the saved run and continues it. On failure the error is
+// returned and the save is left alone.
+func (g *Game) resumeRun() error {
+	s, err := LoadRun(g.savePath)
+	if err == nil {
+		err = g.restore(s)
+	}
+	if err != nil {
+		log.Printf("resuming run: %v", err)
+		return err
+	}
+	g.attachGhost()
+	g.scenes.Reset(&PlayScene{g: g}, Fade)
+	return nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// script returns the input for tick i of a fixed test run: turning,
+// thrusting and firing in a pattern that never repeats quickly.
+func script(i int) Input {
+	var in Input
+	if i%90 < 30 {
+		in = in.With(ActionRotateLeft)
+	}
+	if i%50 < 20 {
+		in = in.With(ActionThrust)
+	}
+	if i%7 == 0 {
+		in = in.With(ActionFire)
+	}
+	return in
+}
+
+func TestSaveRestoresRun(t *testing.T) {
+	a, _ := newTestGame()
+	a.ResetSeed(42)
+	a.players[0].health = 1000 // keep the run going through the collisions
+	for i := 0; i < 400; i++ {
+		a.updatePlaying(script(i))
+	}
+	if !a.running || a.dying {
+		t.Fatal("a partida terminou antes do salvamento")
+	}
+	saved, err := a.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "save.json")
+	if err := SaveRun(path, saved); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadRun(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := newTestGame()
+	if err := b.restore(loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 400; i < 800; i++ {
+		a.updatePlaying(script(i))
+		b.updatePlaying(script(i))
+	}
+	sa, _ := a.snapshot()
+	sb, _ := b.snapshot()
+	if !reflect.DeepEqual(sa, sb) {
+		t.Errorf("partida restaurada divergiu: pontos %d e %d, %d e %d asteroides",
+			sa.Score, sb.Score, len(sa.Asteroids), len(sb.Asteroids))
+	}
+}
+
+func TestRestoreRejectsBadSave(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(*SaveFile)
+	}{
+		{"versão", func(s *SaveFile) { s.Version++ }},
+		{"estado aleatório", func(s *SaveFile) { s.RNG = nil }},
+		{"jogadores", func(s *SaveFile) { s.Players = s.Players[:1] }},
+		{"placar do versus", func(s *SaveFile) { s.Wins = s.Wins[:1] }},
+		{"dono do tiro", func(s *SaveFile) { s.Bullets = append(s.Bullets, savedBullet{Owner: 2}) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, _ := newTestGame()
+			g.ResetRun(g.versusConfig(7))
+			for i := 0; i < 120; i++ {
+				g.updatePlaying(script(i), script(i+45))
+			}
+			bad, err := g.snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.damage(&bad)
+			other, _ := newTestGame()
+			other.ResetSeed(3)
+			for i := 0; i < 60; i++ {
+				other.updatePlaying(script(i))
+			}
+			before, _ := other.snapshot()
+			if err := other.restore(bad); err == nil {
+				t.Fatal("restore() sem erro; esperado erro")
+			}
+			after, _ := other.snapshot()
+			if !reflect.DeepEqual(before, after) || !other.running {
+				t.Error("o salvamento recusado mudou a partida em curso")
+			}
+		})
+	}
+}
+
+func TestLoadRunRejectsOtherVersions(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name        string
+		content     string
+		wantVersion bool
+	}{
+		{"versão mais nova", `{"version": 99, "player": "formato novo"}`, true},
+		{"sem versão", `{"score": 10}`, true},
+		{"arquivo corrompido", `{"version": 3, "score": "muito"}`, false},
+		{"json inválido", `{`, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".json")
+			writeFile(t, path, tt.content)
+			_, err := LoadRun(path)
+			if err == nil {
+				t.Fatal("LoadRun() sem erro; esperado erro")
+			}
+			if errors.Is(err, ErrSaveVersion) != tt.wantVersion {
+				t.Errorf("LoadRun() = %v; ErrSaveVersion esperado: %v", err, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestAutosave(t *testing.T) {
+	g, _ := newTestGame()
+	finishTransition(g)
+	g.savePath = filepath.Join(t.TempDir(), "save.json")
+
+	g.pause()
+	if !g.hasSave() {
+		t.Fatal("pausar não salvou a partida")
+	}
+	g.scenes.Pop(Cut)
+	g.endRun()
+	if g.hasSave() {
+		t.Error("o fim de jogo não apagou a partida salva")
+	}
+
+	g.startRun()
+	finishTransition(g)
+	g.pauseOnFocusLoss()
+	if !g.hasSave() {
+		t.Error("perder o foco não salvou a partida")
+	}
+	if _, ok := g.scenes.Top().(*PauseScene); !ok {
+		t.Errorf("cena = %T; esperado *PauseScene ao perder o foco", g.scenes.Top())
+	}
+}
+
+func TestResumeFromMenu(t *testing.T) {
+	g, _ := newTestGame()
+	g.savePath = filepath.Join(t.TempDir(), "save.json")
+	g.score = 1234
+	g.quitToMenu()
+	finishTransition(g)
+
+	menu := g.scenes.Top().(*MenuScene)
+	g.nav = Nav{Accept: true}
+	menu.Update() // the first entry is "continue"
+	finishTransition(g)
+	if _, ok := g.scenes.Top().(*PlayScene); !ok || g.score != 1234 {
+		t.Errorf("cena = %T, pontos = %d; esperado *PlayScene e 1234", g.scenes.Top(), g.score)
+	}
+
+	g.quitToMenu()
+	finishTransition(g)
+	writeFile(t, g.savePath, `{"version": 99}`)
+	menu = g.scenes.Top().(*MenuScene)
+	menu.Update()
+	if menu.notice != g.loc.T("save.incompatible") {
+		t.Errorf("aviso = %q; esperado a mensagem de versão incompatível", menu.notice)
+	}
+	if _, err := os.Stat(g.savePath); err != nil {
+		t.Error("a partida salva incompatível foi apagada")
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"image/color"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Scene is one screen of the game: the title menu, a run, the pause
+// overlay and so on. Only the scene on top of the stack is updated.
+type Scene interface {
+	Update() error
+	Draw(screen *ebiten.Image)
+	// Overlay reports whether the scenes below should be drawn first. They
+	// are not updated, so an overlay is drawn over a frozen frame.
+	Overlay() bool
+}
+
+// TransitionKind is how one view of the stack blends into the next.
+type TransitionKind int
+
+const (
+	TransitionCut TransitionKind = iota
+	TransitionFade
+	TransitionSlideLeft  // the new view enters from the right
+	TransitionSlideRight // the new view enters from the left
+)
+
+// Transition describes the animation played when the stack changes.
+type Transition struct {
+	Kind   TransitionKind
+	Frames int
+}
+
+// Transitions used by the game.
+var (
+	Cut       = Transition{}
+	Fade      = Transition{TransitionFade, 20}
+	QuickFade = Transition{TransitionFade, 8}
+	SlideIn   = Transition{TransitionSlideLeft, 18}
+	SlideOut  = Transition{TransitionSlideRight, 18}
+)
+
+// SceneStack holds the active scenes, bottom first. While a transition
+// runs no scene is updated, so input cannot act on a screen that is only
+// half visible.
+type SceneStack struct {
+	// Background fills the layers transitions are drawn to.
+	Background color.Color
+
+	scenes []Scene
+	from   []Scene // what was on screen when the transition started
+	trans  Transition
+	frame  int
+	layers [2]*ebiten.Image
+}
+
+// Top returns the scene that receives input, or nil for an empty stack.
+func (s *SceneStack) Top() Scene {
+	if len(s.scenes) == 0 {
+		return nil
+	}
+	return s.scenes[len(s.scenes)-1]
+}
+
+// Len returns the number of scenes on the stack.
+func (s *SceneStack) Len() int {
+	return len(s.scenes)
+}
+
+// Push puts a scene on top of the stack.
+func (s *SceneStack) Push(scene Scene, t Transition) {
+	s.begin(t)
+	s.scenes = append(s.scenes, scene)
+}
+
+// Pop removes the top scene. The last scene is never popped.
+func (s *SceneStack) Pop(t Transition) {
+	if len(s.scenes) < 2 {
+		return
+	}
+	s.begin(t)
+	s.scenes[len(s.scenes)-1] = nil
+	s.scenes = s.scenes[:len(s.scenes)-1]
+}
+
+// Replace swaps the top scene for another one.
+func (s *SceneStack) Replace(scene Scene, t Transition) {
+	if len(s.scenes) == 0 {
+		s.Push(scene, t)
+		return
+	}
+	s.begin(t)
+	s.scenes[len(s.scenes)-1] = scene
+}
+
+// Reset clears the stack and leaves scene as its only entry.
+func (s *SceneStack) Reset(scene Scene, t Transition) {
+	s.begin(t)
+	s.scenes = []Scene{scene}
+}
+
+// begin starts a transition away from the current view.
+func (s *SceneStack) begin(t Transition) {
+	if t.Kind == TransitionCut || t.Frames <= 0 || len(s.scenes) == 0 {
+		s.from, s.trans = nil, Cut
+		return
+	}
+	s.from = slices.Clone(s.scenes)
+	s.trans = t
+	s.frame = 0
+}
+
+// Transitioning reports whether a transition is being played.
+func (s *SceneStack) Transitioning() bool {
+	return s.from != nil
+}
+
+// Update advances the transition, or updates the top scene when there is
+// none.
+func (s *SceneStack) Update() error {
+	if s.Transitioning() {
+		s.frame++
+		if s.frame >= s.trans.Frames {
+			s.from = nil
+		}
+		return nil
+	}
+	if top := s.Top(); top != nil {
+		return top.Update()
+	}
+	return nil
+}
+
+// Draw draws the visible scenes, blending the old and new views while a
+// transition runs.
+func (s *SceneStack) Draw(screen *ebiten.Image) {
+	if !s.Transitioning() {
+		drawScenes(screen, s.scenes)
+		return
+	}
+	for i := range s.layers {
+		if s.layers[i] == nil {
+			s.layers[i] = ebiten.NewImage(ScreenWidth, ScreenHeight)
+		}
+		s.layers[i].Fill(s.Background)
+	}
+	from, to := s.layers[0], s.layers[1]
+	drawScenes(from, s.from)
+	drawScenes(to, s.scenes)
+
+	p := smoothstep(float64(s.frame) / float64(s.trans.Frames))
+	fromOp, toOp := &ebiten.DrawImageOptions{}, &ebiten.DrawImageOptions{}
+	switch s.trans.Kind {
+	case TransitionFade:
+		toOp.ColorScale.ScaleAlpha(float32(p))
+	case TransitionSlideLeft:
+		fromOp.GeoM.Translate(-p*ScreenWidth, 0)
+		toOp.GeoM.Translate((1-p)*ScreenWidth, 0)
+	case TransitionSlideRight:
+		fromOp.GeoM.Translate(p*ScreenWidth, 0)
+		toOp.GeoM.Translate((p-1)*ScreenWidth, 0)
+	}
+	screen.DrawImage(from, fromOp)
+	screen.DrawImage(to, toOp)
+}
+
+// drawScenes draws the top scene and, if it is an overlay, the scenes
+// beneath it down to the first one that is not.
+func drawScenes(dst *ebiten.Image, scenes []Scene) {
+	first := len(scenes) - 1
+	for first > 0 && scenes[first].Overlay() {
+		first--
+	}
+	for _, sc := range scenes[max(first, 0):] {
+		sc.Draw(dst)
+	}
+}
+
+func smoothstep(t float64) float64 {
+	t = max(0, min(1, t))
+	return t * t * (3 - 2*t)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// fakeScene records how often it was updated and drawn.
+type fakeScene struct {
+	name    string
+	overlay bool
+	updates int
+	log     *[]string
+}
+
+func (s *fakeScene) Update() error { s.updates++; return nil }
+
+func (s *fakeScene) Draw(*ebiten.Image) { *s.log = append(*s.log, s.name) }
+
+func (s *fakeScene) Overlay() bool { return s.overlay }
+
+func TestSceneStack(t *testing.T) {
+	var log []string
+	a := &fakeScene{name: "a", log: &log}
+	b := &fakeScene{name: "b", log: &log}
+	c := &fakeScene{name: "c", overlay: true, log: &log}
+	var s SceneStack
+
+	s.Reset(a, Cut)
+	s.Push(b, Cut)
+	s.Push(c, Cut)
+	s.Update()
+	if a.updates != 0 || b.updates != 0 || c.updates != 1 {
+		t.Errorf("updates = %d %d %d; esperado só o topo", a.updates, b.updates, c.updates)
+	}
+	s.Draw(nil)
+	if got := len(log); got != 2 || log[0] != "b" || log[1] != "c" {
+		t.Errorf("desenho = %v; esperado [b c]", log)
+	}
+
+	s.Pop(Cut)
+	s.Replace(a, Cut)
+	if s.Len() != 2 || s.Top() != a {
+		t.Errorf("Len() = %d, Top() = %v; esperado 2 e a", s.Len(), s.Top())
+	}
+	s.Pop(Cut)
+	s.Pop(Cut)
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d; a última cena não deve sair da pilha", s.Len())
+	}
+}
+
+func TestSceneTransition(t *testing.T) {
+	var log []string
+	a := &fakeScene{name: "a", log: &log}
+	b := &fakeScene{name: "b", log: &log}
+	var s SceneStack
+	s.Reset(a, Cut)
+	s.Push(b, Fade)
+
+	for i := 0; i < Fade.Frames; i++ {
+		if !s.Transitioning() {
+			t.Fatalf("transição terminou no quadro %d; esperado %d", i, Fade.Frames)
+		}
+		s.Update()
+	}
+	if s.Transitioning() {
+		t.Fatal("transição não terminou")
+	}
+	if b.updates != 0 {
+		t.Errorf("cena atualizada %d vezes durante a transição", b.updates)
+	}
+	s.Update()
+	if b.updates != 1 {
+		t.Errorf("updates = %d; esperado 1 depois da transição", b.updates)
+	}
+}
+
+func TestPauseFreezesRun(t *testing.T) {
+	g, _ := newTestGame()
+	finishTransition(g)
+	g.players[0].velocity = Vector{X: 2}
+	g.scenes.Push(newPauseScene(g), Cut)
+	pos := g.players[0].position
+	for i := 0; i < 30; i++ {
+		g.Update()
+	}
+	if g.players[0].position != pos {
+		t.Errorf("posição = %v; esperado %v com o jogo pausado", g.players[0].position, pos)
+	}
+
+	g.nav = Nav{Accept: true}
+	g.scenes.Top().Update()
+	finishTransition(g)
+	if _, ok := g.scenes.Top().(*PlayScene); !ok {
+		t.Errorf("cena = %T; esperado *PlayScene depois de continuar", g.scenes.Top())
+	}
+}
+
+func TestConfirmDefaultsToNo(t *testing.T) {
+	g, _ := newTestGame()
+	finishTransition(g)
+	quit := false
+	g.confirm("confirm.new_run", func() { quit = true })
+	finishTransition(g)
+	g.nav = Nav{Accept: true}
+	g.scenes.Top().Update()
+	if quit {
+		t.Error("confirmação aceita sem escolher \"sim\"")
+	}
+	if _, ok := g.scenes.Top().(*PlayScene); !ok {
+		t.Errorf("cena = %T; esperado *PlayScene", g.scenes.Top())
+	}
+}
+
+// finishTransition plays out any running scene transition.
+func finishTransition(g *Game) {
+	for g.scenes.Transitioning() {
+		g.scenes.Update()
+	}
+}
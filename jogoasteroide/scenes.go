@@ -0,0 +1,327 @@
+package main
+
+import (
+	"errors"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// OverlayDim is the opacity of the background drawn behind overlays.
+const OverlayDim = 0.7
+
+// menuStyle is how the game's lists are drawn.
+func (g *Game) menuStyle() ListStyle {
+	return ListStyle{Face: g.fonts.Normal, Width: 720, Color: g.theme.Text, Focus: g.theme.Highlight}
+}
+
+// button is a Button whose label is a message ID.
+func (g *Game) button(id string, onPress func()) *Button {
+	return &Button{Text: func() string { return g.loc.T(id) }, OnPress: onPress}
+}
+
+// confirm asks a yes/no question over the current scene and runs onYes
+// if the player agrees.
+func (g *Game) confirm(id string, onYes func()) {
+	g.scenes.Push(newConfirmScene(g, id, onYes), QuickFade)
+}
+
+// confirmNewRun runs start, asking first if that would replace a saved
+// run.
+func (g *Game) confirmNewRun(start func()) {
+	if g.hasSave() {
+		g.confirm("confirm.new_run", start)
+	} else {
+		start()
+	}
+}
+
+// MenuScene is the title screen.
+type MenuScene struct {
+	g      *Game
+	list   List
+	notice string // shown when resuming a saved run failed
+	idle   int    // frames without input
+	demo   *Demo  // playing in attract mode, if any
+	demos  int    // demos played so far
+}
+
+func newMenuScene(g *Game) *MenuScene {
+	s := &MenuScene{g: g}
+	if g.hasSave() {
+		s.list.Items = append(s.list.Items, g.button("menu.continue", s.resume))
+	}
+	s.list.Items = append(s.list.Items, g.button("menu.start", func() { g.confirmNewRun(g.startRun) }), g.tutorialButton())
+	if len(g.ghosts.Runs) > 0 {
+		s.list.Items = append(s.list.Items, g.button("menu.ghost", func() { g.confirmNewRun(s.raceGhost) }))
+	}
+	s.list.Items = append(s.list.Items,
+		g.button("menu.daily", func() { g.scenes.Push(newDailyScene(g), SlideIn) }),
+		g.button("menu.versus", func() { g.confirmNewRun(g.startVersus) }),
+		g.button("menu.options", g.openSettings),
+		g.button("menu.stats", func() { g.scenes.Push(newStatsScene(g), SlideIn) }),
+		g.button("menu.achievements", func() { g.scenes.Push(newAchievementsScene(g), SlideIn) }),
+		g.button("menu.quit", func() {
+			g.confirm("confirm.quit_game", func() { g.quitting = true })
+		}),
+	)
+	return s
+}
+
+func (s *MenuScene) resume() {
+	err := s.g.resumeRun()
+	switch {
+	case errors.Is(err, ErrSaveVersion):
+		s.notice = s.g.loc.T("save.incompatible")
+	case err != nil:
+		s.notice = s.g.loc.T("save.failed")
+	}
+}
+
+func (s *MenuScene) raceGhost() {
+	if !s.g.raceGhost() {
+		s.notice = s.g.loc.T("ghost.none", s.g.loc.T("difficulty."+s.g.settings.Difficulty.normalize().String()))
+	}
+}
+
+func (s *MenuScene) Update() error {
+	if s.updateAttract() || s.demo != nil {
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *MenuScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	if s.demo != nil {
+		s.drawAttract(screen)
+		return
+	}
+	drawAnchoredText(screen, g.loc.T("menu.best", g.highScore), g.fonts.Normal, AnchorCenter, 0, -220, g.theme.Text)
+	drawAnchoredText(screen, g.loc.T("menu.title"), g.fonts.Title, AnchorCenter, 0, -150, g.theme.Text)
+	drawAnchoredText(screen, g.loc.T("menu.instructions"), g.fonts.Normal, AnchorCenter, 0, -60, g.theme.Text)
+	s.list.Draw(screen, ScreenHeight/2+10, g.menuStyle())
+	if s.notice != "" {
+		drawAnchoredText(screen, s.notice, g.fonts.Normal, AnchorBottom, 0, 40, g.theme.Message)
+	}
+}
+
+func (s *MenuScene) Overlay() bool { return false }
+
+// PlayScene runs the game.
+type PlayScene struct {
+	g *Game
+}
+
+func (s *PlayScene) Update() error {
+	g := s.g
+	if g.settings.Bindings.JustPressed(ActionPause) || g.nav.Back || g.nav.Start {
+		g.pause()
+		return nil
+	}
+	if g.camera.Update() {
+		g.updatePlaying(g.readInputs()...)
+		if g.ghost != nil {
+			g.ghost.Sync(g.tick)
+		}
+		g.broadcast.Record(g)
+	}
+	return nil
+}
+
+func (s *PlayScene) Draw(screen *ebiten.Image) {
+	s.g.drawPlaying(screen)
+}
+
+func (s *PlayScene) Overlay() bool { return false }
+
+// PauseScene is drawn over the frozen run.
+type PauseScene struct {
+	g    *Game
+	list List
+}
+
+func newPauseScene(g *Game) *PauseScene {
+	s := &PauseScene{g: g}
+	s.list.Items = []Widget{
+		g.button("pause.resume", s.resume),
+		g.button("menu.options", g.openSettings),
+		g.button("pause.quit", g.quitToMenu),
+	}
+	return s
+}
+
+func (s *PauseScene) resume() {
+	s.g.scenes.Pop(QuickFade)
+}
+
+func (s *PauseScene) Update() error {
+	g := s.g
+	if g.settings.Bindings.JustPressed(ActionPause) || g.nav.Back || g.nav.Start {
+		s.resume()
+		return nil
+	}
+	s.list.Update(g.nav)
+	return nil
+}
+
+func (s *PauseScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	fillRect(screen, 0, 0, ScreenWidth, ScreenHeight, g.theme.Background, OverlayDim)
+	drawAnchoredText(screen, g.loc.T("paused"), g.fonts.Large, AnchorCenter, 0, -80, g.theme.Text)
+	s.list.Draw(screen, ScreenHeight/2-20, g.menuStyle())
+}
+
+func (s *PauseScene) Overlay() bool { return true }
+
+// GameOverScene shows the result and a breakdown of the run over its last
+// frame.
+type GameOverScene struct {
+	g      *Game
+	list   List
+	submit <-chan submission // pending leaderboard submission
+	status string
+}
+
+// A daily challenge has a single attempt, so it offers no retry. With a
+// leaderboard configured the run is sent to it straight away.
+func newGameOverScene(g *Game) *GameOverScene {
+	s := &GameOverScene{g: g, submit: g.submitRun()}
+	if s.submit != nil {
+		s.status = g.loc.T("leaderboard.sending")
+	}
+	if g.config.Mode != ModeDaily {
+		s.list.Items = append(s.list.Items, g.button("gameover.retry", g.restart))
+	}
+	s.list.Items = append(s.list.Items, g.button("gameover.menu", g.quitToMenu))
+	return s
+}
+
+func (s *GameOverScene) Update() error {
+	select {
+	case res := <-s.submit:
+		s.submit = nil
+		s.status = s.g.submissionStatus(res)
+	default:
+	}
+	if s.g.config.Mode != ModeDaily && inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		s.g.restart()
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *GameOverScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	fillRect(screen, 0, 0, ScreenWidth, ScreenHeight, g.theme.Background, OverlayDim)
+	drawAnchoredText(screen, g.loc.T("gameover.title"), g.fonts.Title, AnchorCenter, 0, -270, g.theme.Highlight)
+	if g.config.Mode == ModeVersus {
+		g.drawVersusResult(screen, g.leader(), "versus.match_winner", "versus.match_draw", -190)
+		s.list.Draw(screen, ScreenHeight/2+110, g.menuStyle())
+		return
+	}
+	lines := g.loc.N("gameover.score", g.score) + "\n" + g.loc.T("gameover.best", g.highScore)
+	if g.config.Mode == ModeDaily {
+		lines = g.loc.N("gameover.score", g.score) + "\n" + g.loc.T("daily.gameover", g.config.Date)
+	}
+	drawAnchoredText(screen, lines, g.fonts.Normal, AnchorCenter, 0, -190, g.theme.Highlight)
+	rows := append([]statRow{{g.loc.T("stats.time"), formatDuration(g.stats.Duration())}}, g.statRows(g.stats)...)
+	drawStatRows(screen, rows, ScreenHeight/2-140, g.menuStyle())
+	s.list.Draw(screen, ScreenHeight/2+110, g.menuStyle())
+	if s.status != "" {
+		drawAnchoredText(screen, s.status, g.fonts.Small, AnchorBottom, 0, 20, g.theme.Message)
+	}
+}
+
+// submissionStatus describes how the leaderboard took the run.
+func (g *Game) submissionStatus(res submission) string {
+	switch {
+	case errors.Is(res.err, ErrRunRejected):
+		return g.loc.T("leaderboard.rejected")
+	case res.err != nil:
+		return g.loc.T("leaderboard.offline")
+	case res.result.Rank == 0:
+		return g.loc.T("leaderboard.unranked")
+	}
+	return g.loc.T("leaderboard.accepted", res.result.Rank)
+}
+
+func (s *GameOverScene) Overlay() bool { return true }
+
+// ConfirmScene is a yes/no dialog. It defaults to "no" so a stray accept
+// press never throws a run away.
+type ConfirmScene struct {
+	g        *Game
+	question string
+	list     List
+}
+
+func newConfirmScene(g *Game, question string, onYes func()) *ConfirmScene {
+	s := &ConfirmScene{g: g, question: question}
+	s.list.Items = []Widget{
+		g.button("confirm.no", func() { g.scenes.Pop(QuickFade) }),
+		g.button("confirm.yes", func() {
+			g.scenes.Pop(Cut)
+			onYes()
+		}),
+	}
+	return s
+}
+
+func (s *ConfirmScene) Update() error {
+	if s.g.nav.Back {
+		s.g.scenes.Pop(QuickFade)
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *ConfirmScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	fillRect(screen, ScreenWidth/2-300, ScreenHeight/2-110, 600, 220, g.theme.Background, 0.95)
+	drawAnchoredText(screen, g.loc.T(s.question), g.fonts.Normal, AnchorCenter, 0, -50, g.theme.Text)
+	s.list.Draw(screen, ScreenHeight/2, g.menuStyle())
+}
+
+func (s *ConfirmScene) Overlay() bool { return true }
+
+// startRun begins a new classic run, replacing any saved one.
+func (g *Game) startRun() {
+	g.beginRun(g.classicConfig(rand.Uint64()))
+}
+
+// beginRun starts a run with cfg, replacing any saved one.
+func (g *Game) beginRun(cfg RunConfig) {
+	g.deleteSave()
+	g.ResetRun(cfg)
+	g.attachGhost()
+	g.scenes.Reset(&PlayScene{g: g}, Fade)
+}
+
+// pause saves the run and shows the pause menu over it.
+func (g *Game) pause() {
+	g.autosave()
+	g.scenes.Push(newPauseScene(g), QuickFade)
+}
+
+// pauseOnFocusLoss pauses a run when the window loses focus, and saves it
+// whatever screen is showing.
+func (g *Game) pauseOnFocusLoss() {
+	if _, ok := g.scenes.Top().(*PlayScene); ok {
+		g.pause()
+		return
+	}
+	g.autosave()
+}
+
+// quitToMenu leaves the run, saving it if it is still going, and shows
+// the title screen.
+func (g *Game) quitToMenu() {
+	g.autosave()
+	g.running = false
+	g.scenes.Reset(newMenuScene(g), Fade)
+}
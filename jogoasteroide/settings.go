@@ -0,0 +1,164 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// SettingsVersion is the settings file format written by this build.
+// Bump it and add an entry to settingsMigrations when a field is renamed
+// or changes meaning; purely new fields need neither, since a file is
+// decoded over the defaults.
+const SettingsVersion = 1
+
+// Settings groups the player-adjustable options.
+type Settings struct {
+	Version    int             `json:"version"`
+	Display    DisplaySettings `json:"display"`
+	Effects    EffectsSettings `json:"effects"`
+	Audio      AudioSettings   `json:"audio"`
+	Language   string          `json:"language"`
+	Difficulty Difficulty      `json:"difficulty"`
+	Theme      string          `json:"theme"`
+	Bindings   Bindings        `json:"bindings"`
+	Coop       CoopSettings    `json:"coop"`
+	// InputDelay is how many steps peer-to-peer play holds back each
+	// input.
+	InputDelay int `json:"input_delay"`
+	// Name goes on shared results; empty means the system user name.
+	Name string `json:"name,omitempty"`
+	// LeaderboardURL is the server finished runs are sent to, if any.
+	LeaderboardURL string `json:"leaderboard_url,omitempty"`
+}
+
+// DefaultSettings returns the settings used on first launch.
+func DefaultSettings() Settings {
+	return Settings{
+		Version:    SettingsVersion,
+		Display:    DisplaySettings{ScaleMode: ScaleSmooth},
+		Effects:    defaultEffects(),
+		Audio:      AudioSettings{Master: 0.8, SFX: 1, Music: 0.5},
+		Language:   DefaultLanguage,
+		Difficulty: DifficultyNormal,
+		Theme:      Themes[0],
+		Bindings:   DefaultBindings(),
+		Coop:       DefaultCoopSettings(),
+		InputDelay: DefaultInputDelay,
+	}
+}
+
+// settingsMigrations[v] upgrades the raw JSON of a version v file to
+// version v+1.
+var settingsMigrations = map[int]func(raw map[string]any){}
+
+// ConfigPath returns the path of the named file in the game's folder of
+// the user's configuration directory.
+func ConfigPath(name string) (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "jogoasteroide", name), nil
+}
+
+// LoadSettings reads the settings file at path, migrating it forward if it
+// was written by an older version. A missing file yields the defaults
+// without error; any other problem yields the defaults and the error.
+func LoadSettings(path string) (Settings, error) {
+	s := DefaultSettings()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	migrated, err := migrateSettings(data)
+	if err != nil {
+		return s, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := json.Unmarshal(migrated, &s); err != nil {
+		return DefaultSettings(), fmt.Errorf("%s: %v", path, err)
+	}
+	return s, nil
+}
+
+// migrateSettings brings raw settings JSON up to SettingsVersion.
+func migrateSettings(data []byte) ([]byte, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	v, _ := raw["version"].(float64)
+	version := int(v)
+	if version > SettingsVersion {
+		return nil, fmt.Errorf("settings version %d is newer than this game (%d)", version, SettingsVersion)
+	}
+	for ; version < SettingsVersion; version++ {
+		if migrate, ok := settingsMigrations[version]; ok {
+			migrate(raw)
+		}
+	}
+	raw["version"] = SettingsVersion
+	return json.Marshal(raw)
+}
+
+// SaveSettings writes s to path, creating the directory if needed.
+func SaveSettings(path string, s Settings) error {
+	s.Version = SettingsVersion
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data beside path and renames it over the old
+// file, so a crash never leaves a half-written file. Missing directories
+// are created.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// playerName is the name put on shared results.
+func (g *Game) playerName() string {
+	return cmp.Or(g.settings.Name, os.Getenv("USER"), os.Getenv("USERNAME"), "jogador")
+}
+
+// applySettings replaces the current settings and pushes them to the
+// subsystems that cache them.
+func (g *Game) applySettings(s Settings) {
+	g.settings = s
+	g.setLanguage(s.Language)
+	g.setTheme(s.Theme)
+	g.settings.Display.apply()
+	g.mixer.Refresh()
+}
+
+// saveSettings writes the settings to disk, if the game has a settings
+// file.
+func (g *Game) saveSettings() {
+	if g.settingsPath == "" {
+		return
+	}
+	if err := SaveSettings(g.settingsPath, g.settings); err != nil {
+		log.Printf("saving settings: %v", err)
+	}
+}
+
+// setLanguage changes the interface language.
+func (g *Game) setLanguage(lang string) {
+	g.loc.SetLanguage(lang)
+	g.settings.Language = g.loc.Language()
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// SettingsRows is how many settings fit on screen before the list scrolls.
+const SettingsRows = 18
+
+// SettingsScene is the options screen, reached from the title menu and
+// the pause menu.
+type SettingsScene struct {
+	g          *Game
+	list       List
+	rebinding  Action
+	waitingKey bool
+	bindFor    int // the player whose keys the action rows show and set
+}
+
+func newSettingsScene(g *Game) *SettingsScene {
+	m := &SettingsScene{g: g, list: List{Rows: SettingsRows}}
+	s := &g.settings
+	loc := g.loc
+	text := func(id string) func() string {
+		return func() string { return loc.T(id) }
+	}
+	onOff := func(b bool) string {
+		if b {
+			return loc.T("value.on")
+		}
+		return loc.T("value.off")
+	}
+
+	volume := func(id string, v *float64) Widget {
+		return &Slider{
+			Text: text(id),
+			Get:  func() float64 { return *v },
+			Set: func(x float64) {
+				*v = x
+				g.mixer.Refresh()
+			},
+			Max:  1,
+			Step: 0.1,
+		}
+	}
+	effect := func(id string, e *EffectSetting) Widget {
+		return &Slider{
+			Text: text(id),
+			Get:  e.amount,
+			Set: func(x float64) {
+				e.Enabled = x > 0
+				if e.Enabled {
+					e.Intensity = x
+				}
+			},
+			Max:  1,
+			Step: 0.25,
+			Format: func(x float64) string {
+				if x == 0 {
+					return loc.T("value.off")
+				}
+				return percent(x)
+			},
+		}
+	}
+	choice := func(id string, options []string, prefix string, get func() string, set func(string)) Widget {
+		return &Choice{
+			Text:   text(id),
+			Count:  len(options),
+			Get:    func() int { return indexOf(options, get()) },
+			Set:    func(i int) { set(options[i]) },
+			Format: func(i int) string { return loc.T(prefix + options[i]) },
+		}
+	}
+
+	m.list.Items = []Widget{
+		volume("settings.master_volume", &s.Audio.Master),
+		volume("settings.sfx_volume", &s.Audio.SFX),
+		volume("settings.music_volume", &s.Audio.Music),
+		Toggle(text("settings.mute"),
+			func() bool { return !s.Audio.Muted },
+			func(bool) { g.mixer.ToggleMute() }, onOff),
+		&Choice{
+			Text:   text("settings.difficulty"),
+			Count:  int(difficultyCount),
+			Get:    func() int { return int(s.Difficulty.normalize()) },
+			Set:    func(i int) { s.Difficulty = Difficulty(i) },
+			Format: func(i int) string { return loc.T("difficulty." + Difficulty(i).String()) },
+		},
+		&Choice{
+			Text:   text("settings.players"),
+			Count:  MaxPlayers,
+			Get:    func() int { return max(1, min(s.Coop.Players, MaxPlayers)) - 1 },
+			Set:    func(i int) { s.Coop.Players = i + 1 },
+			Format: func(i int) string { return fmt.Sprint(i + 1) },
+		},
+		&Choice{
+			Text:   text("settings.rounds"),
+			Count:  len(VersusRounds),
+			Get:    func() int { return max(0, slices.Index(VersusRounds, s.Coop.Rounds)) },
+			Set:    func(i int) { s.Coop.Rounds = VersusRounds[i] },
+			Format: func(i int) string { return loc.T("versus.best_of", VersusRounds[i]) },
+		},
+		&Choice{
+			Text:  text("settings.cpu"),
+			Count: int(botSkillCount) + 1,
+			Get: func() int {
+				skill, ok := ParseBotSkill(s.Coop.CPU)
+				if !ok {
+					return 0
+				}
+				return int(skill) + 1
+			},
+			Set: func(i int) {
+				s.Coop.CPU = ""
+				if i > 0 {
+					s.Coop.CPU = BotSkill(i - 1).String()
+				}
+			},
+			Format: func(i int) string {
+				if i == 0 {
+					return loc.T("value.off")
+				}
+				return loc.T("difficulty." + BotSkill(i-1).String())
+			},
+		},
+		Toggle(text("settings.shared_lives"),
+			func() bool { return s.Coop.SharedLives },
+			func(b bool) { s.Coop.SharedLives = b }, onOff),
+		Toggle(text("settings.friendly_fire"),
+			func() bool { return s.Coop.FriendlyFire },
+			func(b bool) { s.Coop.FriendlyFire = b }, onOff),
+		&Choice{
+			Text:   text("settings.input_delay"),
+			Count:  MaxInputDelay + 1,
+			Get:    func() int { return max(0, min(s.InputDelay, MaxInputDelay)) },
+			Set:    func(i int) { s.InputDelay = i },
+			Format: func(i int) string { return loc.N("value.frames", i) },
+		},
+		choice("settings.language", Languages, "language.",
+			func() string { return s.Language }, g.setLanguage),
+		Toggle(text("settings.fullscreen"),
+			func() bool { return s.Display.Fullscreen },
+			func(bool) { g.toggleFullscreen() }, onOff),
+		&Choice{
+			Text:  text("settings.scale_mode"),
+			Count: 2,
+			Get:   func() int { return int(s.Display.ScaleMode) },
+			Set:   func(int) { g.toggleScaleMode() },
+			Format: func(i int) string {
+				if ScaleMode(i) == ScaleInteger {
+					return loc.T("scale.integer")
+				}
+				return loc.T("scale.smooth")
+			},
+		},
+		choice("settings.theme", Themes, "theme.",
+			func() string { return s.Theme }, g.setTheme),
+		effect("settings.shake", &s.Effects.Shake),
+		effect("settings.flash", &s.Effects.Flash),
+		effect("settings.hit_stop", &s.Effects.HitStop),
+		effect("settings.slow_motion", &s.Effects.SlowMotion),
+	}
+	m.list.Items = append(m.list.Items, &Choice{
+		Text:   text("settings.controls"),
+		Count:  MaxPlayers,
+		Get:    func() int { return m.bindFor },
+		Set:    func(i int) { m.bindFor = i },
+		Format: func(i int) string { return loc.T("settings.controls_player", i+1) },
+	})
+	for a := Action(0); a < actionCount; a++ {
+		m.list.Items = append(m.list.Items, &Button{
+			Text:   text("action." + a.String()),
+			Detail: func() string { return keyNames(m.bindings()[a]) },
+			OnPress: func() {
+				m.rebinding = a
+				m.waitingKey = true
+			},
+		})
+	}
+	m.list.Items = append(m.list.Items,
+		g.button("settings.reset", func() {
+			g.confirm("confirm.reset_settings", func() { g.applySettings(DefaultSettings()) })
+		}),
+		g.button("settings.back", g.closeSettings),
+	)
+	return m
+}
+
+// Update handles navigation, value changes and key rebinding.
+func (m *SettingsScene) Update() error {
+	if m.waitingKey {
+		for _, k := range inpututil.AppendJustPressedKeys(nil) {
+			if k != ebiten.KeyEscape {
+				m.bind(k)
+			}
+			m.waitingKey = false
+			break
+		}
+		return nil
+	}
+	if m.g.nav.Back {
+		m.g.closeSettings()
+		return nil
+	}
+	m.list.Update(m.g.nav)
+	return nil
+}
+
+// bindings returns the keys of the player chosen on the controls row:
+// player 1 has the main bindings, the others their co-op ones.
+func (m *SettingsScene) bindings() Bindings {
+	if m.bindFor == 0 {
+		return m.g.settings.Bindings
+	}
+	return m.g.settings.Coop.Bindings[m.bindFor-1]
+}
+
+// bind sets k as the only key for the action being rebound.
+func (m *SettingsScene) bind(k ebiten.Key) {
+	s := &m.g.settings
+	b := s.Bindings
+	if m.bindFor > 0 {
+		if s.Coop.Bindings[m.bindFor-1] == nil {
+			s.Coop.Bindings[m.bindFor-1] = Bindings{}
+		}
+		b = s.Coop.Bindings[m.bindFor-1]
+	}
+	b[m.rebinding] = []ebiten.Key{k}
+}
+
+// Draw renders the settings list with the focused row highlighted.
+func (m *SettingsScene) Draw(screen *ebiten.Image) {
+	g := m.g
+	drawAnchoredText(screen, g.loc.T("settings.title"), g.fonts.Large, AnchorTop, 0, 40, g.theme.Text)
+	m.list.Draw(screen, 110, g.menuStyle())
+	hint := g.loc.T("settings.hint")
+	if m.waitingKey {
+		hint = g.loc.T("settings.press_key", g.loc.T("action."+m.rebinding.String()))
+	}
+	drawAnchoredText(screen, hint, g.fonts.Small, AnchorBottom, 0, 30, g.theme.Text)
+}
+
+func (m *SettingsScene) Overlay() bool { return false }
+
+// openSettings shows the settings screen over the current scene.
+func (g *Game) openSettings() {
+	g.scenes.Push(newSettingsScene(g), SlideIn)
+}
+
+// closeSettings saves the settings and returns to the previous scene.
+func (g *Game) closeSettings() {
+	g.saveSettings()
+	g.scenes.Pop(SlideOut)
+}
+
+func percent(v float64) string {
+	return fmt.Sprintf("%d%%", int(math.Round(v*100)))
+}
+
+func keyNames(keys []ebiten.Key) string {
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// cycle moves i by dir within [0, n), wrapping around.
+func cycle(i, n, dir int) int {
+	return ((i+dir)%n + n) % n
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return 0
+}
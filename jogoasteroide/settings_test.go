@@ -0,0 +1,132 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestSettingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "settings.json")
+	s := DefaultSettings()
+	s.Audio.Master = 0.3
+	s.Language = "en-US"
+	s.Difficulty = DifficultyHard
+	s.Effects.Shake.Enabled = false
+	s.Bindings[ActionFire] = []ebiten.Key{ebiten.KeyZ}
+	if err := SaveSettings(path, s); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("LoadSettings() = %+v; esperado %+v", got, s)
+	}
+}
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		check   func(Settings) bool
+		wantErr bool
+	}{
+		{"arquivo parcial usa padrões", `{"version": 1, "audio": {"master": 0.2}}`, func(s Settings) bool {
+			return s.Audio.Master == 0.2 && s.Audio.SFX == 1 && s.Theme == Themes[0] && len(s.Bindings[ActionThrust]) == 1
+		}, false},
+		{"teclas parciais", `{"version": 1, "bindings": {"fire": ["X"]}}`, func(s Settings) bool {
+			return s.Bindings[ActionFire][0] == ebiten.KeyX && s.Bindings[ActionThrust][0] == ebiten.KeyUp
+		}, false},
+		{"sem versão", `{"difficulty": 0}`, func(s Settings) bool {
+			return s.Version == SettingsVersion && s.Difficulty == DifficultyEasy
+		}, false},
+		{"versão mais nova", `{"version": 99}`, func(s Settings) bool {
+			return reflect.DeepEqual(s, DefaultSettings())
+		}, true},
+		{"json inválido", `{`, func(s Settings) bool {
+			return reflect.DeepEqual(s, DefaultSettings())
+		}, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".json")
+			writeFile(t, path, tt.content)
+			s, err := LoadSettings(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadSettings() erro = %v; erro esperado: %v", err, tt.wantErr)
+			}
+			if !tt.check(s) {
+				t.Errorf("LoadSettings() = %+v; valores inesperados", s)
+			}
+		})
+	}
+}
+
+func TestLoadSettingsMissingFile(t *testing.T) {
+	s, err := LoadSettings(filepath.Join(t.TempDir(), "settings.json"))
+	if err != nil {
+		t.Fatalf("erro = %v; esperado nil", err)
+	}
+	if !reflect.DeepEqual(s, DefaultSettings()) {
+		t.Errorf("LoadSettings() = %+v; esperado os padrões", s)
+	}
+}
+
+func TestSettingsMigration(t *testing.T) {
+	settingsMigrations[0] = func(raw map[string]any) {
+		raw["theme"] = raw["colors"]
+		delete(raw, "colors")
+	}
+	defer delete(settingsMigrations, 0)
+
+	path := filepath.Join(t.TempDir(), "settings.json")
+	writeFile(t, path, `{"version": 0, "colors": "migrado"}`)
+	s, err := LoadSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Theme != "migrado" {
+		t.Errorf("Theme = %q; esperado \"migrado\"", s.Theme)
+	}
+}
+
+func TestRebindCoopPlayer(t *testing.T) {
+	g, _ := newTestGame()
+	g.settingsPath = filepath.Join(t.TempDir(), "settings.json")
+	fire := g.settings.Bindings[ActionFire]
+	m := newSettingsScene(g)
+	for _, w := range m.list.Items {
+		switch w := w.(type) {
+		case *Choice:
+			if w.Text() == g.loc.T("settings.controls") {
+				w.Set(1)
+			}
+		case *Button:
+			if w.Text() == g.loc.T("action.fire") {
+				w.OnPress()
+			}
+		}
+	}
+	if !m.waitingKey {
+		t.Fatal("nenhuma linha para trocar a tecla de atirar")
+	}
+	m.bind(ebiten.KeyJ)
+	g.saveSettings()
+
+	saved, err := LoadSettings(g.settingsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := saved.Coop.Bindings[0][ActionFire]; !reflect.DeepEqual(got, []ebiten.Key{ebiten.KeyJ}) {
+		t.Errorf("jogador 2 atira com %v; esperado J", got)
+	}
+	if got := saved.Bindings[ActionFire]; !reflect.DeepEqual(got, fire) {
+		t.Errorf("jogador 1 atira com %v; esperado %v", got, fire)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// KeyframeInterval is how often, in steps, a broadcast includes the
+	// whole state of the run, for spectators joining part way through.
+	KeyframeInterval = 5 * TicksPerSecond
+	// SpectateBuffer is how many steps a spectator lets pile up before it
+	// plays more than one a frame to catch up.
+	SpectateBuffer = 10
+	// spectatorQueue is how many lines a spectator's connection may fall
+	// behind before it is dropped.
+	spectatorQueue = 4096
+)
+
+// feedMessage is one line of a broadcast, in JSON.
+type feedMessage struct {
+	Kind  string    `json:"kind"` // "keyframe" or "step"
+	Step  int       `json:"step"` // the run's tick after it
+	State *SaveFile `json:"state,omitempty"`
+	// Inputs are a step's input for each player, in order.
+	Inputs []Input `json:"inputs,omitempty"`
+	// Reset marks a keyframe of a run other than the one before it.
+	Reset bool `json:"reset,omitempty"`
+	// Delay is how far behind the match the broadcast runs, in
+	// milliseconds.
+	Delay int64 `json:"delay_ms,omitempty"`
+}
+
+// Broadcaster streams a run to read-only spectators over TCP, as lines
+// of JSON: a keyframe with the whole state of the run, then the inputs of
+// every step after it, which spectators play through their own copy of
+// the simulation. A new keyframe goes out every KeyframeInterval steps and
+// whenever a new run starts; spectators that connect get the newest one
+// and the steps since, so they can join at any time.
+type Broadcaster struct {
+	// Delay holds everything back this long before spectators see it.
+	Delay time.Duration
+
+	ln      net.Listener
+	config  RunConfig
+	tick    int
+	mu      sync.Mutex
+	pending []pendingLine // recorded but not yet due
+	key     []byte        // the newest keyframe sent
+	since   [][]byte      // the steps sent after it
+	clients map[chan []byte]bool
+	done    chan struct{}
+}
+
+type pendingLine struct {
+	due  time.Time
+	key  bool
+	line []byte
+}
+
+// NewBroadcaster streams to spectators connecting to ln, delay behind the
+// match.
+func NewBroadcaster(ln net.Listener, delay time.Duration) *Broadcaster {
+	b := &Broadcaster{Delay: delay, ln: ln, clients: map[chan []byte]bool{}, done: make(chan struct{})}
+	go b.accept()
+	go b.release()
+	return b
+}
+
+// Close stops the broadcast and disconnects the spectators.
+func (b *Broadcaster) Close() error {
+	close(b.done)
+	err := b.ln.Close()
+	b.mu.Lock()
+	for c := range b.clients {
+		close(c)
+		delete(b.clients, c)
+	}
+	b.mu.Unlock()
+	return err
+}
+
+// Record adds g's newest step to the broadcast, or a keyframe when g has
+// started another run, resumed one or skipped ahead. Call it after every
+// step; calls without a new step are ignored. A nil broadcaster records
+// nothing.
+func (b *Broadcaster) Record(g *Game) {
+	if b == nil || g.tick == b.tick && g.config == b.config {
+		return
+	}
+	follows := g.config == b.config && g.tick == b.tick+1
+	b.config, b.tick = g.config, g.tick
+	if follows {
+		in := g.inputs[len(g.inputs)-len(g.players):]
+		b.queue(feedMessage{Kind: "step", Step: g.tick, Inputs: append([]Input(nil), in...)})
+	}
+	if !follows || g.tick%KeyframeInterval == 0 {
+		st, err := g.state()
+		if err != nil {
+			log.Printf("broadcast: %v", err)
+			return
+		}
+		b.queue(feedMessage{Kind: "keyframe", Step: g.tick, State: &st, Reset: !follows, Delay: b.Delay.Milliseconds()})
+	}
+}
+
+func (b *Broadcaster) queue(m feedMessage) {
+	line, err := json.Marshal(m)
+	if err != nil {
+		log.Printf("broadcast: %v", err)
+		return
+	}
+	line = append(line, '\n')
+	b.mu.Lock()
+	b.pending = append(b.pending, pendingLine{time.Now().Add(b.Delay), m.Kind == "keyframe", line})
+	b.mu.Unlock()
+}
+
+// release sends the lines that are due, in order.
+func (b *Broadcaster) release() {
+	t := time.NewTicker(time.Second / TicksPerSecond / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case now := <-t.C:
+			b.mu.Lock()
+			n := 0
+			for _, p := range b.pending {
+				if p.due.After(now) {
+					break
+				}
+				n++
+				if p.key {
+					b.key, b.since = p.line, nil
+				} else if b.key != nil {
+					b.since = append(b.since, p.line)
+				}
+				for c := range b.clients {
+					b.deliver(c, p.line)
+				}
+			}
+			b.pending = b.pending[n:]
+			b.mu.Unlock()
+		}
+	}
+}
+
+// deliver queues line for one spectator, dropping the spectator if it has
+// fallen too far behind. b.mu must be held.
+func (b *Broadcaster) deliver(c chan []byte, line []byte) {
+	select {
+	case c <- line:
+	default:
+		close(c)
+		delete(b.clients, c)
+	}
+}
+
+func (b *Broadcaster) accept() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("broadcast: %v", err)
+			}
+			return
+		}
+		c := make(chan []byte, spectatorQueue)
+		b.mu.Lock()
+		if b.key != nil {
+			b.deliver(c, b.key)
+			for _, line := range b.since {
+				b.deliver(c, line)
+			}
+		}
+		b.clients[c] = true
+		b.mu.Unlock()
+		go func() {
+			defer conn.Close()
+			w := bufio.NewWriter(conn)
+			for line := range c {
+				if _, err := w.Write(line); err != nil {
+					break
+				}
+				if len(c) == 0 {
+					if err := w.Flush(); err != nil {
+						break
+					}
+				}
+			}
+			// Drain so the broadcaster never blocks on a dead spectator.
+			for range c {
+			}
+		}()
+	}
+}
+
+// Spectator follows a broadcast, playing it in its own game.
+type Spectator struct {
+	game   *Game
+	conn   net.Conn
+	feed   chan feedMessage
+	queue  []feedMessage // received, not yet played
+	synced bool          // a keyframe has been restored
+	Delay  time.Duration // how far behind the match the broadcast runs
+	mu     sync.Mutex
+	err    error // why the feed ended
+}
+
+// DialSpectator connects to the broadcast at addr and plays it in g.
+func DialSpectator(g *Game, addr string) (*Spectator, error) {
+	conn, err := net.DialTimeout("tcp", addr, SubmitTimeout)
+	if err != nil {
+		return nil, err
+	}
+	s := &Spectator{game: g, conn: conn, feed: make(chan feedMessage, spectatorQueue)}
+	go s.read()
+	return s, nil
+}
+
+func (s *Spectator) read() {
+	defer close(s.feed)
+	sc := bufio.NewScanner(s.conn)
+	sc.Buffer(nil, MaxSubmissionBytes)
+	for sc.Scan() {
+		var m feedMessage
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			s.fail(err)
+			return
+		}
+		s.feed <- m
+	}
+	err := sc.Err()
+	if err == nil {
+		err = errors.New("the broadcast ended")
+	}
+	s.fail(err)
+}
+
+func (s *Spectator) fail(err error) {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.mu.Unlock()
+}
+
+// Err returns why the feed ended, or nil while it goes on.
+func (s *Spectator) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close disconnects.
+func (s *Spectator) Close() error {
+	return s.conn.Close()
+}
+
+// Synced reports whether the spectator has a run to show.
+func (s *Spectator) Synced() bool {
+	return s.synced
+}
+
+// Update takes in what has arrived and plays a step of it, or more when
+// it has fallen behind.
+func (s *Spectator) Update() {
+	for len(s.feed) > 0 {
+		m, ok := <-s.feed
+		if !ok {
+			break
+		}
+		s.queue = append(s.queue, m)
+	}
+	g := s.game
+	for n := max(1, len(s.queue)-SpectateBuffer); n > 0 && len(s.queue) > 0; n-- {
+		m := s.queue[0]
+		s.queue = s.queue[1:]
+		switch {
+		case m.Kind == "keyframe":
+			s.keyframe(m)
+			n++
+		case m.Kind != "step" || !s.synced || m.Step <= g.tick || !g.running:
+			// Already in the keyframe, or the run has ended here a step or
+			// two before the match, its slow motion timed differently.
+			n++
+		case m.Step > g.tick+1 || len(m.Inputs) != len(g.players):
+			// A step is missing: wait for the next keyframe.
+			s.synced = false
+		default:
+			g.updatePlaying(m.Inputs...)
+		}
+	}
+}
+
+// keyframe jumps to the keyframe's state, unless the spectator is already
+// following the run there.
+func (s *Spectator) keyframe(m feedMessage) {
+	g := s.game
+	if m.State == nil {
+		return
+	}
+	s.Delay = time.Duration(m.Delay) * time.Millisecond
+	if s.synced && !m.Reset && m.State.Config == g.config && m.Step == g.tick {
+		return
+	}
+	if err := g.restore(*m.State); err != nil {
+		log.Printf("spectate: %v", err)
+		s.synced = false
+		return
+	}
+	s.synced = true
+}
+
+// SpectateScene shows a broadcast match.
+type SpectateScene struct {
+	g *Game
+	s *Spectator
+}
+
+// spectate connects to the broadcast at addr and shows it.
+func (g *Game) spectate(addr string) error {
+	s, err := DialSpectator(g.mirrorGame(), addr)
+	if err != nil {
+		return err
+	}
+	g.scenes.Reset(&SpectateScene{g: g, s: s}, Fade)
+	return nil
+}
+
+func (v *SpectateScene) Update() error {
+	g := v.g
+	if g.settings.Bindings.JustPressed(ActionPause) || g.nav.Back {
+		v.s.Close()
+		g.quitToMenu()
+		return nil
+	}
+	v.s.Update()
+	v.s.game.camera.Update()
+	return nil
+}
+
+func (v *SpectateScene) Draw(screen *ebiten.Image) {
+	g, s := v.g, v.s
+	if s.Synced() {
+		s.game.drawPlaying(screen)
+		label := g.loc.T("spectate.label", int(s.Delay.Round(time.Second)/time.Second))
+		drawAnchoredText(screen, label, g.fonts.Small, AnchorBottom, 0, 20, g.theme.Message)
+	}
+	var status string
+	switch {
+	case s.Err() != nil && len(s.queue) == 0:
+		status = g.loc.T("net.lost")
+	case !s.Synced():
+		status = g.loc.T("spectate.waiting")
+	case !s.game.running || s.game.dying:
+		status = g.loc.T("net.over")
+	default:
+		return
+	}
+	drawAnchoredText(screen, status+"\n"+g.loc.T("net.leave"), g.fonts.Normal, AnchorCenter, 0, -120, g.theme.Message)
+}
+
+func (v *SpectateScene) Overlay() bool { return false }
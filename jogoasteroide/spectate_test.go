@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSpectate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   RunConfig
+		delay time.Duration
+	}{
+		{"co-op ao vivo", RunConfig{Mode: ModeClassic, Seed: 1, Players: 2}, 0},
+		{"versus com atraso", RunConfig{Mode: ModeVersus, Seed: 2, Players: 2, Rounds: 3}, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets, err := NewAssetStore()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := NewBroadcaster(ln, tt.delay)
+			defer b.Close()
+			var spectators []*Spectator
+			watch := func() {
+				s, err := DialSpectator(newSimGame(assets), ln.Addr().String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { s.Close() })
+				spectators = append(spectators, s)
+			}
+			live := newSimGame(assets)
+			start := func(cfg RunConfig) {
+				live.ResetRun(cfg)
+				// Shields up, so a stray asteroid cannot end the run early.
+				for i := range live.players {
+					live.players[i].shield = math.MaxInt32
+				}
+			}
+			watch()
+			start(tt.cfg)
+			rng := NewRNG(tt.cfg.Seed)
+			in := make([]Input, len(live.players))
+			for step := range 3*KeyframeInterval + 40 {
+				switch step {
+				case KeyframeInterval + 10:
+					// Joins part way through, from a keyframe.
+					watch()
+				case 2*KeyframeInterval + 20:
+					// Another run, as after a retry.
+					cfg := tt.cfg
+					cfg.Seed += 100
+					start(cfg)
+				}
+				if step%8 == 0 {
+					for i := range in {
+						in[i] = Input(rng.IntN(1 << actionCount))
+					}
+				}
+				live.updatePlaying(in...)
+				b.Record(live)
+				for _, s := range spectators {
+					s.Update()
+				}
+				time.Sleep(time.Millisecond / 4)
+			}
+			recorded := time.Now()
+			caughtUp := func(s *Spectator) bool {
+				return s.Synced() && s.game.config == live.config && s.game.tick == live.tick
+			}
+			for i, s := range spectators {
+				for !caughtUp(s) {
+					if time.Since(recorded) > 5*time.Second {
+						t.Fatalf("espectador %d parou no passo %d de %d", i, s.game.tick, live.tick)
+					}
+					s.Update()
+					time.Sleep(time.Millisecond)
+				}
+				// Allowing for the last step being recorded just before.
+				if wait := time.Since(recorded); wait < tt.delay*9/10 {
+					t.Errorf("espectador %d alcançou a partida em %v; esperado atraso de %v", i, wait, tt.delay)
+				}
+				if s.Delay != tt.delay {
+					t.Errorf("espectador %d: atraso %v, esperado %v", i, s.Delay, tt.delay)
+				}
+				if got, want := s.game.checksum(), live.checksum(); got != want {
+					t.Errorf("espectador %d: soma %08x, esperado %08x", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// StatsVersion is the lifetime statistics format written by this build.
+const StatsVersion = 1
+
+// StatsHistory is how many past runs are kept for export.
+const StatsHistory = 100
+
+// AsteroidSize groups asteroids for statistics and sound.
+type AsteroidSize int
+
+const (
+	SizeSmall AsteroidSize = iota
+	SizeMedium
+	SizeLarge
+	sizeCount
+)
+
+var sizeNames = [sizeCount]string{"small", "medium", "large"}
+
+func (s AsteroidSize) String() string {
+	return sizeNames[s]
+}
+
+// sizeClass returns the group an asteroid of the given diameter is in.
+func sizeClass(size float64) AsteroidSize {
+	switch {
+	case size >= 70:
+		return SizeLarge
+	case size >= 40:
+		return SizeMedium
+	default:
+		return SizeSmall
+	}
+}
+
+// RunStats counts what happened during one run. Lifetime totals use the
+// same type.
+type RunStats struct {
+	Score       int               `json:"score"`
+	Ticks       int               `json:"ticks"`
+	Distance    float64           `json:"distance"` // pixels flown
+	ShotsFired  int               `json:"shots_fired"`
+	ShotsHit    int               `json:"shots_hit"`
+	Asteroids   [sizeCount]int    `json:"asteroids"`
+	PowerUps    [powerUpCount]int `json:"power_ups"`
+	DamageTaken int               `json:"damage_taken"`
+}
+
+// Record counts an event.
+func (s *RunStats) Record(e Event) {
+	switch e.Kind {
+	case EventShot:
+		s.ShotsFired++
+	case EventAsteroidDestroyed:
+		s.ShotsHit++
+		s.Asteroids[sizeClass(e.Size)]++
+	case EventPowerUp:
+		s.PowerUps[e.PowerUp]++
+	case EventDamage:
+		s.DamageTaken++
+	}
+}
+
+// Advance counts one tick in which the ship moved distance pixels.
+func (s *RunStats) Advance(distance float64) {
+	s.Ticks++
+	s.Distance += distance
+}
+
+// Accuracy is the fraction of shots that destroyed an asteroid.
+func (s RunStats) Accuracy() float64 {
+	if s.ShotsFired == 0 {
+		return 0
+	}
+	return float64(s.ShotsHit) / float64(s.ShotsFired)
+}
+
+// Duration is the time the run lasted.
+func (s RunStats) Duration() time.Duration {
+	return time.Duration(s.Ticks) * time.Second / TicksPerSecond
+}
+
+// AsteroidsDestroyed is the total over every size.
+func (s RunStats) AsteroidsDestroyed() int {
+	n := 0
+	for _, c := range s.Asteroids {
+		n += c
+	}
+	return n
+}
+
+// PowerUpsCollected is the total over every type.
+func (s RunStats) PowerUpsCollected() int {
+	n := 0
+	for _, c := range s.PowerUps {
+		n += c
+	}
+	return n
+}
+
+func (s *RunStats) add(o RunStats) {
+	s.Score += o.Score
+	s.Ticks += o.Ticks
+	s.Distance += o.Distance
+	s.ShotsFired += o.ShotsFired
+	s.ShotsHit += o.ShotsHit
+	for i := range s.Asteroids {
+		s.Asteroids[i] += o.Asteroids[i]
+	}
+	for i := range s.PowerUps {
+		s.PowerUps[i] += o.PowerUps[i]
+	}
+	s.DamageTaken += o.DamageTaken
+}
+
+// RunRecord is a finished run as kept in the history.
+type RunRecord struct {
+	Time       time.Time  `json:"time"`
+	Mode       string     `json:"mode,omitempty"`
+	Difficulty Difficulty `json:"difficulty"`
+	RunStats
+}
+
+// LifetimeStats accumulates every finished run.
+type LifetimeStats struct {
+	Version    int         `json:"version"`
+	Runs       int         `json:"runs"`
+	BestScore  int         `json:"best_score"`
+	LongestRun int         `json:"longest_run"` // ticks
+	Totals     RunStats    `json:"totals"`
+	History    []RunRecord `json:"history"` // newest last, at most StatsHistory
+}
+
+// Add counts a finished run. Only classic runs set the best score; daily
+// challenges have their own leaderboard.
+func (l *LifetimeStats) Add(r RunRecord) {
+	l.Runs++
+	if r.Mode == "" || r.Mode == ModeClassic {
+		l.BestScore = max(l.BestScore, r.Score)
+	}
+	l.LongestRun = max(l.LongestRun, r.Ticks)
+	l.Totals.add(r.RunStats)
+	l.History = append(l.History, r)
+	if n := len(l.History); n > StatsHistory {
+		l.History = append(l.History[:0], l.History[n-StatsHistory:]...)
+	}
+}
+
+// LoadStats reads lifetime statistics from path. A missing file is not an
+// error; a file from a newer version is, so it is not overwritten.
+func LoadStats(path string) (LifetimeStats, error) {
+	l := LifetimeStats{Version: StatsVersion}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	var read LifetimeStats
+	if err := json.Unmarshal(data, &read); err != nil {
+		return l, fmt.Errorf("%s: %v", path, err)
+	}
+	if read.Version > StatsVersion {
+		return l, fmt.Errorf("%s: stats version %d is newer than this game (%d)", path, read.Version, StatsVersion)
+	}
+	read.Version = StatsVersion
+	return read, nil
+}
+
+// SaveStats writes l to path.
+func SaveStats(path string, l LifetimeStats) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// WriteStatsJSON exports l as indented JSON.
+func WriteStatsJSON(w io.Writer, l LifetimeStats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// WriteStatsCSV exports the run history, one row per run, for
+// spreadsheets.
+func WriteStatsCSV(w io.Writer, l LifetimeStats) error {
+	header := []string{"time", "mode", "difficulty", "score", "seconds", "distance", "shots_fired", "shots_hit", "accuracy", "damage_taken"}
+	for s := AsteroidSize(0); s < sizeCount; s++ {
+		header = append(header, "asteroids_"+s.String())
+	}
+	for t := PowerUpType(0); t < powerUpCount; t++ {
+		header = append(header, "power_ups_"+t.String())
+	}
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	for _, r := range l.History {
+		row := []string{
+			r.Time.UTC().Format(time.RFC3339),
+			cmp.Or(r.Mode, ModeClassic),
+			r.Difficulty.String(),
+			strconv.Itoa(r.Score),
+			strconv.FormatFloat(r.Duration().Seconds(), 'f', 2, 64),
+			strconv.FormatFloat(math.Round(r.Distance), 'f', 0, 64),
+			strconv.Itoa(r.ShotsFired),
+			strconv.Itoa(r.ShotsHit),
+			strconv.FormatFloat(r.Accuracy(), 'f', 4, 64),
+			strconv.Itoa(r.DamageTaken),
+		}
+		for _, n := range r.Asteroids {
+			row = append(row, strconv.Itoa(n))
+		}
+		for _, n := range r.PowerUps {
+			row = append(row, strconv.Itoa(n))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// recordRun adds the finished run to the lifetime statistics and saves
+// them.
+func (g *Game) recordRun() {
+	g.stats.Score = g.score
+	g.lifetime.Add(RunRecord{Time: time.Now(), Mode: g.config.Mode, Difficulty: g.config.Difficulty, RunStats: g.stats})
+	if g.statsPath == "" {
+		return
+	}
+	if err := SaveStats(g.statsPath, g.lifetime); err != nil {
+		log.Printf("saving stats: %v", err)
+	}
+}
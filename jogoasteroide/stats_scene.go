@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// statRow is one label and value of a statistics table.
+type statRow struct {
+	Label, Value string
+}
+
+// statRows describes s for the post-game summary and the stats screen.
+// The time row is left to the caller, which knows whether it is one run
+// or a lifetime.
+func (g *Game) statRows(s RunStats) []statRow {
+	loc := g.loc
+	a, p := s.Asteroids, s.PowerUps
+	return []statRow{
+		{loc.T("stats.distance"), loc.T("stats.distance_value", int(math.Round(s.Distance)))},
+		{loc.T("stats.shots"), fmt.Sprint(s.ShotsFired)},
+		{loc.T("stats.accuracy"), percent(s.Accuracy())},
+		{loc.T("stats.damage"), fmt.Sprint(s.DamageTaken)},
+		{loc.T("stats.asteroids"), loc.T("stats.asteroids_value",
+			s.AsteroidsDestroyed(), a[SizeSmall], a[SizeMedium], a[SizeLarge])},
+		{loc.T("stats.power_ups"), loc.T("stats.power_ups_value", s.PowerUpsCollected(),
+			p[PowerUpShield], p[PowerUpRapidFire], p[PowerUpMultiShot], p[PowerUpExtraLife])},
+	}
+}
+
+// drawStatRows draws rows like a List without focus: labels on the left,
+// values right-aligned.
+func drawStatRows(dst *ebiten.Image, rows []statRow, y float64, st ListStyle) {
+	rowH := lineHeight(st.Face) + 6
+	left := (ScreenWidth - st.Width) / 2
+	for i, r := range rows {
+		ry := y + float64(i)*rowH
+		DrawText(dst, r.Label, st.Face, left, ry, AlignLeft, st.Color)
+		vw, _ := MeasureText(st.Face, r.Value)
+		DrawText(dst, r.Value, st.Face, left+st.Width-vw, ry, AlignLeft, st.Color)
+	}
+}
+
+// formatDuration shows d as m:ss, or h:mm:ss from an hour up.
+func formatDuration(d time.Duration) string {
+	s := int(d.Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// StatsScene shows the lifetime statistics and exports them.
+type StatsScene struct {
+	g      *Game
+	list   List
+	notice string
+}
+
+func newStatsScene(g *Game) *StatsScene {
+	s := &StatsScene{g: g}
+	s.list.Items = []Widget{
+		g.button("stats.export_csv", func() { s.export("stats.csv", WriteStatsCSV) }),
+		g.button("stats.export_json", func() { s.export("stats-export.json", WriteStatsJSON) }),
+		g.button("settings.back", func() { g.scenes.Pop(SlideOut) }),
+	}
+	return s
+}
+
+// export writes the statistics next to the stats file and reports where.
+func (s *StatsScene) export(name string, write func(w io.Writer, l LifetimeStats) error) {
+	g := s.g
+	if g.statsPath == "" {
+		s.notice = g.loc.T("stats.export_failed")
+		return
+	}
+	path := filepath.Join(filepath.Dir(g.statsPath), name)
+	var buf bytes.Buffer
+	err := write(&buf, g.lifetime)
+	if err == nil {
+		err = writeFileAtomic(path, buf.Bytes())
+	}
+	if err != nil {
+		s.notice = g.loc.T("stats.export_failed")
+		return
+	}
+	s.notice = g.loc.T("stats.exported", path)
+}
+
+func (s *StatsScene) Update() error {
+	if s.g.nav.Back {
+		s.g.scenes.Pop(SlideOut)
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *StatsScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	l := g.lifetime
+	drawAnchoredText(screen, g.loc.T("stats.title"), g.fonts.Large, AnchorTop, 0, 40, g.theme.Text)
+	rows := []statRow{
+		{g.loc.T("stats.runs"), fmt.Sprint(l.Runs)},
+		{g.loc.T("stats.best"), fmt.Sprint(l.BestScore)},
+		{g.loc.T("stats.total_score"), fmt.Sprint(l.Totals.Score)},
+		{g.loc.T("stats.time_played"), formatDuration(l.Totals.Duration())},
+		{g.loc.T("stats.longest"), formatDuration(RunStats{Ticks: l.LongestRun}.Duration())},
+	}
+	rows = append(rows, g.statRows(l.Totals)...)
+	drawStatRows(screen, rows, 110, g.menuStyle())
+	s.list.Draw(screen, 470, g.menuStyle())
+	if s.notice != "" {
+		drawAnchoredText(screen, s.notice, g.fonts.Small, AnchorBottom, 0, 40, g.theme.Message)
+	}
+}
+
+func (s *StatsScene) Overlay() bool { return false }
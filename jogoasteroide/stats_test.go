@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunStatsFromGameplay(t *testing.T) {
+	g, _ := newTestGame()
+	g.settings.Effects.SlowMotion.Enabled = false
+	g.players[0].multiShot = 600
+	g.fireBullet(&g.players[0])
+	g.fireBullet(&g.players[0])
+	for _, size := range []float64{90, 50, 25} {
+		pos := Vector{X: 300, Y: 300}
+		g.asteroids = append(g.asteroids[:0], Asteroid{position: pos, size: size})
+		b := g.bulletPool.Get()
+		b.position = pos
+		g.bullets = append(g.bullets[:0], b)
+		g.updateBullets()
+	}
+	g.applyPowerUp(&g.players[0], PowerUpRapidFire)
+	g.applyPowerUp(&g.players[0], PowerUpRapidFire)
+	g.applyPowerUp(&g.players[0], PowerUpExtraLife)
+	g.asteroids = append(g.asteroids[:0], Asteroid{position: g.players[0].position, size: 60})
+	g.updatePlaying(0)
+	g.players[0].shield = 100
+	g.updatePlaying(0)
+
+	s := g.stats
+	tests := []struct {
+		name      string
+		got, want int
+	}{
+		{"tiros", s.ShotsFired, 6},
+		{"acertos", s.ShotsHit, 3},
+		{"asteroides grandes", s.Asteroids[SizeLarge], 1},
+		{"asteroides médios", s.Asteroids[SizeMedium], 1},
+		{"asteroides pequenos", s.Asteroids[SizeSmall], 1},
+		{"tiro rápido", s.PowerUps[PowerUpRapidFire], 2},
+		{"vida extra", s.PowerUps[PowerUpExtraLife], 1},
+		{"dano (o escudo bloqueia o segundo)", s.DamageTaken, 1},
+		{"ticks", s.Ticks, 2},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d; esperado %d", tt.name, tt.got, tt.want)
+		}
+	}
+	if s.Accuracy() != 0.5 {
+		t.Errorf("Accuracy() = %v; esperado 0.5", s.Accuracy())
+	}
+}
+
+func TestEndRunRecordsLifetimeStats(t *testing.T) {
+	g, _ := newTestGame()
+	g.statsPath = filepath.Join(t.TempDir(), "stats.json")
+	for _, score := range []int{300, 700} {
+		g.startRun()
+		g.stats.ShotsFired = 10
+		g.score = score
+		g.endRun()
+	}
+	l, err := LoadStats(g.statsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Runs != 2 || l.BestScore != 700 || l.Totals.Score != 1000 || l.Totals.ShotsFired != 20 {
+		t.Errorf("estatísticas = %+v; esperado 2 partidas, melhor 700, total 1000, 20 tiros", l)
+	}
+	if len(l.History) != 2 || l.History[1].Score != 700 {
+		t.Errorf("histórico = %+v; esperado as duas partidas em ordem", l.History)
+	}
+}
+
+func TestLifetimeHistoryIsCapped(t *testing.T) {
+	var l LifetimeStats
+	for i := 0; i < StatsHistory+5; i++ {
+		l.Add(RunRecord{RunStats: RunStats{Score: i}})
+	}
+	if len(l.History) != StatsHistory || l.History[0].Score != 5 || l.Runs != StatsHistory+5 {
+		t.Errorf("histórico com %d partidas começando em %d; esperado %d começando em 5",
+			len(l.History), l.History[0].Score, StatsHistory)
+	}
+}
+
+func TestLoadStats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		content  string // empty means no file
+		wantRuns int
+		wantErr  bool
+	}{
+		{"sem arquivo", "", 0, false},
+		{"válido", `{"version": 1, "runs": 4}`, 4, false},
+		{"versão mais nova", `{"version": 2, "runs": 4}`, 0, true},
+		{"corrompido", `{"runs": "quatro"}`, 0, true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".json")
+			if tt.content != "" {
+				writeFile(t, path, tt.content)
+			}
+			l, err := LoadStats(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadStats() erro = %v; esperado erro: %v", err, tt.wantErr)
+			}
+			if l.Runs != tt.wantRuns || l.Version != StatsVersion {
+				t.Errorf("LoadStats() = %+v; esperado %d partidas na versão %d", l, tt.wantRuns, StatsVersion)
+			}
+		})
+	}
+}
+
+func TestWriteStatsCSV(t *testing.T) {
+	var l LifetimeStats
+	l.Add(RunRecord{
+		Time:       time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Difficulty: DifficultyHard,
+		RunStats:   RunStats{Score: 1200, Ticks: 90, ShotsFired: 4, ShotsHit: 1, Asteroids: [sizeCount]int{1, 0, 0}},
+	})
+	var buf bytes.Buffer
+	if err := WriteStatsCSV(&buf, l); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("%d linhas; esperado cabeçalho e uma partida", len(records))
+	}
+	row := map[string]string{}
+	for i, col := range records[0] {
+		row[col] = records[1][i]
+	}
+	want := map[string]string{
+		"time":             "2026-01-02T03:04:05Z",
+		"difficulty":       "hard",
+		"score":            "1200",
+		"seconds":          "1.50",
+		"accuracy":         "0.2500",
+		"asteroids_small":  "1",
+		"power_ups_shield": "0",
+	}
+	for col, v := range want {
+		if row[col] != v {
+			t.Errorf("coluna %s = %q; esperado %q", col, row[col], v)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0:00"},
+		{75 * time.Second, "1:15"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+	}
+	for _, tt := range tests {
+		if got := formatDuration(tt.d); got != tt.want {
+			t.Errorf("formatDuration(%v) = %q; esperado %q", tt.d, got, tt.want)
+		}
+	}
+}
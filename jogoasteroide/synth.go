@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// SampleRate is the audio sample rate used for every synthesised sound.
+const SampleRate = 44100
+
+type waveform int
+
+const (
+	waveSine waveform = iota
+	waveSquare
+	waveSaw
+	waveTriangle
+	waveNoise
+)
+
+// tone is one voice of a synthesised sound: a waveform with a linear
+// pitch sweep, a simple attack/release envelope and an optional one-pole
+// low-pass filter (smooth in [0, 1), higher is darker).
+type tone struct {
+	wave     waveform
+	start    float64 // seconds from the beginning of the sound
+	duration float64 // seconds
+	freq     float64 // Hz at the start
+	freqEnd  float64 // Hz at the end; 0 keeps freq
+	attack   float64 // seconds
+	release  float64 // seconds
+	volume   float64
+	smooth   float64
+}
+
+// synth renders the tones into mono samples in [-1, 1].
+func synth(tones ...tone) []float64 {
+	length := 0.0
+	for _, t := range tones {
+		length = math.Max(length, t.start+t.duration)
+	}
+	out := make([]float64, int(length*SampleRate))
+	// A fixed LCG keeps noise identical between runs, so sounds never
+	// depend on (or disturb) the game's random number generator.
+	noise := uint32(0x9e3779b9)
+	for _, t := range tones {
+		first := int(t.start * SampleRate)
+		n := int(t.duration * SampleRate)
+		freqEnd := t.freqEnd
+		if freqEnd == 0 {
+			freqEnd = t.freq
+		}
+		phase, filtered := 0.0, 0.0
+		for i := 0; i < n && first+i < len(out); i++ {
+			at := float64(i) / SampleRate
+			progress := float64(i) / float64(n)
+			phase += (t.freq + (freqEnd-t.freq)*progress) / SampleRate
+			phase -= math.Floor(phase)
+
+			var v float64
+			switch t.wave {
+			case waveSine:
+				v = math.Sin(2 * math.Pi * phase)
+			case waveSquare:
+				v = 1
+				if phase >= 0.5 {
+					v = -1
+				}
+			case waveSaw:
+				v = 2*phase - 1
+			case waveTriangle:
+				v = 4*math.Abs(phase-0.5) - 1
+			case waveNoise:
+				noise = noise*1664525 + 1013904223
+				v = float64(noise)/float64(math.MaxUint32)*2 - 1
+			}
+			filtered += (v - filtered) * (1 - t.smooth)
+
+			env := 1.0
+			if t.attack > 0 && at < t.attack {
+				env = at / t.attack
+			}
+			if rest := t.duration - at; t.release > 0 && rest < t.release {
+				env *= rest / t.release
+			}
+			out[first+i] += filtered * env * t.volume
+		}
+	}
+	return out
+}
+
+// encodeStereo converts mono samples to interleaved little-endian float32
+// stereo, the format ebiten's F32 audio players expect, applying a gain
+// to each channel.
+func encodeStereo(samples []float64, left, right float64) []byte {
+	buf := make([]byte, len(samples)*8)
+	clamp := func(v float64) float32 {
+		return float32(math.Max(-1, math.Min(1, v)))
+	}
+	for i, s := range samples {
+		binary.LittleEndian.PutUint32(buf[i*8:], math.Float32bits(clamp(s*left)))
+		binary.LittleEndian.PutUint32(buf[i*8+4:], math.Float32bits(clamp(s*right)))
+	}
+	return buf
+}
+
+// notes returns back-to-back tones of the same shape, one per frequency.
+func notes(base tone, step float64, freqs ...float64) []tone {
+	ts := make([]tone, len(freqs))
+	for i, f := range freqs {
+		t := base
+		t.start = base.start + float64(i)*step
+		t.freq = f
+		ts[i] = t
+	}
+	return ts
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// PowerUpGlyphSize is the drawn size of a power-up, a little larger than
+// its pickup radius so the shape reads at a glance.
+const PowerUpGlyphSize = 28
+
+// Theme holds every colour the game draws with.
+type Theme struct {
+	Background color.Color
+	Letterbox  color.Color
+	Text       color.Color
+	Highlight  color.Color // focused menu rows and the game-over text
+	Message    color.Color // in-game notices such as "you were hit"
+
+	Bullet    color.Color
+	Explosion color.Color
+	Thrust    color.Color
+	Shield    color.Color
+	PowerUps  [powerUpCount]color.Color
+	// Outline surrounds power-up glyphs so they stand out from the
+	// background whatever their colour.
+	Outline color.Color
+	// SpriteBrightness scales the ship and asteroid sprites, which are
+	// drawn for a light background.
+	SpriteBrightness float32
+
+	HealthFill, HealthEmpty     color.Color
+	CooldownFill, CooldownEmpty color.Color
+	// Players tint the ships and HUD panels of a co-op run.
+	Players [MaxPlayers]color.Color
+}
+
+// Themes lists the colour themes that can be picked in the settings, in
+// menu order.
+var Themes = []string{"light", "dark", "high_contrast", "deuteranopia", "protanopia"}
+
+// The colour-blind palettes are built from the Okabe-Ito set, whose
+// colours stay apart for red-green colour blindness, and never tell two
+// things apart by hue alone where a brightness difference can do it.
+var themes = map[string]Theme{
+	"light": {
+		Background: color.White,
+		Letterbox:  color.Black,
+		Text:       color.RGBA{107, 114, 128, 255},
+		Highlight:  color.RGBA{255, 69, 0, 255},
+		Message:    color.RGBA{255, 0, 0, 255},
+		Bullet:     color.Black,
+		Explosion:  color.RGBA{255, 69, 0, 160},
+		Thrust:     color.RGBA{255, 165, 0, 200},
+		Shield:     color.RGBA{0, 255, 255, 128},
+		PowerUps: [powerUpCount]color.Color{
+			PowerUpShield:    color.RGBA{0, 255, 255, 255},
+			PowerUpRapidFire: color.RGBA{255, 255, 0, 255},
+			PowerUpMultiShot: color.RGBA{255, 0, 255, 255},
+			PowerUpExtraLife: color.RGBA{0, 255, 0, 255},
+		},
+		Outline:          color.RGBA{55, 65, 81, 255},
+		SpriteBrightness: 1,
+		HealthFill:       color.RGBA{0, 255, 0, 255},
+		HealthEmpty:      color.RGBA{255, 0, 0, 255},
+		CooldownFill:     color.RGBA{255, 255, 0, 255},
+		CooldownEmpty:    color.RGBA{0, 0, 255, 255},
+		Players: [MaxPlayers]color.Color{
+			color.RGBA{37, 99, 235, 255},
+			color.RGBA{220, 38, 38, 255},
+			color.RGBA{22, 163, 74, 255},
+			color.RGBA{147, 51, 234, 255},
+		},
+	},
+	"dark": {
+		Background: color.RGBA{17, 24, 39, 255},
+		Letterbox:  color.Black,
+		Text:       color.RGBA{209, 213, 219, 255},
+		Highlight:  color.RGBA{251, 146, 60, 255},
+		Message:    color.RGBA{248, 113, 113, 255},
+		Bullet:     color.RGBA{243, 244, 246, 255},
+		Explosion:  color.RGBA{251, 146, 60, 160},
+		Thrust:     color.RGBA{251, 191, 36, 200},
+		Shield:     color.RGBA{34, 211, 238, 110},
+		PowerUps: [powerUpCount]color.Color{
+			PowerUpShield:    color.RGBA{34, 211, 238, 255},
+			PowerUpRapidFire: color.RGBA{250, 204, 21, 255},
+			PowerUpMultiShot: color.RGBA{232, 121, 249, 255},
+			PowerUpExtraLife: color.RGBA{74, 222, 128, 255},
+		},
+		Outline:          color.RGBA{243, 244, 246, 255},
+		SpriteBrightness: 1.8,
+		HealthFill:       color.RGBA{34, 197, 94, 255},
+		HealthEmpty:      color.RGBA{127, 29, 29, 255},
+		CooldownFill:     color.RGBA{250, 204, 21, 255},
+		CooldownEmpty:    color.RGBA{30, 58, 138, 255},
+		Players: [MaxPlayers]color.Color{
+			color.RGBA{96, 165, 250, 255},
+			color.RGBA{248, 113, 113, 255},
+			color.RGBA{74, 222, 128, 255},
+			color.RGBA{192, 132, 252, 255},
+		},
+	},
+	"high_contrast": {
+		Background: color.Black,
+		Letterbox:  color.RGBA{64, 64, 64, 255},
+		Text:       color.White,
+		Highlight:  color.RGBA{255, 255, 0, 255},
+		Message:    color.RGBA{255, 255, 0, 255},
+		Bullet:     color.White,
+		Explosion:  color.RGBA{255, 255, 0, 200},
+		Thrust:     color.RGBA{255, 255, 0, 230},
+		Shield:     color.RGBA{0, 255, 255, 150},
+		PowerUps: [powerUpCount]color.Color{
+			PowerUpShield:    color.RGBA{0, 255, 255, 255},
+			PowerUpRapidFire: color.RGBA{255, 255, 0, 255},
+			PowerUpMultiShot: color.RGBA{255, 0, 255, 255},
+			PowerUpExtraLife: color.White,
+		},
+		Outline:          color.White,
+		SpriteBrightness: 2.2,
+		HealthFill:       color.White,
+		HealthEmpty:      color.RGBA{90, 90, 90, 255},
+		CooldownFill:     color.RGBA{255, 255, 0, 255},
+		CooldownEmpty:    color.RGBA{90, 90, 90, 255},
+		Players: [MaxPlayers]color.Color{
+			color.RGBA{255, 255, 0, 255},
+			color.RGBA{0, 255, 255, 255},
+			color.RGBA{255, 0, 255, 255},
+			color.White,
+		},
+	},
+	"deuteranopia": {
+		Background: color.White,
+		Letterbox:  color.Black,
+		Text:       color.RGBA{64, 64, 64, 255},
+		Highlight:  color.RGBA{0, 114, 178, 255},
+		Message:    color.RGBA{213, 94, 0, 255},
+		Bullet:     color.Black,
+		Explosion:  color.RGBA{230, 159, 0, 170},
+		Thrust:     color.RGBA{230, 159, 0, 200},
+		Shield:     color.RGBA{86, 180, 233, 128},
+		PowerUps: [powerUpCount]color.Color{
+			PowerUpShield:    color.RGBA{86, 180, 233, 255},
+			PowerUpRapidFire: color.RGBA{240, 228, 66, 255},
+			PowerUpMultiShot: color.RGBA{204, 121, 167, 255},
+			PowerUpExtraLife: color.RGBA{0, 114, 178, 255},
+		},
+		Outline:          color.Black,
+		SpriteBrightness: 1,
+		HealthFill:       color.RGBA{0, 114, 178, 255},
+		HealthEmpty:      color.RGBA{230, 159, 0, 255},
+		CooldownFill:     color.RGBA{64, 64, 64, 255},
+		CooldownEmpty:    color.RGBA{200, 200, 200, 255},
+		Players: [MaxPlayers]color.Color{
+			color.RGBA{0, 114, 178, 255},
+			color.RGBA{230, 159, 0, 255},
+			color.RGBA{204, 121, 167, 255},
+			color.RGBA{64, 64, 64, 255},
+		},
+	},
+	"protanopia": {
+		Background: color.White,
+		Letterbox:  color.Black,
+		Text:       color.RGBA{64, 64, 64, 255},
+		Highlight:  color.RGBA{0, 114, 178, 255},
+		Message:    color.RGBA{0, 90, 140, 255},
+		Bullet:     color.Black,
+		Explosion:  color.RGBA{240, 228, 66, 190},
+		Thrust:     color.RGBA{230, 159, 0, 200},
+		Shield:     color.RGBA{86, 180, 233, 128},
+		PowerUps: [powerUpCount]color.Color{
+			PowerUpShield:    color.RGBA{86, 180, 233, 255},
+			PowerUpRapidFire: color.RGBA{240, 228, 66, 255},
+			PowerUpMultiShot: color.RGBA{204, 121, 167, 255},
+			PowerUpExtraLife: color.RGBA{0, 114, 178, 255},
+		},
+		Outline:          color.Black,
+		SpriteBrightness: 1.3,
+		HealthFill:       color.RGBA{0, 114, 178, 255},
+		HealthEmpty:      color.RGBA{240, 228, 66, 255},
+		CooldownFill:     color.RGBA{64, 64, 64, 255},
+		CooldownEmpty:    color.RGBA{200, 200, 200, 255},
+		Players: [MaxPlayers]color.Color{
+			color.RGBA{0, 114, 178, 255},
+			color.RGBA{230, 159, 0, 255},
+			color.RGBA{204, 121, 167, 255},
+			color.RGBA{64, 64, 64, 255},
+		},
+	},
+}
+
+// themeByName returns the named theme, or the light one for unknown names
+// (for example from a hand-edited settings file).
+func themeByName(name string) *Theme {
+	t, ok := themes[name]
+	if !ok {
+		t = themes[Themes[0]]
+	}
+	return &t
+}
+
+// setTheme switches the colour theme and regenerates the images that
+// bake colours in.
+func (g *Game) setTheme(name string) {
+	if _, ok := themes[name]; !ok {
+		name = Themes[0]
+	}
+	g.settings.Theme = name
+	g.theme = themeByName(name)
+	g.scenes.Background = g.theme.Background
+
+	ImgBullet = generateCircleImage(12, g.theme.Bullet)
+	ImgExplosion = generateCircleImage(40, g.theme.Explosion)
+	ImgThrust = generateCircleImage(16, g.theme.Thrust)
+	ImgShield = generateCircleImage(PlayerWidth+20, g.theme.Shield)
+	for t := PowerUpType(0); t < powerUpCount; t++ {
+		ImgPowerUps[t] = powerUpGlyph(t, PowerUpGlyphSize, g.theme.PowerUps[t], g.theme.Outline)
+	}
+	g.loadImages()
+}
+
+// brighten returns a copy of img with its colours scaled by k.
+func brighten(img *ebiten.Image, k float32) *ebiten.Image {
+	if k == 1 {
+		return img
+	}
+	b := img.Bounds()
+	out := ebiten.NewImage(b.Dx(), b.Dy())
+	op := &ebiten.DrawImageOptions{}
+	op.ColorScale.Scale(k, k, k, 1)
+	out.DrawImage(img, op)
+	return out
+}
+
+// powerUpShape reports whether the point (x, y), in a -1..1 box, is
+// inside the glyph for t. Each power-up has its own outline so it can
+// be told apart without relying on colour.
+func powerUpShape(t PowerUpType, x, y float64) bool {
+	switch t {
+	case PowerUpShield: // ring
+		r := math.Hypot(x, y)
+		return r <= 1 && r >= 0.55
+	case PowerUpRapidFire: // triangle pointing up
+		return y <= 1 && math.Abs(x) <= (y+1)/2
+	case PowerUpMultiShot: // three dots
+		for _, c := range [][2]float64{{-0.6, 0.45}, {0, -0.55}, {0.6, 0.45}} {
+			if math.Hypot(x-c[0], y-c[1]) <= 0.4 {
+				return true
+			}
+		}
+	case PowerUpExtraLife: // plus
+		return math.Abs(x) <= 0.33 && math.Abs(y) <= 1 || math.Abs(y) <= 0.33 && math.Abs(x) <= 1
+	}
+	return false
+}
+
+// powerUpGlyph draws the glyph for t in fill, with a two pixel outline.
+func powerUpGlyph(t PowerUpType, d int, fill, outline color.Color) *ebiten.Image {
+	const border = 2
+	img := ebiten.NewImage(d, d)
+	inside := func(px, py int) bool {
+		// Leave room for the outline at the edges of the image.
+		scale := float64(d)/2 - border
+		return powerUpShape(t, (float64(px)+0.5-float64(d)/2)/scale, (float64(py)+0.5-float64(d)/2)/scale)
+	}
+	for y := 0; y < d; y++ {
+		for x := 0; x < d; x++ {
+			if inside(x, y) {
+				img.Set(x, y, fill)
+				continue
+			}
+		near:
+			for dy := -border; dy <= border; dy++ {
+				for dx := -border; dx <= border; dx++ {
+					if dx*dx+dy*dy <= border*border && inside(x+dx, y+dy) {
+						img.Set(x, y, outline)
+						break near
+					}
+				}
+			}
+		}
+	}
+	return img
+}
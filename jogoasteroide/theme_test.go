@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Simulation matrices for dichromats from Viénot, Brettel and Mollon
+// (1999), applied to sRGB values. Rough, but enough to catch two colours
+// that collapse into one.
+var colorBlindness = map[string][3][3]float64{
+	"deuteranopia": {{0.625, 0.375, 0}, {0.7, 0.3, 0}, {0, 0.3, 0.7}},
+	"protanopia":   {{0.56667, 0.43333, 0}, {0.55833, 0.44167, 0}, {0, 0.24167, 0.75833}},
+}
+
+func simulate(m [3][3]float64, c color.Color) [3]float64 {
+	r, g, b, _ := c.RGBA()
+	in := [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+	var out [3]float64
+	for i := range out {
+		for j := range in {
+			out[i] += m[i][j] * in[j]
+		}
+	}
+	return out
+}
+
+func distance(a, b [3]float64) float64 {
+	return math.Sqrt((a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2]))
+}
+
+func TestThemesComplete(t *testing.T) {
+	g, _ := newTestGame()
+	for _, name := range Themes {
+		th, ok := themes[name]
+		if !ok {
+			t.Errorf("tema %q listado mas não definido", name)
+			continue
+		}
+		v := reflect.ValueOf(th)
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.Kind() == reflect.Interface && f.IsNil() {
+				t.Errorf("tema %q: %s sem cor", name, v.Type().Field(i).Name)
+			}
+		}
+		for i, c := range th.PowerUps {
+			if c == nil {
+				t.Errorf("tema %q: power-up %d sem cor", name, i)
+			}
+		}
+		for i, c := range th.Players {
+			if c == nil {
+				t.Errorf("tema %q: jogador %d sem cor", name, i+1)
+			}
+		}
+		if th.SpriteBrightness <= 0 {
+			t.Errorf("tema %q: SpriteBrightness = %v", name, th.SpriteBrightness)
+		}
+		if id := "theme." + name; g.loc.T(id) == id {
+			t.Errorf("tema %q sem nome no catálogo", name)
+		}
+	}
+	if len(themes) != len(Themes) {
+		t.Errorf("%d temas definidos, %d listados", len(themes), len(Themes))
+	}
+}
+
+func TestColorBlindThemes(t *testing.T) {
+	const minDistance = 60
+	for name, m := range colorBlindness {
+		th := themes[name]
+		pairs := map[string][2]color.Color{
+			"vida":           {th.HealthFill, th.HealthEmpty},
+			"recarga":        {th.CooldownFill, th.CooldownEmpty},
+			"mensagem/fundo": {th.Message, th.Background},
+			"texto/fundo":    {th.Text, th.Background},
+			"destaque/texto": {th.Highlight, th.Text},
+		}
+		for i := range th.PowerUps {
+			for j := i + 1; j < len(th.PowerUps); j++ {
+				pairs[fmt.Sprintf("power-ups %d/%d", i, j)] = [2]color.Color{th.PowerUps[i], th.PowerUps[j]}
+			}
+		}
+		for i := range th.Players {
+			for j := i + 1; j < len(th.Players); j++ {
+				pairs[fmt.Sprintf("jogadores %d/%d", i+1, j+1)] = [2]color.Color{th.Players[i], th.Players[j]}
+			}
+			pairs[fmt.Sprintf("jogador %d/fundo", i+1)] = [2]color.Color{th.Players[i], th.Background}
+		}
+		for what, p := range pairs {
+			if d := distance(simulate(m, p[0]), simulate(m, p[1])); d < minDistance {
+				t.Errorf("tema %s, %s: distância %.0f; esperado pelo menos %d", name, what, d, minDistance)
+			}
+		}
+	}
+}
+
+func TestPowerUpShapesDiffer(t *testing.T) {
+	const n = 32
+	masks := make([][n * n]bool, powerUpCount)
+	for p := PowerUpType(0); p < powerUpCount; p++ {
+		for y := 0; y < n; y++ {
+			for x := 0; x < n; x++ {
+				masks[p][y*n+x] = powerUpShape(p, (float64(x)+0.5)/n*2-1, (float64(y)+0.5)/n*2-1)
+			}
+		}
+	}
+	for a := range masks {
+		for b := a + 1; b < len(masks); b++ {
+			diff := 0
+			for i := range masks[a] {
+				if masks[a][i] != masks[b][i] {
+					diff++
+				}
+			}
+			if diff < n*n/5 {
+				t.Errorf("formas %d e %d diferem em %d pixels; esperado pelo menos %d", a, b, diff, n*n/5)
+			}
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// TutorialVersion is the tutorial progress file format written by this
+// build.
+const TutorialVersion = 1
+
+// Tutorial goals.
+const (
+	GoalRotate   = "rotate"   // turn Target degrees, either way
+	GoalDistance = "distance" // fly Target pixels
+	GoalShots    = "shots"    // fire Target bullets
+	GoalDestroy  = "destroy"  // destroy Target asteroids
+	GoalPowerUp  = "power_up" // collect Target power-ups
+)
+
+// TutorialStep is one objective from assets/tutorial.json. Its prompt is
+// the catalog message tutorial.<id>, formatted with the keys bound to each
+// of Keys.
+type TutorialStep struct {
+	ID      string          `json:"id"`
+	Goal    string          `json:"goal"`
+	Target  int             `json:"target"`
+	Size    string          `json:"size,omitempty"`     // only asteroids of this size
+	PowerUp string          `json:"power_up,omitempty"` // only this power-up
+	Keys    []string        `json:"keys,omitempty"`
+	Clear   bool            `json:"clear,omitempty"` // remove the asteroids left by earlier steps
+	Spawn   []TutorialSpawn `json:"spawn,omitempty"`
+
+	actions []Action
+	size    AsteroidSize // sizeCount for any
+	powerUp PowerUpType  // powerUpCount for any
+}
+
+// TutorialSpawn is an asteroid or power-up a step puts on screen. It is
+// put back if the step is not done when nothing is left on screen.
+type TutorialSpawn struct {
+	Kind    string  `json:"kind"` // "asteroid" or "power_up"
+	X       float64 `json:"x"`
+	Y       float64 `json:"y"`
+	VX      float64 `json:"vx,omitempty"`
+	VY      float64 `json:"vy,omitempty"`
+	Size    float64 `json:"size,omitempty"`     // asteroids only
+	PowerUp string  `json:"power_up,omitempty"` // power-ups only
+
+	powerUp PowerUpType
+}
+
+// validate checks the step and resolves its names.
+func (st *TutorialStep) validate() error {
+	if st.ID == "" {
+		return errors.New("missing id")
+	}
+	if !slices.Contains([]string{GoalRotate, GoalDistance, GoalShots, GoalDestroy, GoalPowerUp}, st.Goal) {
+		return fmt.Errorf("%s: unknown goal %q", st.ID, st.Goal)
+	}
+	if st.Target <= 0 {
+		return fmt.Errorf("%s: target must be positive", st.ID)
+	}
+	st.size = sizeCount
+	if st.Size != "" {
+		st.size = AsteroidSize(indexOf(sizeNames[:], st.Size))
+		if st.Goal != GoalDestroy || sizeNames[st.size] != st.Size {
+			return fmt.Errorf("%s: unknown size %q", st.ID, st.Size)
+		}
+	}
+	st.powerUp = powerUpCount
+	if st.PowerUp != "" {
+		st.powerUp = PowerUpType(indexOf(powerUpNames[:], st.PowerUp))
+		if st.Goal != GoalPowerUp || powerUpNames[st.powerUp] != st.PowerUp {
+			return fmt.Errorf("%s: unknown power-up %q", st.ID, st.PowerUp)
+		}
+	}
+	st.actions = nil
+	for _, k := range st.Keys {
+		a, ok := actionByName(k)
+		if !ok {
+			return fmt.Errorf("%s: unknown action %q", st.ID, k)
+		}
+		st.actions = append(st.actions, a)
+	}
+	for i := range st.Spawn {
+		sp := &st.Spawn[i]
+		switch sp.Kind {
+		case "asteroid":
+			if sp.Size < MinAsteroidSize {
+				return fmt.Errorf("%s: asteroid %d is smaller than %v", st.ID, i+1, MinAsteroidSize)
+			}
+		case "power_up":
+			sp.powerUp = PowerUpType(indexOf(powerUpNames[:], sp.PowerUp))
+			if powerUpNames[sp.powerUp] != sp.PowerUp {
+				return fmt.Errorf("%s: unknown power-up %q", st.ID, sp.PowerUp)
+			}
+		default:
+			return fmt.Errorf("%s: unknown spawn kind %q", st.ID, sp.Kind)
+		}
+	}
+	return nil
+}
+
+// ParseTutorial reads and validates the tutorial steps.
+func ParseTutorial(data []byte) ([]TutorialStep, error) {
+	var file struct {
+		Version int            `json:"version"`
+		Steps   []TutorialStep `json:"steps"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("unsupported tutorial version %d", file.Version)
+	}
+	var errs []error
+	seen := map[string]bool{}
+	for i := range file.Steps {
+		st := &file.Steps[i]
+		if err := st.validate(); err != nil {
+			errs = append(errs, err)
+		} else if seen[st.ID] {
+			errs = append(errs, fmt.Errorf("%s: duplicate id", st.ID))
+		}
+		seen[st.ID] = true
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return file.Steps, nil
+}
+
+// loadTutorial reads the tutorial steps from the assets. A broken file is
+// logged and the tutorial has no steps.
+func loadTutorial(assets *AssetStore) []TutorialStep {
+	data, err := assets.Data("tutorial")
+	var steps []TutorialStep
+	if err == nil {
+		steps, err = ParseTutorial(data)
+	}
+	if err != nil {
+		log.Printf("tutorial: %v", err)
+	}
+	return steps
+}
+
+// TutorialProgress is which tutorial steps the player has completed.
+type TutorialProgress struct {
+	Version int      `json:"version"`
+	Done    []string `json:"done"` // step ids, in the order first completed
+}
+
+// complete marks the step done.
+func (p *TutorialProgress) complete(id string) {
+	if !slices.Contains(p.Done, id) {
+		p.Done = append(p.Done, id)
+	}
+}
+
+// count is how many of steps are done.
+func (p TutorialProgress) count(steps []TutorialStep) int {
+	n := 0
+	for _, st := range steps {
+		if slices.Contains(p.Done, st.ID) {
+			n++
+		}
+	}
+	return n
+}
+
+// LoadTutorialProgress reads which tutorial steps are done from path.
+// Until the first one is there is no file, and no steps are done.
+func LoadTutorialProgress(path string) (TutorialProgress, error) {
+	p := TutorialProgress{Version: TutorialVersion}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return p, err
+	}
+	var read TutorialProgress
+	if err := json.Unmarshal(data, &read); err != nil {
+		return p, fmt.Errorf("%s: %v", path, err)
+	}
+	if read.Version > TutorialVersion {
+		return p, fmt.Errorf("%s: tutorial version %d is newer than this game (%d)", path, read.Version, TutorialVersion)
+	}
+	read.Version = TutorialVersion
+	return read, nil
+}
+
+// SaveTutorialProgress writes p to path.
+func SaveTutorialProgress(path string, p TutorialProgress) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+func (g *Game) saveTutorial() {
+	if g.tutorialPath == "" {
+		return
+	}
+	if err := SaveTutorialProgress(g.tutorialPath, g.tutorial); err != nil {
+		log.Printf("saving tutorial progress: %v", err)
+	}
+}
+
+// tutorialButton opens the tutorial and shows how much of it is done.
+func (g *Game) tutorialButton() *Button {
+	return &Button{
+		Text: func() string { return g.loc.T("menu.tutorial") },
+		Detail: func() string {
+			switch n := g.tutorial.count(g.tutorialSteps); {
+			case n == 0:
+				return ""
+			case n == len(g.tutorialSteps):
+				return g.loc.T("tutorial.complete")
+			default:
+				return fmt.Sprintf("%d/%d", n, len(g.tutorialSteps))
+			}
+		},
+		OnPress: func() { g.scenes.Reset(newTutorialScene(g), Fade) },
+	}
+}
+
+// TutorialScene walks the player through the tutorial steps on a run of
+// its own, which is neither saved nor counted in the statistics.
+type TutorialScene struct {
+	g        *Game
+	game     *Game
+	steps    []TutorialStep
+	step     int      // the current step; len(steps) once all are done
+	start    RunStats // the run's statistics when the step began
+	turned   float64  // degrees turned since the step began
+	angle    float64
+	finished int // frames since the last step was done
+}
+
+func newTutorialScene(g *Game) *TutorialScene {
+	s := &TutorialScene{g: g, game: g.mirrorGame(), steps: g.tutorialSteps}
+	s.game.settings = g.settings
+	s.game.ResetRun(RunConfig{Mode: ModeTutorial, Difficulty: DifficultyNormal})
+	s.begin(0)
+	return s
+}
+
+// begin starts step i, putting its asteroids and power-ups on screen.
+func (s *TutorialScene) begin(i int) {
+	t := s.game
+	s.step, s.start, s.turned = i, t.stats, 0
+	if i == len(s.steps) {
+		return
+	}
+	if s.steps[i].Clear {
+		t.asteroids = t.asteroids[:0]
+	}
+	s.spawn(&s.steps[i])
+}
+
+// spawn puts the step's asteroids and power-ups on screen.
+func (s *TutorialScene) spawn(st *TutorialStep) {
+	t := s.game
+	for _, sp := range st.Spawn {
+		pos, vel := Vector{sp.X, sp.Y}, Vector{sp.VX, sp.VY}
+		if sp.Kind == "asteroid" {
+			t.asteroids = append(t.asteroids, Asteroid{id: t.newID(), position: pos, velocity: vel, size: sp.Size, rotSpeed: 0.01})
+			continue
+		}
+		pw := t.powerUpPool.Get()
+		*pw = PowerUp{id: t.newID(), position: pos, velocity: vel, powerType: sp.powerUp, size: 20, maxAge: PowerUpMaxAge}
+		t.powerUps = append(t.powerUps, pw)
+	}
+}
+
+// progress is how far the player has got towards the step's target.
+func (s *TutorialScene) progress(st *TutorialStep) float64 {
+	now, was := s.game.stats, s.start
+	switch st.Goal {
+	case GoalRotate:
+		return s.turned
+	case GoalDistance:
+		return now.Distance - was.Distance
+	case GoalShots:
+		return float64(now.ShotsFired - was.ShotsFired)
+	case GoalDestroy:
+		if st.size != sizeCount {
+			return float64(now.Asteroids[st.size] - was.Asteroids[st.size])
+		}
+		return float64(now.AsteroidsDestroyed() - was.AsteroidsDestroyed())
+	case GoalPowerUp:
+		if st.powerUp != powerUpCount {
+			return float64(now.PowerUps[st.powerUp] - was.PowerUps[st.powerUp])
+		}
+		return float64(now.PowerUpsCollected() - was.PowerUpsCollected())
+	}
+	return 0
+}
+
+func (s *TutorialScene) Update() error {
+	g := s.g
+	if g.settings.Bindings.JustPressed(ActionPause) || g.nav.Back || g.nav.Start ||
+		s.finished > TicksPerSecond && g.nav.Accept {
+		g.scenes.Reset(newMenuScene(g), Fade)
+		return nil
+	}
+	if s.game.camera.Update() {
+		s.play(s.game.readInputs()...)
+	}
+	return nil
+}
+
+// play runs a step of the tutorial's run and checks the current objective.
+func (s *TutorialScene) play(in ...Input) {
+	g, t := s.g, s.game
+	t.updatePlaying(in...)
+	// Nothing is lost in the tutorial: hits only shake the screen.
+	for i := range t.players {
+		*t.health(&t.players[i]) = t.maxHealth()
+	}
+	p := &t.players[0]
+	s.turned += math.Abs(p.angle-s.angle) * 180 / math.Pi
+	s.angle = p.angle
+	if s.step == len(s.steps) {
+		s.finished++
+		return
+	}
+	st := &s.steps[s.step]
+	switch {
+	case s.progress(st) >= float64(st.Target):
+		g.tutorial.complete(st.ID)
+		g.saveTutorial()
+		g.mixer.Play(SoundAchievement)
+		t.message, t.messageTimer = g.loc.T("tutorial.well_done"), 90
+		s.begin(s.step + 1)
+	case len(st.Spawn) > 0 && len(t.asteroids) == 0 && len(t.powerUps) == 0:
+		// Bring back what the step needs, such as a power-up that
+		// expired before it was collected.
+		s.spawn(st)
+	}
+}
+
+// prompt is the step's instruction with the keys currently bound to it.
+func (s *TutorialScene) prompt(st *TutorialStep) string {
+	keys := make([]any, len(st.actions))
+	for i, a := range st.actions {
+		keys[i] = keyNames(s.g.settings.Bindings[a])
+	}
+	return s.g.loc.T("tutorial."+st.ID, keys...)
+}
+
+func (s *TutorialScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	s.game.drawPlaying(screen)
+	if s.step == len(s.steps) {
+		drawAnchoredText(screen, g.loc.T("tutorial.finished"), g.fonts.Normal, AnchorTop, 0, 90, g.theme.Highlight)
+		return
+	}
+	st := &s.steps[s.step]
+	drawAnchoredText(screen, g.loc.T("tutorial.step", s.step+1, len(s.steps)), g.fonts.Small, AnchorTop, 0, 64, g.theme.Text)
+	drawAnchoredText(screen, s.prompt(st), g.fonts.Normal, AnchorTop, 0, 90, g.theme.Highlight)
+	const barW, barH = 300.0, 8.0
+	x, y := AnchorTop.Place(barW, barH, 0, 140)
+	fillRect(screen, x, y, barW, barH, g.theme.CooldownEmpty, 1)
+	fillRect(screen, x, y, barW*math.Min(s.progress(st)/float64(st.Target), 1), barH, g.theme.CooldownFill, 1)
+}
+
+func (s *TutorialScene) Overlay() bool { return false }
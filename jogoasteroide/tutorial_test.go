@@ -0,0 +1,168 @@
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestShippedTutorial(t *testing.T) {
+	assets, err := NewAssetStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := assets.Data("tutorial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps, err := ParseTutorial(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goals := map[string]bool{}
+	for _, st := range steps {
+		goals[st.Goal] = true
+	}
+	for _, goal := range []string{GoalRotate, GoalDistance, GoalShots, GoalDestroy, GoalPowerUp} {
+		if !goals[goal] {
+			t.Errorf("nenhum passo com o objetivo %q", goal)
+		}
+	}
+	for _, lang := range Languages {
+		c, err := loadCatalog(assets, lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, st := range steps {
+			m, ok := c["tutorial."+st.ID]
+			if !ok {
+				t.Errorf("catálogo %s: falta a chave %q", lang, "tutorial."+st.ID)
+				continue
+			}
+			if n := strings.Count(m.Forms["other"], "%s"); n != len(st.Keys) {
+				t.Errorf("catálogo %s: %q mostra %d teclas; esperado %d", lang, "tutorial."+st.ID, n, len(st.Keys))
+			}
+		}
+	}
+}
+
+func TestParseTutorialRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		step string
+	}{
+		{"objetivo desconhecido", `{"id": "a", "goal": "dance", "target": 1}`},
+		{"alvo zero", `{"id": "a", "goal": "shots", "target": 0}`},
+		{"tamanho desconhecido", `{"id": "a", "goal": "destroy", "size": "huge", "target": 1}`},
+		{"tamanho sem asteroides", `{"id": "a", "goal": "shots", "size": "large", "target": 1}`},
+		{"power-up desconhecido", `{"id": "a", "goal": "power_up", "power_up": "laser", "target": 1}`},
+		{"tecla desconhecida", `{"id": "a", "goal": "shots", "target": 1, "keys": ["jump"]}`},
+		{"asteroide pequeno demais", `{"id": "a", "goal": "destroy", "target": 1, "spawn": [{"kind": "asteroid", "size": 5}]}`},
+		{"item desconhecido", `{"id": "a", "goal": "destroy", "target": 1, "spawn": [{"kind": "ufo"}]}`},
+		{"id repetido", `{"id": "a", "goal": "shots", "target": 1}, {"id": "a", "goal": "shots", "target": 2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"version": 1, "steps": [` + tt.step + `]}`
+			if _, err := ParseTutorial([]byte(data)); err == nil {
+				t.Error("ParseTutorial() sem erro; esperado erro")
+			}
+		})
+	}
+}
+
+func TestTutorialWalkthrough(t *testing.T) {
+	g, _ := newTestGame()
+	g.tutorialPath = filepath.Join(t.TempDir(), "tutorial.json")
+	s := newTutorialScene(g)
+	// Held keys cover the first steps and the bot the shooting ones;
+	// flyTo picks up the power-up, which the bot only goes for nearby.
+	bot := NewBot(BotHard, 0, 1)
+	script := map[string]Input{
+		"rotate": Input(0).With(ActionRotateLeft),
+		"thrust": Input(0).With(ActionThrust),
+		"fire":   Input(0).With(ActionFire),
+	}
+	for i, st := range s.steps {
+		if i == 0 && len(s.game.asteroids)+len(s.game.powerUps) != 0 {
+			t.Fatal("o tutorial começou com asteroides ou itens na tela")
+		}
+		for n := 0; s.step == i; n++ {
+			if n == 60*TicksPerSecond {
+				t.Fatalf("passo %q não concluído em um minuto, com %d asteroides na tela", st.ID, len(s.game.asteroids))
+			}
+			in, ok := script[st.ID]
+			switch {
+			case st.Goal == GoalPowerUp && len(s.game.powerUps) > 0:
+				in = flyTo(&s.game.players[0], s.game.powerUps[0].position)
+			case !ok:
+				in = bot.Input(s.game)
+			}
+			s.play(in)
+		}
+		if got := g.tutorial.count(s.steps); got != i+1 {
+			t.Errorf("depois do passo %q: %d passos concluídos; esperado %d", st.ID, got, i+1)
+		}
+	}
+	if !s.game.running {
+		t.Error("o tutorial terminou a partida")
+	}
+	saved, err := LoadTutorialProgress(g.tutorialPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.count(s.steps) != len(s.steps) {
+		t.Errorf("progresso salvo %v; esperado todos os passos", saved.Done)
+	}
+	if got := g.tutorialButton().Detail(); got != g.loc.T("tutorial.complete") {
+		t.Errorf("botão do menu mostra %q; esperado concluído", got)
+	}
+}
+
+func TestTutorialRefillsHealth(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		g, _ := newTestGame()
+		s := newTutorialScene(g)
+		s.game.ResetRun(RunConfig{Mode: ModeTutorial, Players: 2, SharedLives: shared})
+		for i := range s.game.players {
+			s.game.hurt(&s.game.players[i], -1)
+		}
+		s.play()
+		for i := range s.game.players {
+			p := &s.game.players[i]
+			if got := *s.game.health(p); got != s.game.maxHealth() || p.out {
+				t.Errorf("vidas compartilhadas %v: jogador %d com %d de vida; esperado %d", shared, i+1, got, s.game.maxHealth())
+			}
+		}
+	}
+}
+
+// flyTo turns the ship towards target and thrusts once it faces it.
+func flyTo(p *Player, target Vector) Input {
+	turn := math.Remainder(heading(wrapDelta(p.position, target))-p.angle, 2*math.Pi)
+	switch {
+	case turn > 0.1:
+		return Input(0).With(ActionRotateRight)
+	case turn < -0.1:
+		return Input(0).With(ActionRotateLeft)
+	}
+	return Input(0).With(ActionThrust)
+}
+
+func TestTutorialPromptShowsBindings(t *testing.T) {
+	g, _ := newTestGame()
+	g.settings.Bindings[ActionFire] = []ebiten.Key{ebiten.KeyF}
+	s := newTutorialScene(g)
+	for i := range s.steps {
+		if st := &s.steps[i]; st.ID == "fire" {
+			if got := s.prompt(st); !strings.Contains(got, "F") || strings.Contains(got, "Space") {
+				t.Errorf("instrução %q; esperado a tecla F", got)
+			}
+			return
+		}
+	}
+	t.Fatal("nenhum passo fire")
+}
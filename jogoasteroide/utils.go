@@ -1,19 +1,11 @@
 package main
 
 import (
-	"fmt"
 	"image/color"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"golang.org/x/image/font"
-	"golang.org/x/image/font/basicfont"
 )
 
-func loadFont() font.Face {
-	return basicfont.Face7x13
-}
-
 func generateCircleImage(d int, clr color.Color) *ebiten.Image {
 	img := ebiten.NewImage(d, d)
 	c := float64(d) / 2
@@ -34,11 +26,3 @@ func circleCollision(x1, y1, r1, x2, y2, r2 float64) bool {
 	dy := y1 - y2
 	return dx*dx+dy*dy < (r1+r2)*(r1+r2)
 }
-
-func loadImage(path string) (*ebiten.Image, error) {
-	img, _, err := ebitenutil.NewImageFromFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load %s: %v", path, err)
-	}
-	return img, nil
-}
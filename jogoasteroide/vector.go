@@ -3,7 +3,8 @@ package main
 import "math"
 
 type Vector struct {
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 func (v *Vector) Add(v2 Vector) {
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// DefaultRounds is the length of a versus match unless set otherwise.
+const DefaultRounds = 3
+
+// VersusRounds lists the match lengths offered in the settings.
+var VersusRounds = []int{1, 3, 5, 7}
+
+// RoundEndTicks is how long a versus round plays on once only one ship is
+// left, so the last explosion can finish. It counts simulation steps, not
+// frames, so replays end rounds at the same step.
+const RoundEndTicks = 90
+
+// roundCount is the most rounds a versus match can last.
+func (c RunConfig) roundCount() int {
+	return max(1, c.Rounds)
+}
+
+// versusConfig is a versus match between the chosen number of players.
+func (g *Game) versusConfig(seed uint64) RunConfig {
+	c := g.settings.Coop
+	cfg := RunConfig{
+		Mode:       ModeVersus,
+		Seed:       seed,
+		Difficulty: g.settings.Difficulty.normalize(),
+		Players:    max(2, min(c.Players, MaxPlayers)),
+		Rounds:     c.Rounds,
+	}
+	if _, ok := ParseBotSkill(c.CPU); ok {
+		cfg.Players = max(2, min(c.Players+1, MaxPlayers))
+		cfg.CPU = c.CPU
+	}
+	return cfg
+}
+
+// startVersus begins a new versus match, replacing any saved run.
+func (g *Game) startVersus() {
+	g.beginRun(g.versusConfig(rand.Uint64()))
+}
+
+// restart starts another run like the one that just ended.
+func (g *Game) restart() {
+	switch {
+	case g.config.Mode == ModeVersus:
+		g.startVersus()
+	case g.ghost != nil:
+		// Race the best run on this seed again, which may now be this one.
+		g.beginRun(g.config)
+	default:
+		g.startRun()
+	}
+}
+
+// contestedPoint is the middle of the ships still playing, as far from one
+// as from the others.
+func (g *Game) contestedPoint() Vector {
+	var sum Vector
+	n := 0
+	for _, p := range g.players {
+		if !p.out {
+			sum.Add(p.position)
+			n++
+		}
+	}
+	if n == 0 {
+		return Vector{ScreenWidth / 2, ScreenHeight / 2}
+	}
+	return sum.Scaled(1 / float64(n))
+}
+
+// knockOut takes p out of the round, crediting the kill to player by if a
+// bullet did it. The round ends a little after only one ship is left.
+func (g *Game) knockOut(p *Player, by int) {
+	p.out = true
+	g.explode(p.position)
+	if by >= 0 {
+		g.kills[by]++
+		g.message = g.loc.T("msg.shot_down", g.shipName(by), g.shipName(p.index))
+		g.messageTimer = 120
+	} else {
+		g.notify(p, "msg.player_out")
+	}
+	if g.playersLeft() <= 1 && g.roundEnding == 0 {
+		g.roundEnding = RoundEndTicks
+		g.camera.SlowMotion(SlowMotionFrames)
+	}
+}
+
+// endRound awards the round to the last ship standing, if any, then ends
+// the match or sets up the next round behind the scoreboard.
+func (g *Game) endRound() {
+	g.roundWinner = -1
+	if g.playersLeft() == 1 {
+		for i, p := range g.players {
+			if !p.out {
+				g.roundWinner = i
+				g.wins[i]++
+			}
+		}
+	}
+	if g.round >= g.config.roundCount() || g.leader() >= 0 && g.wins[g.leader()] > g.config.roundCount()/2 {
+		g.endRun()
+		return
+	}
+	g.round++
+	g.startRound()
+	if !g.headless {
+		g.scenes.Push(newRoundScene(g), Fade)
+	}
+}
+
+// startRound clears the arena and puts fresh ships in it.
+func (g *Game) startRound() {
+	for _, b := range g.bullets {
+		g.bulletPool.Put(b)
+	}
+	g.bullets = g.bullets[:0]
+	for _, p := range g.powerUps {
+		g.powerUpPool.Put(p)
+	}
+	g.powerUps = g.powerUps[:0]
+	g.asteroids = g.asteroids[:0]
+	g.resetPlayers()
+	g.roundEnding = 0
+	g.messageTimer = 0
+	for i := 0; i < g.config.preset().StartAsteroids; i++ {
+		g.spawnAsteroid()
+	}
+}
+
+// leader is the player with the most round wins, or -1 on a tie.
+func (g *Game) leader() int {
+	best := -1
+	tied := false
+	for i, w := range g.wins {
+		switch {
+		case best < 0 || w > g.wins[best]:
+			best, tied = i, false
+		case w == g.wins[best]:
+			tied = true
+		}
+	}
+	if tied {
+		return -1
+	}
+	return best
+}
+
+// scoreboard is a row per player with their round wins and kills.
+func (g *Game) scoreboard() []statRow {
+	rows := make([]statRow, len(g.players))
+	for i := range rows {
+		rows[i] = statRow{g.shipName(i), g.loc.T("versus.record", g.wins[i], g.kills[i])}
+	}
+	return rows
+}
+
+// drawVersusResult draws who won above the scoreboard.
+func (g *Game) drawVersusResult(screen *ebiten.Image, winner int, winID, drawID string, y float64) {
+	text, clr := g.loc.T(drawID), g.theme.Highlight
+	if winner >= 0 {
+		text, clr = g.loc.T(winID, g.shipName(winner)), g.theme.Players[winner]
+	}
+	drawAnchoredText(screen, text, g.fonts.Large, AnchorCenter, 0, y, clr)
+	drawStatRows(screen, g.scoreboard(), ScreenHeight/2+y+60, g.menuStyle())
+}
+
+// RoundScene is the scoreboard between versus rounds, over the next
+// round's frozen arena.
+type RoundScene struct {
+	g    *Game
+	list List
+}
+
+func newRoundScene(g *Game) *RoundScene {
+	s := &RoundScene{g: g}
+	s.list.Items = []Widget{
+		g.button("versus.next_round", func() { g.scenes.Pop(QuickFade) }),
+		g.button("pause.quit", g.quitToMenu),
+	}
+	return s
+}
+
+func (s *RoundScene) Update() error {
+	if s.g.nav.Start {
+		s.g.scenes.Pop(QuickFade)
+		return nil
+	}
+	s.list.Update(s.g.nav)
+	return nil
+}
+
+func (s *RoundScene) Draw(screen *ebiten.Image) {
+	g := s.g
+	fillRect(screen, 0, 0, ScreenWidth, ScreenHeight, g.theme.Background, OverlayDim)
+	drawAnchoredText(screen, g.loc.T("versus.round_over", g.round-1), g.fonts.Normal, AnchorCenter, 0, -240, g.theme.Text)
+	g.drawVersusResult(screen, g.roundWinner, "versus.round_winner", "versus.round_draw", -180)
+	s.list.Draw(screen, ScreenHeight/2+120, g.menuStyle())
+}
+
+func (s *RoundScene) Overlay() bool { return true }
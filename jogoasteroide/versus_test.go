@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func newVersusGame(cfg RunConfig) *Game {
+	g, _ := newTestGame()
+	cfg.Mode = ModeVersus
+	g.ResetRun(cfg)
+	g.asteroids = g.asteroids[:0]
+	return g
+}
+
+// knockOutAllBut has winner shoot down every other ship, then lets the
+// round run out.
+func knockOutAllBut(g *Game, winner int) {
+	for i := range g.players {
+		for i != winner && !g.players[i].out {
+			g.hurt(&g.players[i], winner)
+		}
+	}
+	for i := 0; i < RoundEndTicks && g.running; i++ {
+		g.updatePlaying()
+	}
+}
+
+func TestVersusRounds(t *testing.T) {
+	g := newVersusGame(RunConfig{Players: 3, Rounds: 3})
+	knockOutAllBut(g, 1)
+	if g.wins[1] != 1 || g.kills[1] != 2 || g.round != 2 {
+		t.Errorf("vitórias %v, abates %v, rodada %d; esperado a rodada 1 do jogador 2", g.wins, g.kills, g.round)
+	}
+	if g.playersLeft() != 3 {
+		t.Errorf("%d naves na rodada nova; esperado 3", g.playersLeft())
+	}
+	if _, ok := g.scenes.Top().(*RoundScene); !ok {
+		t.Errorf("cena = %T; esperado o placar da rodada", g.scenes.Top())
+	}
+
+	// Everyone out at once is a draw.
+	for i := range g.players {
+		for !g.players[i].out {
+			g.hurt(&g.players[i], -1)
+		}
+	}
+	for i := 0; i < RoundEndTicks; i++ {
+		g.updatePlaying()
+	}
+	if g.roundWinner != -1 || g.wins[1] != 1 || g.round != 3 {
+		t.Errorf("vencedor %d, vitórias %v, rodada %d; esperado empate", g.roundWinner, g.wins, g.round)
+	}
+
+	knockOutAllBut(g, 1)
+	if g.running {
+		t.Fatal("a partida continuou depois de duas vitórias em três")
+	}
+	if g.leader() != 1 {
+		t.Errorf("líder = %d; esperado o jogador 2", g.leader())
+	}
+	if _, ok := g.scenes.Top().(*GameOverScene); !ok {
+		t.Errorf("cena = %T; esperado *GameOverScene", g.scenes.Top())
+	}
+}
+
+func TestVersusPowerUpsContested(t *testing.T) {
+	g := newVersusGame(RunConfig{})
+	g.players[0].position = Vector{X: 200, Y: 200}
+	g.players[1].position = Vector{X: 800, Y: 600}
+	g.spawnPowerUp()
+	p := g.powerUps[0].position
+	if a, b := math.Hypot(p.X-200, p.Y-200), math.Hypot(p.X-800, p.Y-600); math.Abs(a-b) > 1 {
+		t.Errorf("power-up em %v: distâncias %.0f e %.0f; esperado no meio", p, a, b)
+	}
+}
+
+func TestVersusReplay(t *testing.T) {
+	g, _ := newTestGame()
+	g.ResetRun(RunConfig{Mode: ModeVersus, Seed: 5, Rounds: 3})
+	// Player 1 turns to face player 2 and fires; player 2 sits still.
+	for i := 0; g.running; i++ {
+		if i == 50000 {
+			t.Fatal("a partida não terminou")
+		}
+		var in Input
+		if g.players[0].angle < math.Pi/2-0.05 {
+			in = in.With(ActionRotateRight)
+		}
+		g.updatePlaying(in.With(ActionFire), 0)
+	}
+	if g.round < 2 {
+		t.Errorf("a partida durou %d rodada; esperado mais de uma", g.round)
+	}
+	sim, err := Simulate(g.assets, g.replay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sim.Over || sim.Ticks != g.tick || sim.Score != g.score {
+		t.Errorf("simulação: %+v; esperado fim no passo %d com %d pontos", sim, g.tick, g.score)
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/font"
+)
+
+// Held directions repeat after NavRepeatDelay frames, every
+// NavRepeatInterval frames.
+const (
+	NavRepeatDelay    = 24
+	NavRepeatInterval = 6
+	// NavStickThreshold is how far the left stick must be pushed to count
+	// as a direction.
+	NavStickThreshold = 0.5
+)
+
+// Nav is the menu input for one frame, merged from the keyboard and every
+// connected gamepad.
+type Nav struct {
+	Up, Down, Left, Right bool
+	Accept, Back          bool
+	Start                 bool
+}
+
+// navigator turns raw keyboard and gamepad state into Nav, with key
+// repeat for held directions. The analogue stick has no press duration
+// of its own, so it is timed here.
+type navigator struct {
+	stickX, stickY int // frames the stick has been held in one direction
+}
+
+func repeats(d int) bool {
+	return d == 1 || d >= NavRepeatDelay && (d-NavRepeatDelay)%NavRepeatInterval == 0
+}
+
+func (n *navigator) read() Nav {
+	var nav Nav
+	key := func(keys ...ebiten.Key) bool {
+		for _, k := range keys {
+			if repeats(inpututil.KeyPressDuration(k)) {
+				return true
+			}
+		}
+		return false
+	}
+	nav.Up = key(ebiten.KeyUp, ebiten.KeyW)
+	nav.Down = key(ebiten.KeyDown, ebiten.KeyS)
+	nav.Left = key(ebiten.KeyLeft, ebiten.KeyA)
+	nav.Right = key(ebiten.KeyRight, ebiten.KeyD)
+	nav.Accept = inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeySpace)
+	nav.Back = inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyBackspace)
+
+	sx, sy := 0.0, 0.0
+	for _, id := range ebiten.AppendGamepadIDs(nil) {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+		button := func(b ebiten.StandardGamepadButton) bool {
+			return repeats(inpututil.StandardGamepadButtonPressDuration(id, b))
+		}
+		nav.Up = nav.Up || button(ebiten.StandardGamepadButtonLeftTop)
+		nav.Down = nav.Down || button(ebiten.StandardGamepadButtonLeftBottom)
+		nav.Left = nav.Left || button(ebiten.StandardGamepadButtonLeftLeft)
+		nav.Right = nav.Right || button(ebiten.StandardGamepadButtonLeftRight)
+		nav.Accept = nav.Accept || inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightBottom)
+		nav.Back = nav.Back || inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightRight)
+		nav.Start = nav.Start || inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonCenterRight)
+		sx += ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		sy += ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	}
+	n.stickX = stickDuration(n.stickX, sx)
+	n.stickY = stickDuration(n.stickY, sy)
+	nav.Left = nav.Left || n.stickX < 0 && repeats(-n.stickX)
+	nav.Right = nav.Right || n.stickX > 0 && repeats(n.stickX)
+	nav.Up = nav.Up || n.stickY < 0 && repeats(-n.stickY)
+	nav.Down = nav.Down || n.stickY > 0 && repeats(n.stickY)
+	return nav
+}
+
+// stickDuration counts frames held in the direction of v: positive when
+// pushed towards +1, negative towards -1, 0 in the dead zone.
+func stickDuration(d int, v float64) int {
+	switch {
+	case v > NavStickThreshold:
+		return max(d, 0) + 1
+	case v < -NavStickThreshold:
+		return min(d, 0) - 1
+	}
+	return 0
+}
+
+// Widget is one focusable row of a List.
+type Widget interface {
+	// Update handles the input while the widget has focus.
+	Update(nav Nav)
+	// Label and Value are the texts drawn on the left and right of the
+	// row; Value may be empty.
+	Label() string
+	Value() string
+}
+
+// Button runs OnPress when accepted.
+type Button struct {
+	Text    func() string
+	Detail  func() string // optional text on the right
+	OnPress func()
+}
+
+func (b *Button) Update(nav Nav) {
+	if nav.Accept && b.OnPress != nil {
+		b.OnPress()
+	}
+}
+
+func (b *Button) Label() string { return b.Text() }
+
+func (b *Button) Value() string {
+	if b.Detail == nil {
+		return ""
+	}
+	return b.Detail()
+}
+
+// Slider moves a value between Min and Max in Step increments with left
+// and right. Format renders the value; by default it is a percentage.
+type Slider struct {
+	Text     func() string
+	Get      func() float64
+	Set      func(v float64)
+	Min, Max float64
+	Step     float64
+	Format   func(v float64) string
+}
+
+func (s *Slider) Update(nav Nav) {
+	dir := 0.0
+	if nav.Left {
+		dir--
+	}
+	if nav.Right {
+		dir++
+	}
+	if dir == 0 {
+		return
+	}
+	v := s.Get() + dir*s.Step
+	// Snap to the step grid so repeated presses never drift, and drop
+	// float noise so saved values stay tidy.
+	v = s.Min + math.Round((v-s.Min)/s.Step)*s.Step
+	v = math.Round(v*1e6) / 1e6
+	s.Set(math.Max(s.Min, math.Min(s.Max, v)))
+}
+
+func (s *Slider) Label() string { return s.Text() }
+
+func (s *Slider) Value() string {
+	if s.Format != nil {
+		return s.Format(s.Get())
+	}
+	return percent(s.Get())
+}
+
+// Choice cycles through Count options with left, right or accept.
+type Choice struct {
+	Text   func() string
+	Count  int
+	Get    func() int
+	Set    func(i int)
+	Format func(i int) string
+}
+
+func (c *Choice) Update(nav Nav) {
+	switch {
+	case nav.Left:
+		c.Set(cycle(c.Get(), c.Count, -1))
+	case nav.Right, nav.Accept:
+		c.Set(cycle(c.Get(), c.Count, 1))
+	}
+}
+
+func (c *Choice) Label() string { return c.Text() }
+
+func (c *Choice) Value() string { return c.Format(c.Get()) }
+
+// Toggle is a two-way Choice.
+func Toggle(text func() string, get func() bool, set func(bool), onOff func(bool) string) *Choice {
+	return &Choice{
+		Text:  text,
+		Count: 2,
+		Get: func() int {
+			if get() {
+				return 1
+			}
+			return 0
+		},
+		Set:    func(i int) { set(i == 1) },
+		Format: func(i int) string { return onOff(i == 1) },
+	}
+}
+
+// List is a vertical menu of widgets. Up and down move the focus,
+// wrapping at the ends; other input goes to the focused widget. Lists
+// taller than Rows scroll to keep the focus visible.
+type List struct {
+	Items  []Widget
+	Focus  int
+	Rows   int // 0 shows every item
+	scroll int
+}
+
+// Update moves the focus or forwards nav to the focused widget.
+func (l *List) Update(nav Nav) {
+	if len(l.Items) == 0 {
+		return
+	}
+	switch {
+	case nav.Up:
+		l.Focus = cycle(l.Focus, len(l.Items), -1)
+	case nav.Down:
+		l.Focus = cycle(l.Focus, len(l.Items), 1)
+	default:
+		l.Items[l.Focus].Update(nav)
+	}
+	if l.Rows > 0 {
+		l.scroll = max(min(l.scroll, l.Focus), l.Focus-l.Rows+1)
+	}
+}
+
+// Visible returns the index range of the items on screen.
+func (l *List) Visible() (first, end int) {
+	if l.Rows <= 0 || len(l.Items) <= l.Rows {
+		return 0, len(l.Items)
+	}
+	return l.scroll, l.scroll + l.Rows
+}
+
+// ListStyle sets how a List is drawn.
+type ListStyle struct {
+	Face         font.Face
+	Width        float64 // row width; values are right-aligned within it
+	Color, Focus color.Color
+}
+
+// Draw draws the visible rows centred horizontally with their tops from
+// y downwards. Rows without a value have their label centred.
+func (l *List) Draw(dst *ebiten.Image, y float64, st ListStyle) {
+	rowH := lineHeight(st.Face) + 6
+	left := (ScreenWidth - st.Width) / 2
+	first, end := l.Visible()
+	for i := first; i < end; i++ {
+		w := l.Items[i]
+		clr := st.Color
+		label := w.Label()
+		if i == l.Focus {
+			clr = st.Focus
+			label = "> " + label
+		}
+		ry := y + float64(i-first)*rowH
+		value := w.Value()
+		if value == "" {
+			lw, _ := MeasureText(st.Face, label)
+			DrawText(dst, label, st.Face, (ScreenWidth-lw)/2, ry, AlignLeft, clr)
+			continue
+		}
+		DrawText(dst, label, st.Face, left, ry, AlignLeft, clr)
+		vw, _ := MeasureText(st.Face, value)
+		DrawText(dst, value, st.Face, left+st.Width-vw, ry, AlignLeft, clr)
+	}
+}
+
+var pixel *ebiten.Image
+
+// fillRect fills a rectangle with clr at the given opacity.
+func fillRect(dst *ebiten.Image, x, y, w, h float64, clr color.Color, alpha float64) {
+	if pixel == nil {
+		pixel = ebiten.NewImage(1, 1)
+		pixel.Fill(color.White)
+	}
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(w, h)
+	op.GeoM.Translate(x, y)
+	op.ColorScale.ScaleWithColor(clr)
+	op.ColorScale.ScaleAlpha(float32(alpha))
+	dst.DrawImage(pixel, op)
+}
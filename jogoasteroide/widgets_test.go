@@ -0,0 +1,97 @@
+package main
+
+import "testing"
+
+func TestListNavigation(t *testing.T) {
+	pressed := -1
+	l := List{Rows: 2}
+	for i := 0; i < 4; i++ {
+		i := i
+		l.Items = append(l.Items, &Button{Text: func() string { return "" }, OnPress: func() { pressed = i }})
+	}
+
+	l.Update(Nav{Up: true})
+	if l.Focus != 3 {
+		t.Errorf("Focus = %d; esperado 3 ao subir do primeiro", l.Focus)
+	}
+	if first, end := l.Visible(); first != 2 || end != 4 {
+		t.Errorf("Visible() = %d, %d; esperado 2, 4", first, end)
+	}
+	l.Update(Nav{Down: true})
+	l.Update(Nav{Down: true})
+	if first, _ := l.Visible(); l.Focus != 1 || first != 0 {
+		t.Errorf("Focus = %d, primeiro visível = %d; esperado 1 e 0", l.Focus, first)
+	}
+	l.Update(Nav{Accept: true})
+	if pressed != 1 {
+		t.Errorf("botão %d acionado; esperado 1", pressed)
+	}
+}
+
+func TestSlider(t *testing.T) {
+	v := 0.0
+	s := &Slider{Get: func() float64 { return v }, Set: func(x float64) { v = x }, Max: 1, Step: 0.1}
+	tests := []struct {
+		nav  Nav
+		want float64
+	}{
+		{Nav{Left: true}, 0},
+		{Nav{Right: true}, 0.1},
+		{Nav{Right: true}, 0.2},
+		{Nav{Right: true}, 0.3},
+		{Nav{Accept: true}, 0.3},
+	}
+	for _, tt := range tests {
+		s.Update(tt.nav)
+		if v != tt.want {
+			t.Errorf("valor = %v; esperado %v", v, tt.want)
+		}
+	}
+	v = 0.95
+	s.Update(Nav{Right: true})
+	if v != 1 {
+		t.Errorf("valor = %v; esperado 1 no máximo", v)
+	}
+}
+
+func TestChoiceWraps(t *testing.T) {
+	i := 0
+	c := &Choice{Count: 3, Get: func() int { return i }, Set: func(x int) { i = x }}
+	c.Update(Nav{Left: true})
+	if i != 2 {
+		t.Errorf("índice = %d; esperado 2", i)
+	}
+	c.Update(Nav{Accept: true})
+	if i != 0 {
+		t.Errorf("índice = %d; esperado 0", i)
+	}
+}
+
+func TestNavRepeat(t *testing.T) {
+	tests := []struct {
+		frames int
+		want   bool
+	}{
+		{0, false},
+		{1, true},
+		{2, false},
+		{NavRepeatDelay, true},
+		{NavRepeatDelay + 1, false},
+		{NavRepeatDelay + NavRepeatInterval, true},
+	}
+	for _, tt := range tests {
+		if got := repeats(tt.frames); got != tt.want {
+			t.Errorf("repeats(%d) = %v; esperado %v", tt.frames, got, tt.want)
+		}
+	}
+	d := 0
+	for i := 0; i < 3; i++ {
+		d = stickDuration(d, -0.9)
+	}
+	if d != -3 {
+		t.Errorf("stickDuration = %d; esperado -3", d)
+	}
+	if d = stickDuration(d, 0.9); d != 1 {
+		t.Errorf("stickDuration ao inverter = %d; esperado 1", d)
+	}
+}